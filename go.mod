@@ -3,6 +3,7 @@ module example.com/gobot-simulator
 go 1.16
 
 require (
-	github.com/sirupsen/logrus v1.8.1 // indirect
-	github.com/t-tomalak/logrus-easy-formatter v0.0.0-20190827215021-c074f06c5816 // indirect
+	github.com/sirupsen/logrus v1.8.1
+	github.com/t-tomalak/logrus-easy-formatter v0.0.0-20190827215021-c074f06c5816
+	gopkg.in/yaml.v3 v3.0.1
 )
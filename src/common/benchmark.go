@@ -0,0 +1,130 @@
+package common
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	BenchmarkBuyAndHold   = "BuyAndHold"
+	BenchmarkShortAndHold = "ShortAndHold"
+	BenchmarkDCA          = "DCA"
+
+	dcaIntervalSeconds = 24 * 3600 // DCA buys once a day
+)
+
+// Benchmark is the equity of a passive strategy computed on the same symbol data
+// as the simulation, one value per tick.
+type Benchmark struct {
+	Name       string
+	Timestamps []int64
+	Equity     []float64
+}
+
+type BenchmarkMetrics struct {
+	Name             string  `json:"name"`
+	ReturnPerc       float64 `json:"returnPerc"`
+	MaxDrawdownPerc  float64 `json:"maxDrawdownPerc"`
+	ExcessReturnPerc float64 `json:"excessReturnPerc"` // strategy return minus benchmark return
+	Alpha            float64 `json:"alpha"`            // annualized, from daily returns
+	Beta             float64 `json:"beta"`
+}
+
+func (m BenchmarkMetrics) String() string {
+	return fmt.Sprintf("%s: return %.2f%%, max drawdown %.2f%%, excess return %.2f%%, alpha %.4f, beta %.4f",
+		m.Name, m.ReturnPerc, m.MaxDrawdownPerc, m.ExcessReturnPerc, m.Alpha, m.Beta)
+}
+
+// NewBenchmarks computes buy and hold, short and hold and daily DCA equity series
+// sized on the given initial balance.
+func NewBenchmarks(data *SymbolData, initialBalance float64) []Benchmark {
+	if len(data.Data) == 0 {
+		return []Benchmark{}
+	}
+	n := len(data.Data)
+	timestamps := make([]int64, n)
+	for i, tick := range data.Data {
+		timestamps[i] = tick.Time.Unix()
+	}
+	buyAndHold := Benchmark{Name: BenchmarkBuyAndHold, Timestamps: timestamps, Equity: make([]float64, n)}
+	shortAndHold := Benchmark{Name: BenchmarkShortAndHold, Timestamps: timestamps, Equity: make([]float64, n)}
+	dca := Benchmark{Name: BenchmarkDCA, Timestamps: timestamps, Equity: make([]float64, n)}
+
+	p0 := data.Data[0].Price
+	size := initialBalance / p0
+
+	// DCA spends the same amount at the start of every interval
+	intervals := (timestamps[n-1]-timestamps[0])/dcaIntervalSeconds + 1
+	dcaAmount := initialBalance / float64(intervals)
+	cash := initialBalance
+	holdings := 0.0
+	nextBuy := timestamps[0]
+
+	for i, tick := range data.Data {
+		buyAndHold.Equity[i] = initialBalance + size*(tick.Price-p0)
+		shortAndHold.Equity[i] = initialBalance - size*(tick.Price-p0)
+
+		if timestamps[i] >= nextBuy && cash > 0 {
+			amount := math.Min(dcaAmount, cash)
+			holdings += amount / tick.Price
+			cash -= amount
+			nextBuy += dcaIntervalSeconds
+		}
+		dca.Equity[i] = cash + holdings*tick.Price
+	}
+
+	return []Benchmark{buyAndHold, shortAndHold, dca}
+}
+
+// CompareWithBenchmarks computes excess return, alpha and beta of the strategy
+// equity with respect to each benchmark.
+func CompareWithBenchmarks(history []SimulatorStatus, benchmarks []Benchmark) []BenchmarkMetrics {
+	metrics := make([]BenchmarkMetrics, 0, len(benchmarks))
+	if len(history) == 0 {
+		return metrics
+	}
+	timestamps := historyTimestamps(history)
+	equity := historyEquity(history)
+	strategyReturn := (equity[len(equity)-1]/equity[0] - 1) * 100
+	strategyDaily := DailyReturns(timestamps, equity)
+
+	for _, b := range benchmarks {
+		if len(b.Equity) == 0 {
+			continue
+		}
+		m := BenchmarkMetrics{
+			Name:            b.Name,
+			ReturnPerc:      (b.Equity[len(b.Equity)-1]/b.Equity[0] - 1) * 100,
+			MaxDrawdownPerc: MaxDrawdownPerc(b.Equity),
+		}
+		m.ExcessReturnPerc = strategyReturn - m.ReturnPerc
+		m.Alpha, m.Beta = alphaBeta(strategyDaily, DailyReturns(b.Timestamps, b.Equity))
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+// alphaBeta regresses the strategy returns on the benchmark returns; alpha is
+// annualized assuming daily returns.
+func alphaBeta(strategyReturns []float64, benchmarkReturns []float64) (float64, float64) {
+	n := len(strategyReturns)
+	if len(benchmarkReturns) < n {
+		n = len(benchmarkReturns)
+	}
+	if n < 2 {
+		return 0, 0
+	}
+	meanS, _ := MeanStd(strategyReturns[:n])
+	meanB, _ := MeanStd(benchmarkReturns[:n])
+	cov, variance := 0.0, 0.0
+	for i := 0; i < n; i++ {
+		cov += (strategyReturns[i] - meanS) * (benchmarkReturns[i] - meanB)
+		variance += (benchmarkReturns[i] - meanB) * (benchmarkReturns[i] - meanB)
+	}
+	if variance == 0 {
+		return meanS * 365, 0
+	}
+	beta := cov / variance
+	alpha := (meanS - beta*meanB) * 365
+	return alpha, beta
+}
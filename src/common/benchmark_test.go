@@ -0,0 +1,95 @@
+package common
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNewBenchmarks(t *testing.T) {
+	tests := []struct {
+		name   string
+		prices []float64
+		step   time.Duration
+		want   map[string][]float64
+	}{
+		{
+			name:   "empty data",
+			prices: nil,
+			want:   map[string][]float64{},
+		},
+		{
+			name:   "within a day DCA buys at once",
+			prices: []float64{100, 110, 90},
+			step:   time.Hour,
+			want: map[string][]float64{
+				BenchmarkBuyAndHold:   {1000, 1100, 900},
+				BenchmarkShortAndHold: {1000, 900, 1100},
+				BenchmarkDCA:          {1000, 1100, 900},
+			},
+		},
+		{
+			name:   "DCA buys once a day",
+			prices: []float64{100, 50, 100},
+			step:   24 * time.Hour,
+			want: map[string][]float64{
+				BenchmarkBuyAndHold:   {1000, 500, 1000},
+				BenchmarkShortAndHold: {1000, 1500, 1000},
+				BenchmarkDCA:          {1000, 1000.0/3 + 500, 4000.0 / 3},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := &SymbolData{}
+			for i, price := range tt.prices {
+				data.Data = append(data.Data, SymbolDataItem{Time: time.Unix(0, 0).Add(time.Duration(i) * tt.step), Price: price})
+			}
+			benchmarks := NewBenchmarks(data, 1000)
+			if len(benchmarks) != len(tt.want) {
+				t.Fatalf("got %d benchmarks, want %d", len(benchmarks), len(tt.want))
+			}
+			for _, b := range benchmarks {
+				want := tt.want[b.Name]
+				if len(b.Equity) != len(want) {
+					t.Fatalf("%s: got %v, want %v", b.Name, b.Equity, want)
+				}
+				for i := range want {
+					if math.Abs(b.Equity[i]-want[i]) > 1e-9 {
+						t.Errorf("%s: got %v, want %v", b.Name, b.Equity, want)
+						break
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCompareWithBenchmarks(t *testing.T) {
+	history := []SimulatorStatus{{Timestamp: 0, Equity: 1000}, {Timestamp: 3600, Equity: 1050}}
+	benchmarks := []Benchmark{
+		{Name: BenchmarkBuyAndHold, Timestamps: []int64{0, 3600}, Equity: []float64{1000, 1200}},
+		{Name: BenchmarkShortAndHold, Timestamps: []int64{0, 3600}, Equity: []float64{1000, 800}},
+		{Name: "empty"},
+	}
+	tests := []struct {
+		name     string
+		excess   float64
+		drawdown float64
+	}{
+		{BenchmarkBuyAndHold, -15, 0},
+		{BenchmarkShortAndHold, 25, 20},
+	}
+	metrics := CompareWithBenchmarks(history, benchmarks)
+	if len(metrics) != len(tests) {
+		t.Fatalf("got %d metrics, want %d", len(metrics), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := metrics[i]
+			if m.Name != tt.name || math.Abs(m.ExcessReturnPerc-tt.excess) > 1e-9 || math.Abs(m.MaxDrawdownPerc-tt.drawdown) > 1e-9 {
+				t.Errorf("got %s", m.String())
+			}
+		})
+	}
+}
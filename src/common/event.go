@@ -0,0 +1,25 @@
+package common
+
+import "fmt"
+
+const (
+	EventGridReanchor = "GRID_REANCHOR"
+	EventGridTrail    = "GRID_TRAIL"
+	EventEntryBlocked = "ENTRY_BLOCKED" // a new cycle waits for the entry conditions
+	EventEntryAllowed = "ENTRY_ALLOWED"
+	EventRisk         = "RISK" // a rule of the risk manager has been triggered
+)
+
+// SimulatorEvent is a notable action taken during a run (e.g. a grid re-anchor),
+// kept in the results next to the status history.
+type SimulatorEvent struct {
+	Date      string
+	Timestamp int64
+	Symbol    string // empty if the run has one symbol
+	Type      string
+	Message   string
+}
+
+func (e SimulatorEvent) String() string {
+	return fmt.Sprintf("%s [%s] %s", e.Date, e.Type, e.Message)
+}
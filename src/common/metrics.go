@@ -0,0 +1,308 @@
+package common
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type Metrics struct {
+	InitialEquity   float64 `json:"initialEquity"`
+	FinalEquity     float64 `json:"finalEquity"`
+	NetProfit       float64 `json:"netProfit"`
+	ReturnPerc      float64 `json:"returnPerc"`
+	MaxDrawdownPerc float64 `json:"maxDrawdownPerc"`
+	Sharpe          float64 `json:"sharpe"`
+	Calmar          float64 `json:"calmar"`
+	Fills           int     `json:"fills"`
+	Cycles          int     `json:"cycles"`
+	MaxGridReached  int64   `json:"maxGridReached"`
+	Reanchors       int     `json:"reanchors"`
+	IdleHours       float64 `json:"idleHours"` // waiting for the entry conditions, summed over the position sides
+}
+
+func (m Metrics) String() string {
+	str := fmt.Sprintf("net profit %.2f, return %.2f%%, max drawdown %.2f%%, sharpe %.2f, calmar %.2f, cycles %d, max grid %d, re-anchors %d",
+		m.NetProfit, m.ReturnPerc, m.MaxDrawdownPerc, m.Sharpe, m.Calmar, m.Cycles, m.MaxGridReached, m.Reanchors)
+	if m.IdleHours > 0 {
+		str += fmt.Sprintf(", idle %.1fh", m.IdleHours)
+	}
+	return str
+}
+
+// MetricName is the JSON name of a metric used to rank runs
+type MetricName string
+
+const (
+	MetricNetProfit   MetricName = "netProfit"
+	MetricReturn      MetricName = "returnPerc"
+	MetricMaxDrawdown MetricName = "maxDrawdownPerc"
+	MetricSharpe      MetricName = "sharpe"
+	MetricCalmar      MetricName = "calmar"
+)
+
+// Score returns the metric so that higher is better, the max drawdown being
+// negated.
+func (m Metrics) Score(name MetricName) float64 {
+	switch name {
+	case MetricNetProfit:
+		return m.NetProfit
+	case MetricReturn:
+		return m.ReturnPerc
+	case MetricMaxDrawdown:
+		return -m.MaxDrawdownPerc
+	case MetricSharpe:
+		return m.Sharpe
+	case MetricCalmar:
+		return m.Calmar
+	default:
+		log.Panicf("Unknown metric %s", name)
+		return 0
+	}
+}
+
+// MetricNames returns the JSON names of all the metrics
+func MetricNames() []string {
+	t := reflect.TypeOf(Metrics{})
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		names = append(names, strings.Split(t.Field(i).Tag.Get("json"), ",")[0])
+	}
+	return names
+}
+
+// Value returns a metric by JSON name, false if there is no such metric
+func (m Metrics) Value(name string) (float64, bool) {
+	v := reflect.ValueOf(m)
+	for i := 0; i < v.NumField(); i++ {
+		if strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0] != name {
+			continue
+		}
+		switch field := v.Field(i); field.Kind() {
+		case reflect.Int, reflect.Int64:
+			return float64(field.Int()), true
+		default:
+			return field.Float(), true
+		}
+	}
+	return 0, false
+}
+
+// metricRows are the rows of Table: JSON name of the metric, label and format
+var metricRows = [][3]string{
+	{"initialEquity", "Initial equity", "%.2f"},
+	{"finalEquity", "Final equity", "%.2f"},
+	{"netProfit", "Net profit", "%.2f"},
+	{"returnPerc", "Return", "%.2f%%"},
+	{"maxDrawdownPerc", "Max drawdown", "%.2f%%"},
+	{"sharpe", "Sharpe", "%.2f"},
+	{"calmar", "Calmar", "%.2f"},
+	{"fills", "Fills", "%.0f"},
+	{"cycles", "Cycles", "%.0f"},
+	{"maxGridReached", "Max grid reached", "%.0f"},
+	{"reanchors", "Grid re-anchors", "%.0f"},
+	{"idleHours", "Idle (hours)", "%.1f"},
+}
+
+// Table returns the metrics as ordered (name, value) rows, used by reports,
+// leaving out the metrics given by JSON name.
+func (m Metrics) Table(omit ...string) [][2]string {
+	rows := make([][2]string, 0, len(metricRows))
+	for _, row := range metricRows {
+		if contains(omit, row[0]) {
+			continue
+		}
+		value, _ := m.Value(row[0])
+		rows = append(rows, [2]string{row[1], fmt.Sprintf(row[2], value)})
+	}
+	return rows
+}
+
+// ComputeMetrics evaluates the performance of a run on the mark to market equity
+// (wallet balance plus unrealized PNL of both sides).
+func ComputeMetrics(history []SimulatorStatus) Metrics {
+	m := Metrics{}
+	if len(history) == 0 {
+		return m
+	}
+
+	first := history[0]
+	last := history[len(history)-1]
+	m.InitialEquity = first.TotalEquity()
+	m.FinalEquity = last.TotalEquity()
+	m.NetProfit = m.FinalEquity - m.InitialEquity
+	if m.InitialEquity != 0 {
+		m.ReturnPerc = m.NetProfit / m.InitialEquity * 100
+	}
+
+	equity := historyEquity(history)
+	m.MaxDrawdownPerc = MaxDrawdownPerc(equity)
+
+	for _, st := range history {
+		if st.LongOrderAmount != 0 {
+			m.Fills++
+		}
+		if st.ShortOrderAmount != 0 {
+			m.Fills++
+		}
+		if st.LongGridReached > m.MaxGridReached {
+			m.MaxGridReached = st.LongGridReached
+		}
+		if st.ShortGridReached > m.MaxGridReached {
+			m.MaxGridReached = st.ShortGridReached
+		}
+	}
+	m.Cycles = len(GridDepths(history))
+
+	mean, std := MeanStd(DailyReturns(historyTimestamps(history), equity))
+	if std > 0 {
+		m.Sharpe = mean / std * math.Sqrt(365)
+	}
+	if m.MaxDrawdownPerc > 0 {
+		m.Calmar = AnnualizedReturnPerc(m.ReturnPerc, last.Timestamp-first.Timestamp) / m.MaxDrawdownPerc
+	}
+	return m
+}
+
+// ComputeSideMetrics evaluates the performance of one side of a hedge mode run,
+// as if the side had traded alone on the initial equity.
+func ComputeSideMetrics(history []SimulatorStatus, isLong bool) Metrics {
+	return ComputeMetrics(projectSide(history, isLong))
+}
+
+// projectSide returns a copy of the history where only one side is kept and the
+// wallet balance only accounts for the profits realized by that side.
+func projectSide(history []SimulatorStatus, isLong bool) []SimulatorStatus {
+	projected := make([]SimulatorStatus, len(history))
+	if len(history) == 0 {
+		return projected
+	}
+	equity := history[0].Equity
+	for i, st := range history {
+		p := SimulatorStatus{Date: st.Date, Timestamp: st.Timestamp, MarkPrice: st.MarkPrice}
+		if isLong {
+			if st.LongOrderAmount != 0 {
+				equity += st.LongRealizedProfit
+			}
+			p.LongPositionSize = st.LongPositionSize
+			p.LongEntryPrice = st.LongEntryPrice
+			p.LongGridReached = st.LongGridReached
+			p.LongOrderAmount = st.LongOrderAmount
+			p.LongOrderPrice = st.LongOrderPrice
+			p.LongRealizedProfit = st.LongRealizedProfit
+			p.LongUnrealizedPNL = st.LongUnrealizedPNL
+		} else {
+			if st.ShortOrderAmount != 0 {
+				equity += st.ShortRealizedProfit
+			}
+			p.ShortPositionSize = st.ShortPositionSize
+			p.ShortEntryPrice = st.ShortEntryPrice
+			p.ShortGridReached = st.ShortGridReached
+			p.ShortOrderAmount = st.ShortOrderAmount
+			p.ShortOrderPrice = st.ShortOrderPrice
+			p.ShortRealizedProfit = st.ShortRealizedProfit
+			p.ShortUnrealizedPNL = st.ShortUnrealizedPNL
+		}
+		p.Equity = equity
+		projected[i] = p
+	}
+	return projected
+}
+
+// AnnualizedReturnPerc linearly scales a return obtained over the given number
+// of seconds to one year.
+func AnnualizedReturnPerc(returnPerc float64, seconds int64) float64 {
+	if seconds <= 0 {
+		return 0
+	}
+	return returnPerc * float64(365*24*time.Hour/time.Second) / float64(seconds)
+}
+
+// DailyReturns samples the equity series once a day and returns the relative
+// change between consecutive samples.
+func DailyReturns(timestamps []int64, equity []float64) []float64 {
+	returns := make([]float64, 0)
+	if len(equity) == 0 {
+		return returns
+	}
+	dayStartEquity := equity[0]
+	dayStart := timestamps[0]
+	for i := range equity {
+		if timestamps[i]-dayStart >= 24*3600 || i == len(equity)-1 {
+			if dayStartEquity != 0 {
+				returns = append(returns, equity[i]/dayStartEquity-1)
+			}
+			dayStartEquity = equity[i]
+			dayStart = timestamps[i]
+		}
+	}
+	return returns
+}
+
+// MaxDrawdownPerc returns the largest peak to trough decline of an equity series.
+func MaxDrawdownPerc(equity []float64) float64 {
+	maxDrawdown := 0.0
+	peak := math.Inf(-1)
+	for _, e := range equity {
+		peak = math.Max(peak, e)
+		if peak > 0 {
+			maxDrawdown = math.Max(maxDrawdown, (peak-e)/peak*100)
+		}
+	}
+	return maxDrawdown
+}
+
+func historyTimestamps(history []SimulatorStatus) []int64 {
+	timestamps := make([]int64, len(history))
+	for i, st := range history {
+		timestamps[i] = st.Timestamp
+	}
+	return timestamps
+}
+
+func historyEquity(history []SimulatorStatus) []float64 {
+	equity := make([]float64, len(history))
+	for i, st := range history {
+		equity[i] = st.TotalEquity()
+	}
+	return equity
+}
+
+// GridDepths returns the grid reached by every closed cycle (long and short),
+// a cycle being closed when the position size goes back to 0.
+func GridDepths(history []SimulatorStatus) []int64 {
+	depths := make([]int64, 0)
+	for i := 1; i < len(history); i++ {
+		prev, st := history[i-1], history[i]
+		if prev.LongPositionSize != 0 && st.LongPositionSize == 0 {
+			depths = append(depths, st.LongGridReached)
+		}
+		if prev.ShortPositionSize != 0 && st.ShortPositionSize == 0 {
+			depths = append(depths, st.ShortGridReached)
+		}
+	}
+	return depths
+}
+
+func MeanStd(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	if len(values) > 1 {
+		variance /= float64(len(values) - 1)
+	}
+	return mean, math.Sqrt(variance)
+}
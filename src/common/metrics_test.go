@@ -0,0 +1,182 @@
+package common
+
+import (
+	"math"
+	"testing"
+)
+
+func TestComputeMetrics(t *testing.T) {
+	tests := []struct {
+		name    string
+		history []SimulatorStatus
+		want    Metrics
+	}{
+		{
+			name:    "empty history",
+			history: nil,
+			want:    Metrics{},
+		},
+		{
+			name: "unrealized PNL counts in the equity",
+			history: []SimulatorStatus{
+				{Timestamp: 0, Equity: 1000},
+				{Timestamp: 3600, Equity: 1000, LongUnrealizedPNL: 60, ShortUnrealizedPNL: -10},
+			},
+			want: Metrics{InitialEquity: 1000, FinalEquity: 1050, NetProfit: 50, ReturnPerc: 5},
+		},
+		{
+			name: "drawdown and calmar",
+			history: []SimulatorStatus{
+				{Timestamp: 0, Equity: 1000},
+				{Timestamp: 3600, Equity: 1200},
+				{Timestamp: 7200, Equity: 900},
+				{Timestamp: 10800, Equity: 1100},
+			},
+			want: Metrics{InitialEquity: 1000, FinalEquity: 1100, NetProfit: 100, ReturnPerc: 10, MaxDrawdownPerc: 25,
+				Calmar: AnnualizedReturnPerc(10, 10800) / 25},
+		},
+		{
+			name: "sharpe from daily returns",
+			history: []SimulatorStatus{
+				{Timestamp: 0, Equity: 1000},
+				{Timestamp: 86400, Equity: 1100},
+				{Timestamp: 172800, Equity: 1155},
+			},
+			want: Metrics{InitialEquity: 1000, FinalEquity: 1155, NetProfit: 155, ReturnPerc: 15.5,
+				Sharpe: 0.075 / math.Sqrt(2*0.025*0.025) * math.Sqrt(365)},
+		},
+		{
+			name: "fills, cycles and grid reached of both sides",
+			history: []SimulatorStatus{
+				{Timestamp: 0, Equity: 1000, LongOrderAmount: 1, LongPositionSize: 1, LongGridReached: 1, ShortOrderAmount: 1, ShortPositionSize: 1, ShortGridReached: 1},
+				{Timestamp: 1, Equity: 1000, LongOrderAmount: 2, LongPositionSize: 3, LongGridReached: 2, ShortPositionSize: 1, ShortGridReached: 1},
+				{Timestamp: 2, Equity: 1000, LongOrderAmount: 3, LongPositionSize: 0, LongGridReached: 2, ShortPositionSize: 1, ShortGridReached: 1},
+			},
+			want: Metrics{InitialEquity: 1000, FinalEquity: 1000, Fills: 4, Cycles: 1, MaxGridReached: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ComputeMetrics(tt.history)
+			for _, row := range metricRows {
+				g, _ := got.Value(row[0])
+				w, _ := tt.want.Value(row[0])
+				if math.Abs(g-w) > 1e-9 {
+					t.Errorf("%s %g, want %g", row[0], g, w)
+				}
+			}
+		})
+	}
+}
+
+func TestComputeSideMetrics(t *testing.T) {
+	history := []SimulatorStatus{
+		{Timestamp: 0, Equity: 1000},
+		{Timestamp: 1, Equity: 1030, LongOrderAmount: 1, LongRealizedProfit: 50, ShortOrderAmount: 1, ShortRealizedProfit: -20},
+	}
+	tests := []struct {
+		name   string
+		isLong bool
+		profit float64
+	}{
+		{"long side", true, 50},
+		{"short side", false, -20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := ComputeSideMetrics(history, tt.isLong)
+			if m.NetProfit != tt.profit || m.Fills != 1 {
+				t.Errorf("got net profit %g, fills %d, want %g, 1", m.NetProfit, m.Fills, tt.profit)
+			}
+		})
+	}
+}
+
+func TestMeanStd(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		mean   float64
+		std    float64
+	}{
+		{"empty", nil, 0, 0},
+		{"single value", []float64{3}, 3, 0},
+		{"sample standard deviation", []float64{2, 4, 4, 4, 5, 5, 7, 9}, 5, math.Sqrt(32.0 / 7)},
+		{"constant", []float64{1, 1, 1}, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mean, std := MeanStd(tt.values)
+			if math.Abs(mean-tt.mean) > 1e-12 || math.Abs(std-tt.std) > 1e-12 {
+				t.Errorf("got %g, %g, want %g, %g", mean, std, tt.mean, tt.std)
+			}
+		})
+	}
+}
+
+func TestGridDepths(t *testing.T) {
+	tests := []struct {
+		name    string
+		history []SimulatorStatus
+		want    []int64
+	}{
+		{"empty", nil, []int64{}},
+		{
+			name: "open position is not a cycle",
+			history: []SimulatorStatus{
+				{LongPositionSize: 1, LongGridReached: 1},
+				{LongPositionSize: 2, LongGridReached: 2},
+			},
+			want: []int64{},
+		},
+		{
+			name: "long then short close",
+			history: []SimulatorStatus{
+				{LongPositionSize: 1, LongGridReached: 1, ShortPositionSize: 1, ShortGridReached: 1},
+				{LongPositionSize: 0, LongGridReached: 3, ShortPositionSize: 2, ShortGridReached: 2},
+				{LongPositionSize: 1, LongGridReached: 1, ShortPositionSize: 0, ShortGridReached: 2},
+			},
+			want: []int64{3, 2},
+		},
+		{
+			name: "both sides closing together",
+			history: []SimulatorStatus{
+				{LongPositionSize: 1, LongGridReached: 4, ShortPositionSize: 1, ShortGridReached: 5},
+				{LongPositionSize: 0, LongGridReached: 4, ShortPositionSize: 0, ShortGridReached: 5},
+			},
+			want: []int64{4, 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GridDepths(tt.history)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMaxDrawdownPerc(t *testing.T) {
+	tests := []struct {
+		name   string
+		equity []float64
+		want   float64
+	}{
+		{"empty", nil, 0},
+		{"rising", []float64{1, 2, 3}, 0},
+		{"largest of two drawdowns", []float64{100, 80, 120, 60, 130}, 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MaxDrawdownPerc(tt.equity); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("got %g, want %g", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package common
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+type PathMethod string
+
+const (
+	PathBlockBootstrap PathMethod = "BLOCK_BOOTSTRAP" // blocks of returns drawn with replacement
+	PathRandomStart    PathMethod = "RANDOM_START"    // window of the data starting at a random time
+	PathNoise          PathMethod = "NOISE"           // gaussian noise added to every log price
+)
+
+// BlockBootstrap returns a price path of the same length and timestamps as d,
+// rebuilt from the first price with blocks of blockSize consecutive log returns
+// of d drawn with replacement. Blocks keep the volatility clustering of the
+// data, the volume follows the returns.
+func BlockBootstrap(d *SymbolData, blockSize int, random *rand.Rand) *SymbolData {
+	n := len(d.Data)
+	path := &SymbolData{Symbol: d.Symbol, StartDate: d.StartDate, EndDate: d.EndDate, Data: make([]SymbolDataItem, n)}
+	if n == 0 {
+		return path
+	}
+	if blockSize < 1 || blockSize > n-1 {
+		blockSize = int(math.Max(1, float64(n-1)))
+	}
+	path.Data[0] = d.Data[0]
+	for i := 1; i < n; {
+		start := 1 + random.Intn(int(math.Max(1, float64(n-blockSize))))
+		for k := start; k < start+blockSize && k < n && i < n; k, i = k+1, i+1 {
+			logReturn := math.Log(d.Data[k].Price / d.Data[k-1].Price)
+			path.Data[i] = SymbolDataItem{Time: d.Data[i].Time, Price: path.Data[i-1].Price * math.Exp(logReturn), Volume: d.Data[k].Volume}
+		}
+	}
+	return path
+}
+
+// RandomStart returns the window of d of the given length starting at a random
+// time, sharing the items with d
+func RandomStart(d *SymbolData, length time.Duration, random *rand.Rand) (*SymbolData, error) {
+	if len(d.Data) < 2 {
+		return nil, fmt.Errorf("not enough data")
+	}
+	first, last := d.Data[0].Time, d.Data[len(d.Data)-1].Time
+	span := last.Sub(first)
+	if length <= 0 || length > span {
+		return nil, fmt.Errorf("window length %s out of the data span %s", length, span)
+	}
+	start := first.Add(time.Duration(random.Int63n(int64(span-length) + 1)))
+	return d.Slice(start, start.Add(length)), nil
+}
+
+// InjectNoise returns a copy of d with every price multiplied by exp(e), e
+// being normal with standard deviation sigma
+func InjectNoise(d *SymbolData, sigma float64, random *rand.Rand) *SymbolData {
+	path := &SymbolData{Symbol: d.Symbol, StartDate: d.StartDate, EndDate: d.EndDate, Data: make([]SymbolDataItem, len(d.Data))}
+	for i, item := range d.Data {
+		item.Price *= math.Exp(random.NormFloat64() * sigma)
+		path.Data[i] = item
+	}
+	return path
+}
+
+// Distribution summarizes a sample
+type Distribution struct {
+	Mean   float64 `json:"mean"`
+	Std    float64 `json:"std"`
+	Min    float64 `json:"min"`
+	P5     float64 `json:"p5"`
+	P25    float64 `json:"p25"`
+	Median float64 `json:"median"`
+	P75    float64 `json:"p75"`
+	P95    float64 `json:"p95"`
+	Max    float64 `json:"max"`
+}
+
+func NewDistribution(values []float64) Distribution {
+	if len(values) == 0 {
+		return Distribution{}
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	d := Distribution{Min: sorted[0], Max: sorted[len(sorted)-1],
+		P5: Percentile(sorted, 5), P25: Percentile(sorted, 25), Median: Percentile(sorted, 50), P75: Percentile(sorted, 75), P95: Percentile(sorted, 95)}
+	d.Mean, d.Std = MeanStd(values)
+	return d
+}
+
+func (d Distribution) String() string {
+	return fmt.Sprintf("mean %.2f, std %.2f, min %.2f, p5 %.2f, median %.2f, p95 %.2f, max %.2f", d.Mean, d.Std, d.Min, d.P5, d.Median, d.P95, d.Max)
+}
+
+// Percentile interpolates the p-th percentile of sorted values
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	i := int(math.Floor(rank))
+	if i >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (rank-float64(i))*(sorted[i+1]-sorted[i])
+}
+
+// MonteCarloPath is the run of the strategy on one path
+type MonteCarloPath struct {
+	Index      int     `json:"index"`
+	Metrics    Metrics `json:"metrics"`
+	MinEquity  float64 `json:"minEquity"`
+	Ruined     bool    `json:"ruined"`     // lost the ruin share of the initial equity
+	Liquidated bool    `json:"liquidated"` // equity down to zero
+	Error      string  `json:"error,omitempty"`
+}
+
+// MonteCarloResult is the distribution of the runs of a strategy on the paths
+type MonteCarloResult struct {
+	Label                  string           `json:"label"`
+	Method                 PathMethod       `json:"method"`
+	RuinLossPerc           float64          `json:"ruinLossPerc"`
+	Historical             Metrics          `json:"historical"` // on the original data
+	Paths                  []MonteCarloPath `json:"paths"`
+	Failed                 int              `json:"failed"` // runs that panicked, not in the distributions
+	Return                 Distribution     `json:"return"`
+	MaxDrawdown            Distribution     `json:"maxDrawdown"`
+	RuinProbability        float64          `json:"ruinProbability"`
+	LiquidationProbability float64          `json:"liquidationProbability"`
+}
+
+// Summarize computes the distributions and probabilities from the paths
+func (r *MonteCarloResult) Summarize() {
+	returns, drawdowns := make([]float64, 0, len(r.Paths)), make([]float64, 0, len(r.Paths))
+	ruined, liquidated := 0, 0
+	r.Failed = 0
+	for _, p := range r.Paths {
+		if p.Error != "" {
+			r.Failed++
+			continue
+		}
+		returns = append(returns, p.Metrics.ReturnPerc)
+		drawdowns = append(drawdowns, p.Metrics.MaxDrawdownPerc)
+		if p.Ruined {
+			ruined++
+		}
+		if p.Liquidated {
+			liquidated++
+		}
+	}
+	r.Return, r.MaxDrawdown = NewDistribution(returns), NewDistribution(drawdowns)
+	if len(returns) > 0 {
+		r.RuinProbability = float64(ruined) / float64(len(returns))
+		r.LiquidationProbability = float64(liquidated) / float64(len(returns))
+	}
+}
+
+func (r *MonteCarloResult) String() string {
+	return fmt.Sprintf("%s, %d %s paths (%d failed): return %s; max drawdown %s; ruin (-%.0f%%) %.1f%%, liquidation %.1f%%",
+		r.Label, len(r.Paths), r.Method, r.Failed, r.Return.String(), r.MaxDrawdown.String(), r.RuinLossPerc, r.RuinProbability*100, r.LiquidationProbability*100)
+}
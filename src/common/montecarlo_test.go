@@ -0,0 +1,87 @@
+package common
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestBlockBootstrap(t *testing.T) {
+	t0 := time.Unix(1620000000, 0)
+	data := &SymbolData{Symbol: "DOGE"}
+	prices := []float64{100, 101, 99, 104, 103, 108, 107, 110}
+	returns := make(map[float64]bool)
+	for i, price := range prices {
+		data.Data = append(data.Data, SymbolDataItem{Time: t0.Add(time.Duration(i) * time.Second), Price: price, Volume: float64(i)})
+		if i > 0 {
+			returns[roundReturn(math.Log(price/prices[i-1]))] = true
+		}
+	}
+
+	tests := []struct {
+		name      string
+		blockSize int
+		identical bool // the single block is the whole data
+	}{
+		{"single returns", 1, false},
+		{"blocks of 3", 3, false},
+		{"block size 0 is the whole data", 0, true},
+		{"block size longer than the data", 100, true},
+		{"block of every return", len(prices) - 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := BlockBootstrap(data, tt.blockSize, rand.New(rand.NewSource(1)))
+			if len(path.Data) != len(data.Data) || path.Symbol != data.Symbol {
+				t.Fatalf("got %d items of %s, want %d of %s", len(path.Data), path.Symbol, len(data.Data), data.Symbol)
+			}
+			if path.Data[0] != data.Data[0] {
+				t.Errorf("got first item %v, want %v", path.Data[0], data.Data[0])
+			}
+			for i := 1; i < len(path.Data); i++ {
+				if !path.Data[i].Time.Equal(data.Data[i].Time) {
+					t.Errorf("item %d at %s, want %s", i, path.Data[i].Time, data.Data[i].Time)
+				}
+				if r := roundReturn(math.Log(path.Data[i].Price / path.Data[i-1].Price)); !returns[r] {
+					t.Errorf("item %d has a log return %g not in the data", i, r)
+				}
+				if tt.identical && math.Abs(path.Data[i].Price-data.Data[i].Price) > 1e-9 {
+					t.Errorf("item %d price %g, want %g", i, path.Data[i].Price, data.Data[i].Price)
+				}
+			}
+		})
+	}
+
+	if path := BlockBootstrap(&SymbolData{}, 3, rand.New(rand.NewSource(1))); len(path.Data) != 0 {
+		t.Errorf("got %d items from empty data", len(path.Data))
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 5}
+	tests := []struct {
+		name   string
+		sorted []float64
+		p      float64
+		want   float64
+	}{
+		{"empty", nil, 50, 0},
+		{"min", sorted, 0, 1},
+		{"median", sorted, 50, 3},
+		{"interpolated", sorted, 10, 1.4},
+		{"max", sorted, 100, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Percentile(tt.sorted, tt.p); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("got %g, want %g", got, tt.want)
+			}
+		})
+	}
+}
+
+// roundReturn drops the rounding errors of rebuilding the prices
+func roundReturn(r float64) float64 {
+	return math.Round(r*1e9) / 1e9
+}
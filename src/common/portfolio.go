@@ -0,0 +1,311 @@
+package common
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// MultiSymbolTick holds the prices of every symbol of a portfolio at one time
+type MultiSymbolTick struct {
+	Time   time.Time
+	Prices map[string]float64
+}
+
+// AlignSymbolData merges the tick streams of several symbols on the union of
+// their timestamps, forward filling the last known price of each symbol. The
+// stream starts at the first time every symbol has a price.
+func AlignSymbolData(data map[string]*SymbolData) []MultiSymbolTick {
+	symbols := make([]string, 0, len(data))
+	for symbol := range data {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	ticks := make([]MultiSymbolTick, 0)
+	indexes := make(map[string]int, len(symbols))
+	last := make(map[string]float64, len(symbols))
+	for {
+		// next timestamp among all the streams
+		var next time.Time
+		found := false
+		for _, symbol := range symbols {
+			i := indexes[symbol]
+			if i < len(data[symbol].Data) && (!found || data[symbol].Data[i].Time.Before(next)) {
+				next = data[symbol].Data[i].Time
+				found = true
+			}
+		}
+		if !found {
+			break
+		}
+
+		for _, symbol := range symbols {
+			items := data[symbol].Data
+			for indexes[symbol] < len(items) && !items[indexes[symbol]].Time.After(next) {
+				last[symbol] = items[indexes[symbol]].Price
+				indexes[symbol]++
+			}
+		}
+		if len(last) < len(symbols) {
+			continue
+		}
+
+		prices := make(map[string]float64, len(symbols))
+		for symbol, price := range last {
+			prices[symbol] = price
+		}
+		ticks = append(ticks, MultiSymbolTick{Time: next, Prices: prices})
+	}
+	return ticks
+}
+
+type SymbolStatus struct {
+	Symbol            string
+	MarkPrice         float64
+	LongPositionSize  float64
+	ShortPositionSize float64
+	RealizedProfit    float64 // cumulative, both sides
+	UnrealizedPNL     float64 // both sides
+}
+
+type PortfolioStatus struct {
+	Date      string
+	Timestamp int64
+	Equity    float64 // shared wallet balance
+	Symbols   []SymbolStatus
+}
+
+func (st PortfolioStatus) TotalEquity() float64 {
+	equity := st.Equity
+	for _, s := range st.Symbols {
+		equity += s.UnrealizedPNL
+	}
+	return equity
+}
+
+type PortfolioResult struct {
+	statusHistory []PortfolioStatus
+	events        []SimulatorEvent
+	allocations   map[string]float64 // capital allocated to every symbol at start
+	idleSeconds   map[string]float64 // by symbol
+}
+
+func NewPortfolioResult() *PortfolioResult {
+	return &PortfolioResult{
+		statusHistory: make([]PortfolioStatus, 0),
+		events:        make([]SimulatorEvent, 0),
+		allocations:   make(map[string]float64),
+		idleSeconds:   make(map[string]float64),
+	}
+}
+
+func (r *PortfolioResult) Reset(allocations map[string]float64) {
+	r.statusHistory = make([]PortfolioStatus, 0)
+	r.events = make([]SimulatorEvent, 0)
+	r.allocations = allocations
+	r.idleSeconds = make(map[string]float64)
+}
+
+func (r *PortfolioResult) Append(status PortfolioStatus) {
+	r.statusHistory = append(r.statusHistory, status)
+}
+
+func (r *PortfolioResult) AppendEvent(event SimulatorEvent) {
+	r.events = append(r.events, event)
+}
+
+// AddIdleTime records time spent by a strategy of the symbol waiting for the
+// entry conditions
+func (r *PortfolioResult) AddIdleTime(symbol string, seconds float64) {
+	r.idleSeconds[symbol] += seconds
+}
+
+func (r *PortfolioResult) Events() []SimulatorEvent {
+	return r.events
+}
+
+func (r *PortfolioResult) History() []PortfolioStatus {
+	return r.statusHistory
+}
+
+func (r *PortfolioResult) Symbols() []string {
+	symbols := make([]string, 0)
+	if len(r.statusHistory) > 0 {
+		for _, s := range r.statusHistory[0].Symbols {
+			symbols = append(symbols, s.Symbol)
+		}
+	}
+	return symbols
+}
+
+// Metrics evaluates the whole portfolio on the mark to market equity
+func (r *PortfolioResult) Metrics() Metrics {
+	history := make([]SimulatorStatus, len(r.statusHistory))
+	for i, st := range r.statusHistory {
+		history[i] = SimulatorStatus{Date: st.Date, Timestamp: st.Timestamp, Equity: st.TotalEquity()}
+	}
+	m := ComputeMetrics(history)
+	// fills and cycles are not tracked at portfolio level
+	m.Fills, m.Cycles, m.MaxGridReached = 0, 0, 0
+	for _, symbol := range r.Symbols() {
+		m.Reanchors += r.countEvents(symbol, EventGridReanchor)
+		m.IdleHours += r.idleSeconds[symbol] / 3600
+	}
+	return m
+}
+
+// SymbolEquity returns the equity curve of one symbol: the capital allocated to
+// it plus its realized and unrealized profits.
+func (r *PortfolioResult) SymbolEquity(symbol string) ([]int64, []float64) {
+	timestamps := make([]int64, len(r.statusHistory))
+	equity := make([]float64, len(r.statusHistory))
+	for i, st := range r.statusHistory {
+		timestamps[i] = st.Timestamp
+		for _, s := range st.Symbols {
+			if s.Symbol == symbol {
+				equity[i] = r.allocations[symbol] + s.RealizedProfit + s.UnrealizedPNL
+			}
+		}
+	}
+	return timestamps, equity
+}
+
+func (r *PortfolioResult) SymbolMetrics(symbol string) Metrics {
+	timestamps, equity := r.SymbolEquity(symbol)
+	history := make([]SimulatorStatus, len(equity))
+	for i := range equity {
+		history[i] = SimulatorStatus{Timestamp: timestamps[i], Equity: equity[i]}
+	}
+	m := ComputeMetrics(history)
+	m.Fills, m.Cycles, m.MaxGridReached = 0, 0, 0
+	m.Reanchors = r.countEvents(symbol, EventGridReanchor)
+	m.IdleHours = r.idleSeconds[symbol] / 3600
+	return m
+}
+
+// DrawdownCorrelation returns the correlation matrix of the symbols drawdown
+// curves: values close to 1 mean that the symbols lose money at the same time.
+func (r *PortfolioResult) DrawdownCorrelation() [][]float64 {
+	symbols := r.Symbols()
+	drawdowns := make([][]float64, len(symbols))
+	for i, symbol := range symbols {
+		_, equity := r.SymbolEquity(symbol)
+		drawdowns[i] = DrawdownSeries(equity)
+	}
+	corr := make([][]float64, len(symbols))
+	for i := range symbols {
+		corr[i] = make([]float64, len(symbols))
+		for j := range symbols {
+			corr[i][j] = Correlation(drawdowns[i], drawdowns[j])
+		}
+	}
+	return corr
+}
+
+func (r *PortfolioResult) WriteToFile(filepath string) error {
+	file, err := os.OpenFile(filepath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	datawriter := bufio.NewWriter(file)
+	header := "Date,Timestamp,Equity,TotalEquity"
+	for _, symbol := range r.Symbols() {
+		header += fmt.Sprintf(",MarkPrice-%s,PositionSize-L-%s,PositionSize-S-%s,GrossProfit-%s,PNL-%s", symbol, symbol, symbol, symbol, symbol)
+	}
+	_, err = datawriter.WriteString(header + "\n")
+	if err != nil {
+		log.Error("Error writing header of file")
+	}
+
+	for N, st := range r.statusHistory {
+		// reduce output file size with 1 min discretization
+		if N%60 != 0 {
+			continue
+		}
+		line := fmt.Sprintf("%s,%d,%f,%f", st.Date, st.Timestamp, st.Equity, st.TotalEquity())
+		for _, s := range st.Symbols {
+			line += fmt.Sprintf(",%f,%f,%f,%f,%f", s.MarkPrice, s.LongPositionSize, s.ShortPositionSize, s.RealizedProfit, s.UnrealizedPNL)
+		}
+		_, err = datawriter.WriteString(line + "\n")
+		if err != nil {
+			log.Error("Error writing status to result file")
+		}
+	}
+	datawriter.Flush()
+	return nil
+}
+
+func (r *PortfolioResult) WriteEventsToFile(filepath string) error {
+	file, err := os.OpenFile(filepath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	datawriter := csv.NewWriter(file)
+	if err := datawriter.Write([]string{"Date", "Timestamp", "Symbol", "Type", "Message"}); err != nil {
+		log.Error("Error writing header of file")
+	}
+	for _, e := range r.events {
+		if err := datawriter.Write([]string{e.Date, strconv.FormatInt(e.Timestamp, 10), e.Symbol, e.Type, e.Message}); err != nil {
+			log.Error("Error writing event to result file")
+		}
+	}
+	datawriter.Flush()
+	return datawriter.Error()
+}
+
+// DrawdownSeries returns the relative distance (in %, negative) of every equity
+// value from the running peak.
+func DrawdownSeries(equity []float64) []float64 {
+	dd := make([]float64, len(equity))
+	peak := math.Inf(-1)
+	for i, e := range equity {
+		peak = math.Max(peak, e)
+		if peak > 0 {
+			dd[i] = (e - peak) / peak * 100
+		}
+	}
+	return dd
+}
+
+func Correlation(x []float64, y []float64) float64 {
+	n := len(x)
+	if len(y) < n {
+		n = len(y)
+	}
+	if n < 2 {
+		return 0
+	}
+	meanX, stdX := MeanStd(x[:n])
+	meanY, stdY := MeanStd(y[:n])
+	if stdX == 0 || stdY == 0 {
+		return 0
+	}
+	cov := 0.0
+	for i := 0; i < n; i++ {
+		cov += (x[i] - meanX) * (y[i] - meanY)
+	}
+	return cov / float64(n-1) / (stdX * stdY)
+}
+
+// PRIVATE METHODS
+func (r *PortfolioResult) countEvents(symbol string, eventType string) int {
+	count := 0
+	for _, e := range r.events {
+		if e.Symbol == symbol && e.Type == eventType {
+			count++
+		}
+	}
+	return count
+}
@@ -2,8 +2,10 @@ package common
 
 import (
 	"bufio"
+	"encoding/csv"
 	"fmt"
 	"os"
+	"strconv"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -33,13 +35,22 @@ type SimulatorStatus struct {
 	ShortUnrealizedPNL  float64
 }
 
+func (st SimulatorStatus) TotalEquity() float64 {
+	return st.Equity + st.LongUnrealizedPNL + st.ShortUnrealizedPNL
+}
+
 type SimulatorResult struct {
 	statusHistory []SimulatorStatus
+	events        []SimulatorEvent
+	benchmarks    []Benchmark
+	idleSeconds   map[bool]float64 // by side, true for the long one
 }
 
 func NewSimulatorResult() *SimulatorResult {
 	return &SimulatorResult{
 		statusHistory: make([]SimulatorStatus, 0),
+		events:        make([]SimulatorEvent, 0),
+		idleSeconds:   make(map[bool]float64),
 	}
 }
 
@@ -47,6 +58,102 @@ func (s *SimulatorResult) Append(status SimulatorStatus) {
 	s.statusHistory = append(s.statusHistory, status)
 }
 
+func (s *SimulatorResult) AppendEvent(event SimulatorEvent) {
+	s.events = append(s.events, event)
+}
+
+func (s *SimulatorResult) Reset() {
+	s.statusHistory = make([]SimulatorStatus, 0)
+	s.events = make([]SimulatorEvent, 0)
+	s.idleSeconds = make(map[bool]float64)
+}
+
+// AddIdleTime records time spent by a side waiting for the entry conditions
+func (s *SimulatorResult) AddIdleTime(isLong bool, seconds float64) {
+	s.idleSeconds[isLong] += seconds
+}
+
+func (s *SimulatorResult) History() []SimulatorStatus {
+	return s.statusHistory
+}
+
+// SetBenchmarks sets the benchmark series, expected to have one value per status.
+func (s *SimulatorResult) SetBenchmarks(benchmarks []Benchmark) {
+	s.benchmarks = benchmarks
+}
+
+func (s *SimulatorResult) Benchmarks() []Benchmark {
+	return s.benchmarks
+}
+
+func (s *SimulatorResult) BenchmarkMetrics() []BenchmarkMetrics {
+	return CompareWithBenchmarks(s.statusHistory, s.benchmarks)
+}
+
+func (s *SimulatorResult) Events() []SimulatorEvent {
+	return s.events
+}
+
+func (s *SimulatorResult) CountEvents(eventType string) int {
+	count := 0
+	for _, e := range s.events {
+		if e.Type == eventType {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *SimulatorResult) Metrics() Metrics {
+	m := ComputeMetrics(s.statusHistory)
+	m.Reanchors = s.CountEvents(EventGridReanchor)
+	for _, seconds := range s.idleSeconds {
+		m.IdleHours += seconds / 3600
+	}
+	return m
+}
+
+func (s *SimulatorResult) LongMetrics() Metrics {
+	m := ComputeSideMetrics(s.statusHistory, true)
+	m.IdleHours = s.idleSeconds[true] / 3600
+	return m
+}
+
+func (s *SimulatorResult) ShortMetrics() Metrics {
+	m := ComputeSideMetrics(s.statusHistory, false)
+	m.IdleHours = s.idleSeconds[false] / 3600
+	return m
+}
+
+// IsHedged returns true if both the long and the short side have been traded.
+func (s *SimulatorResult) IsHedged() bool {
+	long, short := false, false
+	for _, st := range s.statusHistory {
+		long = long || st.LongOrderAmount != 0
+		short = short || st.ShortOrderAmount != 0
+		if long && short {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary returns the metrics of the run together with an equity curve reduced
+// to at most maxPoints points, small enough to be kept for every run of a sweep.
+func (s *SimulatorResult) Summary(label string, maxPoints int) RunSummary {
+	summary := RunSummary{
+		Label:      label,
+		Metrics:    s.Metrics(),
+		Benchmarks: s.BenchmarkMetrics(),
+	}
+	for _, i := range DownsampleIndexes(len(s.statusHistory), maxPoints) {
+		st := s.statusHistory[i]
+		summary.Timestamps = append(summary.Timestamps, st.Timestamp)
+		summary.Equity = append(summary.Equity, st.TotalEquity())
+	}
+	return summary
+}
+
 func (s *SimulatorResult) WriteToFile(filepath string) error {
 	file, err := os.OpenFile(filepath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if err != nil {
@@ -55,7 +162,16 @@ func (s *SimulatorResult) WriteToFile(filepath string) error {
 	defer file.Close()
 
 	datawriter := bufio.NewWriter(file)
-	_, err = datawriter.WriteString("Date,Timestamp,MarkPrice,Equity,PositionSize-L,EntryPrice-L,GridReached-L,OrderSize-L,OrderPrice-L,GrossProfit-L,PNL-L,PositionSize-S,EntryPrice-S,GridReached-S,OrderSize-S,OrderPrice-S,GrossProfit-S,PNL-S\n")
+	// benchmark columns are appended only if they are aligned with the history
+	benchmarks := make([]Benchmark, 0)
+	header := "Date,Timestamp,MarkPrice,Equity,PositionSize-L,EntryPrice-L,GridReached-L,OrderSize-L,OrderPrice-L,GrossProfit-L,PNL-L,PositionSize-S,EntryPrice-S,GridReached-S,OrderSize-S,OrderPrice-S,GrossProfit-S,PNL-S"
+	for _, b := range s.benchmarks {
+		if len(b.Equity) == len(s.statusHistory) {
+			benchmarks = append(benchmarks, b)
+			header += "," + b.Name
+		}
+	}
+	_, err = datawriter.WriteString(header + "\n")
 	if err != nil {
 		log.Error("Error writing header of file")
 	}
@@ -66,10 +182,14 @@ func (s *SimulatorResult) WriteToFile(filepath string) error {
 			continue
 		}
 
-		_, err = datawriter.WriteString(fmt.Sprintf("%s,%d,%f,%f,%f,%f,%d,%f,%f,%f,%f,%f,%f,%d,%f,%f,%f,%f\n",
+		line := fmt.Sprintf("%s,%d,%f,%f,%f,%f,%d,%f,%f,%f,%f,%f,%f,%d,%f,%f,%f,%f",
 			st.Date, st.Timestamp, st.MarkPrice, st.Equity,
 			st.LongPositionSize, st.LongEntryPrice, st.LongGridReached, st.LongOrderAmount, st.LongOrderPrice, st.LongRealizedProfit, st.LongUnrealizedPNL,
-			st.ShortPositionSize, st.ShortEntryPrice, st.ShortGridReached, st.ShortOrderAmount, st.ShortOrderPrice, st.ShortRealizedProfit, st.ShortUnrealizedPNL))
+			st.ShortPositionSize, st.ShortEntryPrice, st.ShortGridReached, st.ShortOrderAmount, st.ShortOrderPrice, st.ShortRealizedProfit, st.ShortUnrealizedPNL)
+		for _, b := range benchmarks {
+			line += fmt.Sprintf(",%f", b.Equity[N])
+		}
+		_, err = datawriter.WriteString(line + "\n")
 		if err != nil {
 			log.Error("Error writing status to result file")
 		}
@@ -78,6 +198,54 @@ func (s *SimulatorResult) WriteToFile(filepath string) error {
 	return nil
 }
 
+type RunSummary struct {
+	Label      string             `json:"label"`
+	Metrics    Metrics            `json:"metrics"`
+	Benchmarks []BenchmarkMetrics `json:"benchmarks"`
+	Timestamps []int64            `json:"timestamps"`
+	Equity     []float64          `json:"equity"`
+}
+
+// DownsampleIndexes returns evenly spaced indexes of a series of length n,
+// always including the last one.
+func DownsampleIndexes(n int, maxPoints int) []int {
+	indexes := make([]int, 0)
+	if n == 0 {
+		return indexes
+	}
+	step := 1
+	if maxPoints > 0 && n > maxPoints {
+		step = (n + maxPoints - 1) / maxPoints
+	}
+	for i := 0; i < n; i += step {
+		indexes = append(indexes, i)
+	}
+	if indexes[len(indexes)-1] != n-1 {
+		indexes = append(indexes, n-1)
+	}
+	return indexes
+}
+
+func (s *SimulatorResult) WriteEventsToFile(filepath string) error {
+	file, err := os.OpenFile(filepath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	datawriter := csv.NewWriter(file)
+	if err := datawriter.Write([]string{"Date", "Timestamp", "Type", "Message"}); err != nil {
+		log.Error("Error writing header of file")
+	}
+	for _, e := range s.events {
+		if err := datawriter.Write([]string{e.Date, strconv.FormatInt(e.Timestamp, 10), e.Type, e.Message}); err != nil {
+			log.Error("Error writing event to result file")
+		}
+	}
+	datawriter.Flush()
+	return datawriter.Error()
+}
+
 func (s *SimulatorResult) Performance() float64 {
 	first := s.statusHistory[0]
 	last := s.statusHistory[len(s.statusHistory)-1]
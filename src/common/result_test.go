@@ -0,0 +1,52 @@
+package common
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteEventsToFile(t *testing.T) {
+	result := NewSimulatorResult()
+	result.AppendEvent(SimulatorEvent{Date: "2024-01-01", Timestamp: 1704067200, Type: EventGridReanchor, Message: `re-anchored at 1,5: "drift"`})
+	result.AppendEvent(SimulatorEvent{Date: "2024-01-02", Timestamp: 1704153600, Type: EventRisk, Message: "FLATTEN: DRAWDOWN 12.00 beyond 10.00"})
+	path := filepath.Join(t.TempDir(), "events.csv")
+	if err := result.WriteEventsToFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	got, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"Date", "Timestamp", "Type", "Message"},
+		{"2024-01-01", "1704067200", EventGridReanchor, `re-anchored at 1,5: "drift"`},
+		{"2024-01-02", "1704153600", EventRisk, "FLATTEN: DRAWDOWN 12.00 beyond 10.00"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestReanchorsMetric(t *testing.T) {
+	result := NewSimulatorResult()
+	result.Append(SimulatorStatus{Equity: 1000})
+	for _, eventType := range []string{EventGridReanchor, EventEntryBlocked, EventGridReanchor} {
+		result.AppendEvent(SimulatorEvent{Type: eventType})
+	}
+	if got := result.Metrics().Reanchors; got != 2 {
+		t.Errorf("got %d re-anchors, want 2", got)
+	}
+	result.Reset()
+	if got := result.Metrics().Reanchors; got != 0 {
+		t.Errorf("got %d re-anchors after a reset, want 0", got)
+	}
+}
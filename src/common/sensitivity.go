@@ -0,0 +1,290 @@
+package common
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// sensitivityLevels is the maximum number of levels of a parameter, the values
+// of continuous ones (e.g. sampled by a random search) being binned by quantiles
+const sensitivityLevels = 10
+
+// SweepPoint is a completed run of a sweep with its parameters by name
+type SweepPoint struct {
+	Label      string             `json:"label"`
+	Parameters map[string]float64 `json:"parameters"`
+	Metrics    Metrics            `json:"metrics"`
+}
+
+// Heatmap is the score of the runs for every pair of levels of two parameters,
+// the rows following Y and the columns X. Cells without any run have a zero
+// count.
+type Heatmap struct {
+	X       string      `json:"x"`
+	Y       string      `json:"y"`
+	XValues []float64   `json:"xValues"`
+	YValues []float64   `json:"yValues"`
+	Scores  [][]float64 `json:"scores"`
+	Counts  [][]int     `json:"counts"`
+}
+
+// SensitivityCurve is the score of the runs for every level of a parameter, the
+// mean, min and max over the runs sharing the level.
+type SensitivityCurve struct {
+	Parameter string    `json:"parameter"`
+	Values    []float64 `json:"values"`
+	Mean      []float64 `json:"mean"`
+	Min       []float64 `json:"min"`
+	Max       []float64 `json:"max"`
+	Counts    []int     `json:"counts"`
+}
+
+// Robustness compares the score of a candidate with the one of its neighbours,
+// the runs at most one level away on every parameter of the sweep. A good
+// result on a plateau keeps most of its score around it, a lonely spike does
+// not.
+type Robustness struct {
+	Label         string  `json:"label"`
+	Score         float64 `json:"score"`
+	Neighbours    int     `json:"neighbours"`
+	NeighbourMean float64 `json:"neighbourMean"`
+	NeighbourMin  float64 `json:"neighbourMin"`
+	Robust        float64 `json:"robust"`    // mean score of the candidate and its neighbours
+	Retention     float64 `json:"retention"` // neighbour mean over the candidate score, 0 if the latter is not positive
+}
+
+func (r Robustness) String() string {
+	return fmt.Sprintf("%s: score %.4f, %d neighbours mean %.4f min %.4f, robust %.4f, retention %.2f",
+		r.Label, r.Score, r.Neighbours, r.NeighbourMean, r.NeighbourMin, r.Robust, r.Retention)
+}
+
+// SensitivityResult is the sensitivity of the score of a sweep to its
+// parameters. Scores are higher for better runs, see Metrics.Score.
+type SensitivityResult struct {
+	Metric     MetricName         `json:"metric"`
+	Fixed      bool               `json:"fixed"`      // other parameters fixed at the best run, marginalized otherwise
+	Best       string             `json:"best"`       // label of the best run
+	Parameters []string           `json:"parameters"` // varied by the sweep
+	Heatmaps   []Heatmap          `json:"heatmaps"`
+	Curves     []SensitivityCurve `json:"curves"`
+	Robustness []Robustness       `json:"robustness"` // of the top runs by score
+}
+
+// parameterLevels are the levels of a varied parameter, a value belonging to
+// the first level whose upper bound is not below it
+type parameterLevels struct {
+	values []float64 // shown, the median of the level
+	upper  []float64 // upper bound of the level
+}
+
+// NewSensitivityResult analyzes the runs of a sweep: a heatmap for every pair
+// of varied parameters, a curve for every varied parameter and the robustness
+// of the top runs. The parameters not shown are either fixed at the values of
+// the best run or marginalized, averaging the runs over them. Every distinct
+// value of a grid parameter is a level, the values of a parameter with more
+// than sensitivityLevels ones are binned by quantiles, shown by their median.
+func NewSensitivityResult(points []SweepPoint, metric MetricName, fixed bool, top int) SensitivityResult {
+	Metrics{}.Score(metric) // panics on unknown metrics
+	result := SensitivityResult{Metric: metric, Fixed: fixed}
+	if len(points) == 0 {
+		return result
+	}
+
+	ranked := append([]SweepPoint{}, points...)
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Metrics.Score(metric) > ranked[j].Metrics.Score(metric) })
+	best := ranked[0]
+	result.Best = best.Label
+
+	levels := make(map[string]parameterLevels)
+	for _, name := range parameterNames(points) {
+		if l := newParameterLevels(points, name); len(l.values) > 1 {
+			result.Parameters = append(result.Parameters, name)
+			levels[name] = l
+		}
+	}
+
+	for i, x := range result.Parameters {
+		result.Curves = append(result.Curves, sensitivityCurve(points, metric, x, levels[x], result.selector(best, levels, x)))
+		for _, y := range result.Parameters[i+1:] {
+			result.Heatmaps = append(result.Heatmaps, heatmap(points, metric, x, y, levels[x], levels[y], result.selector(best, levels, x, y)))
+		}
+	}
+
+	if top > len(ranked) {
+		top = len(ranked)
+	}
+	for _, candidate := range ranked[:top] {
+		result.Robustness = append(result.Robustness, robustness(points, metric, candidate, levels))
+	}
+	return result
+}
+
+// PRIVATE METHODS
+// selector returns the filter of the runs shown for the parameters, the ones
+// at the levels of the best run on the other parameters when fixed
+func (r SensitivityResult) selector(best SweepPoint, levels map[string]parameterLevels, shown ...string) func(p SweepPoint) bool {
+	if !r.Fixed {
+		return func(p SweepPoint) bool { return true }
+	}
+	return func(p SweepPoint) bool {
+		for _, name := range r.Parameters {
+			if !contains(shown, name) && levels[name].index(p.Parameters[name]) != levels[name].index(best.Parameters[name]) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func (l parameterLevels) index(value float64) int {
+	return sort.SearchFloat64s(l.upper, value)
+}
+
+// PRIVATE FUNCTIONS
+// newParameterLevels returns every distinct value of the parameter as a level,
+// or sensitivityLevels quantile bins if there are more, equal values being kept
+// in the same bin
+func newParameterLevels(points []SweepPoint, name string) parameterLevels {
+	values := make([]float64, len(points))
+	for i, p := range points {
+		values[i] = p.Parameters[name]
+	}
+	sort.Float64s(values)
+	if distinct := distinctValues(values); len(distinct) <= sensitivityLevels {
+		return parameterLevels{values: distinct, upper: distinct}
+	}
+
+	l := parameterLevels{}
+	start := 0
+	for k := 1; k <= sensitivityLevels; k++ {
+		end := k * len(values) / sensitivityLevels
+		for end > 0 && end < len(values) && values[end] == values[end-1] {
+			end++
+		}
+		if end <= start {
+			continue
+		}
+		l.values = append(l.values, values[(start+end-1)/2])
+		l.upper = append(l.upper, values[end-1])
+		start = end
+	}
+	return l
+}
+
+func sensitivityCurve(points []SweepPoint, metric MetricName, name string, levels parameterLevels, selected func(p SweepPoint) bool) SensitivityCurve {
+	n := len(levels.values)
+	c := SensitivityCurve{Parameter: name, Values: levels.values, Mean: make([]float64, n), Min: make([]float64, n), Max: make([]float64, n), Counts: make([]int, n)}
+	for _, p := range points {
+		if !selected(p) {
+			continue
+		}
+		i := levels.index(p.Parameters[name])
+		score := p.Metrics.Score(metric)
+		if c.Counts[i] == 0 || score < c.Min[i] {
+			c.Min[i] = score
+		}
+		if c.Counts[i] == 0 || score > c.Max[i] {
+			c.Max[i] = score
+		}
+		c.Mean[i] += score
+		c.Counts[i]++
+	}
+	for i := range c.Mean {
+		if c.Counts[i] > 0 {
+			c.Mean[i] /= float64(c.Counts[i])
+		}
+	}
+	return c
+}
+
+func heatmap(points []SweepPoint, metric MetricName, x string, y string, xLevels parameterLevels, yLevels parameterLevels, selected func(p SweepPoint) bool) Heatmap {
+	h := Heatmap{X: x, Y: y, XValues: xLevels.values, YValues: yLevels.values, Scores: make([][]float64, len(yLevels.values)), Counts: make([][]int, len(yLevels.values))}
+	for j := range h.YValues {
+		h.Scores[j] = make([]float64, len(h.XValues))
+		h.Counts[j] = make([]int, len(h.XValues))
+	}
+	for _, p := range points {
+		if !selected(p) {
+			continue
+		}
+		i, j := xLevels.index(p.Parameters[x]), yLevels.index(p.Parameters[y])
+		h.Scores[j][i] += p.Metrics.Score(metric)
+		h.Counts[j][i]++
+	}
+	for j := range h.Scores {
+		for i := range h.Scores[j] {
+			if h.Counts[j][i] > 0 {
+				h.Scores[j][i] /= float64(h.Counts[j][i])
+			}
+		}
+	}
+	return h
+}
+
+func robustness(points []SweepPoint, metric MetricName, candidate SweepPoint, levels map[string]parameterLevels) Robustness {
+	r := Robustness{Label: candidate.Label, Score: candidate.Metrics.Score(metric), NeighbourMin: math.Inf(1)}
+	for _, p := range points {
+		if isNeighbour(p, candidate, levels) {
+			score := p.Metrics.Score(metric)
+			r.NeighbourMean += score
+			r.NeighbourMin = math.Min(r.NeighbourMin, score)
+			r.Neighbours++
+		}
+	}
+	if r.Neighbours == 0 {
+		r.NeighbourMin = 0
+		r.Robust = r.Score
+		return r
+	}
+	r.Robust = (r.Score + r.NeighbourMean) / float64(r.Neighbours+1)
+	r.NeighbourMean /= float64(r.Neighbours)
+	if r.Score > 0 {
+		r.Retention = r.NeighbourMean / r.Score
+	}
+	return r
+}
+
+// isNeighbour returns true if p is not the candidate and is at most one level
+// away on every varied parameter, a run in the same bins of all the binned
+// parameters being a neighbour
+func isNeighbour(p SweepPoint, candidate SweepPoint, levels map[string]parameterLevels) bool {
+	same := true
+	for name, l := range levels {
+		i, j := l.index(p.Parameters[name]), l.index(candidate.Parameters[name])
+		if i-j > 1 || j-i > 1 {
+			return false
+		}
+		same = same && p.Parameters[name] == candidate.Parameters[name]
+	}
+	return !same
+}
+
+func parameterNames(points []SweepPoint) []string {
+	names := make([]string, 0)
+	for name := range points[0].Parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// distinctValues returns the distinct values of a sorted slice
+func distinctValues(sorted []float64) []float64 {
+	values := make([]float64, 0)
+	for i, v := range sorted {
+		if i == 0 || v != sorted[i-1] {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package common
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewParameterLevels(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		levels []float64
+		upper  []float64
+	}{
+		{"grid values are the levels", []float64{0.3, 0.1, 0.2, 0.1}, []float64{0.1, 0.2, 0.3}, []float64{0.1, 0.2, 0.3}},
+		{"single value", []float64{5, 5}, []float64{5}, []float64{5}},
+		{"continuous values are binned", sequence(1, 20), []float64{1, 3, 5, 7, 9, 11, 13, 15, 17, 19}, []float64{2, 4, 6, 8, 10, 12, 14, 16, 18, 20}},
+		{"equal values share a bin", append(sequence(1, 12), 12, 12, 12, 12, 12, 12, 12, 12, 12, 12),
+			[]float64{1, 3, 5, 7, 10, 12}, []float64{2, 4, 6, 8, 11, 12}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points := make([]SweepPoint, len(tt.values))
+			for i, v := range tt.values {
+				points[i] = SweepPoint{Parameters: map[string]float64{"GS": v}}
+			}
+			l := newParameterLevels(points, "GS")
+			if !equalValues(l.values, tt.levels) || !equalValues(l.upper, tt.upper) {
+				t.Errorf("got levels %v upper %v, want %v %v", l.values, l.upper, tt.levels, tt.upper)
+			}
+			for _, v := range tt.values {
+				if i := l.index(v); i >= len(l.upper) {
+					t.Errorf("value %g out of the levels", v)
+				}
+			}
+		})
+	}
+}
+
+func TestRobustnessNeighbours(t *testing.T) {
+	grid := make([]SweepPoint, 0)
+	for _, gs := range []float64{1, 2, 3} {
+		for _, ts := range []float64{1, 2, 3} {
+			grid = append(grid, SweepPoint{Parameters: map[string]float64{"GS": gs, "TS": ts}, Metrics: Metrics{NetProfit: gs + ts}})
+		}
+	}
+	continuous := make([]SweepPoint, 0)
+	for _, gs := range sequence(1, 100) {
+		continuous = append(continuous, SweepPoint{Parameters: map[string]float64{"GS": gs}, Metrics: Metrics{NetProfit: gs}})
+	}
+
+	tests := []struct {
+		name       string
+		points     []SweepPoint
+		neighbours int
+	}{
+		{"corner of the grid", grid, 3},
+		{"continuous parameter, adjacent bins", continuous, 19},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewSensitivityResult(tt.points, MetricNetProfit, false, 1)
+			if got := result.Robustness[0].Neighbours; got != tt.neighbours {
+				t.Errorf("got %d neighbours, want %d", got, tt.neighbours)
+			}
+		})
+	}
+}
+
+func sequence(from int, to int) []float64 {
+	values := make([]float64, 0)
+	for i := from; i <= to; i++ {
+		values = append(values, float64(i))
+	}
+	return values
+}
+
+func equalValues(a []float64, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -13,8 +14,9 @@ import (
 )
 
 type SymbolDataItem struct {
-	Time  time.Time
-	Price float64
+	Time   time.Time
+	Price  float64
+	Volume float64 // traded amount since the previous item, 0 if not available
 }
 
 type SymbolData struct {
@@ -24,6 +26,20 @@ type SymbolData struct {
 	Data      []SymbolDataItem
 }
 
+// Slice returns the data in [from, to), sharing the items with d
+func (d *SymbolData) Slice(from time.Time, to time.Time) *SymbolData {
+	start := sort.Search(len(d.Data), func(i int) bool { return !d.Data[i].Time.Before(from) })
+	end := sort.Search(len(d.Data), func(i int) bool { return !d.Data[i].Time.Before(to) })
+	slice := &SymbolData{Symbol: d.Symbol, Data: d.Data[start:end]}
+	if len(slice.Data) > 0 {
+		slice.StartDate = slice.Data[0].Time.UTC().String()
+		slice.EndDate = slice.Data[len(slice.Data)-1].Time.UTC().String()
+	}
+	return slice
+}
+
+// readFromFile reads a processed file, with columns index, timestamp, price and
+// optionally volume
 func (d *SymbolData) readFromFile(path string) {
 	file, err := os.Open(path)
 	failOnError(err, fmt.Sprintf("Could not open file %s", path))
@@ -40,8 +56,13 @@ func (d *SymbolData) readFromFile(path string) {
 		failOnError(err, "Error parsing timestamp")
 		price, err := strconv.ParseFloat(values[2], 64)
 		failOnError(err, "Error parsing price")
+		volume := 0.0
+		if len(values) > 3 {
+			volume, err = strconv.ParseFloat(values[3], 64)
+			failOnError(err, "Error parsing volume")
+		}
 
-		d.Data = append(d.Data, SymbolDataItem{Time: time.Unix(int64(timestamp), 0), Price: price})
+		d.Data = append(d.Data, SymbolDataItem{Time: time.Unix(int64(timestamp), 0), Price: price, Volume: volume})
 	}
 	err = scanner.Err()
 	failOnError(err, "Scanner error")
@@ -65,13 +86,13 @@ func (d *SymbolData) writeToFile(filePath string) error {
 	defer file.Close()
 
 	datawriter := bufio.NewWriter(file)
-	_, err = datawriter.WriteString("Timestamp,Price\n")
+	_, err = datawriter.WriteString("i,Timestamp,Price,Volume\n")
 	if err != nil {
 		log.Error("Error writing header of file")
 	}
 
-	for _, di := range d.Data {
-		_, err = datawriter.WriteString(fmt.Sprintf("%d,%f\n", di.Time.Unix(), di.Price))
+	for i, di := range d.Data {
+		_, err = datawriter.WriteString(fmt.Sprintf("%d,%d,%f,%f\n", i, di.Time.Unix(), di.Price, di.Volume))
 		if err != nil {
 			log.Error("Error writing status to result file")
 		}
@@ -122,6 +143,7 @@ func processTickData(filePath string) *SymbolData {
 	scanner.Scan() // skip header
 
 	lastTimestamp := 0
+	volume := 0.0 // traded since the last appended item
 	for scanner.Scan() {
 		line := scanner.Text()
 		values := strings.Split(line, ",")
@@ -131,10 +153,14 @@ func processTickData(filePath string) *SymbolData {
 		failOnError(err, "Error parsing timestamp")
 		price, err := strconv.ParseFloat(values[2], 64)
 		failOnError(err, "Error parsing price")
+		quantity, err := strconv.ParseFloat(values[3], 64)
+		failOnError(err, "Error parsing quantity")
+		volume += quantity
 
 		if timestamp >= (lastTimestamp + 1) { // append new value only if 1 second has passed
 			lastTimestamp = timestamp
-			symbolData.Data = append(symbolData.Data, SymbolDataItem{Time: time.Unix(int64(lastTimestamp), 0), Price: price})
+			symbolData.Data = append(symbolData.Data, SymbolDataItem{Time: time.Unix(int64(lastTimestamp), 0), Price: price, Volume: volume})
+			volume = 0
 		}
 	}
 	err = scanner.Err()
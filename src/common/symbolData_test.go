@@ -0,0 +1,54 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSymbolDataSlice(t *testing.T) {
+	t0 := time.Unix(1620000000, 0)
+	data := &SymbolData{Symbol: "DOGE"}
+	for i := 0; i < 5; i++ {
+		data.Data = append(data.Data, SymbolDataItem{Time: t0.Add(time.Duration(i) * time.Minute), Price: float64(i + 1)})
+	}
+	minute := func(i int) time.Time { return t0.Add(time.Duration(i) * time.Minute) }
+
+	tests := []struct {
+		name  string
+		from  time.Time
+		to    time.Time
+		first int // index in data of the first item, -1 if empty
+		count int
+	}{
+		{"whole data", minute(0), minute(5), 0, 5},
+		{"end is excluded", minute(1), minute(3), 1, 2},
+		{"from between two items", minute(1).Add(time.Second), minute(4), 2, 2},
+		{"range wider than the data", minute(-10), minute(10), 0, 5},
+		{"empty range", minute(2), minute(2), -1, 0},
+		{"after the data", minute(6), minute(8), -1, 0},
+		{"before the data", minute(-3), minute(-1), -1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slice := data.Slice(tt.from, tt.to)
+			if len(slice.Data) != tt.count {
+				t.Fatalf("got %d items, want %d", len(slice.Data), tt.count)
+			}
+			if slice.Symbol != data.Symbol {
+				t.Errorf("got symbol %s, want %s", slice.Symbol, data.Symbol)
+			}
+			if tt.count == 0 {
+				if slice.StartDate != "" || slice.EndDate != "" {
+					t.Errorf("got dates %s %s on an empty slice", slice.StartDate, slice.EndDate)
+				}
+				return
+			}
+			if &slice.Data[0] != &data.Data[tt.first] {
+				t.Errorf("first item not shared with the data")
+			}
+			if slice.StartDate != data.Data[tt.first].Time.UTC().String() || slice.EndDate != data.Data[tt.first+tt.count-1].Time.UTC().String() {
+				t.Errorf("got dates %s %s", slice.StartDate, slice.EndDate)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package common
+
+import (
+	"fmt"
+	"time"
+)
+
+// WalkForwardWindow is one step of a walk-forward optimization: the parameters
+// chosen in-sample and their result out-of-sample.
+type WalkForwardWindow struct {
+	InSampleStart  time.Time `json:"inSampleStart"`
+	InSampleEnd    time.Time `json:"inSampleEnd"`
+	OutSampleStart time.Time `json:"outSampleStart"`
+	OutSampleEnd   time.Time `json:"outSampleEnd"`
+	Label          string    `json:"label"` // strategy chosen in-sample
+	Runs           int       `json:"runs"`  // in-sample runs compared
+	InSample       Metrics   `json:"inSample"`
+	OutSample      Metrics   `json:"outSample"`
+	Efficiency     float64   `json:"efficiency"` // annualized out-of-sample return over the in-sample one
+}
+
+func (w WalkForwardWindow) String() string {
+	return fmt.Sprintf("IS %s - %s, OOS %s - %s: %s, IS return %.2f%%, OOS return %.2f%%, efficiency %.2f",
+		w.InSampleStart.UTC().Format(time.RFC3339), w.InSampleEnd.UTC().Format(time.RFC3339),
+		w.OutSampleStart.UTC().Format(time.RFC3339), w.OutSampleEnd.UTC().Format(time.RFC3339),
+		w.Label, w.InSample.ReturnPerc, w.OutSample.ReturnPerc, w.Efficiency)
+}
+
+// WalkForwardResult stitches the out-of-sample runs: every window starts from
+// the same initial balance and its profit is added to the equity of the
+// previous ones.
+type WalkForwardResult struct {
+	Metric     MetricName          `json:"metric"`
+	Windows    []WalkForwardWindow `json:"windows"`
+	Metrics    Metrics             `json:"metrics"`    // of the stitched out-of-sample equity
+	Efficiency float64             `json:"efficiency"` // annualized out-of-sample return over the mean in-sample one
+	Timestamps []int64             `json:"timestamps"`
+	Equity     []float64           `json:"equity"`
+}
+
+// StitchOutSample appends the history of an out-of-sample run to the stitched
+// one, shifting its equity so that it starts where the previous run ended.
+func StitchOutSample(stitched []SimulatorStatus, history []SimulatorStatus) []SimulatorStatus {
+	if len(history) == 0 {
+		return stitched
+	}
+	offset := 0.0
+	if len(stitched) > 0 {
+		offset = stitched[len(stitched)-1].TotalEquity() - history[0].TotalEquity()
+	}
+	for _, st := range history {
+		st.Equity += offset
+		stitched = append(stitched, st)
+	}
+	return stitched
+}
+
+// WalkForwardEfficiency returns the annualized out-of-sample return over the
+// annualized in-sample return, 0 if the latter is not positive.
+func WalkForwardEfficiency(inSample Metrics, inSampleSeconds int64, outSample Metrics, outSampleSeconds int64) float64 {
+	in := AnnualizedReturnPerc(inSample.ReturnPerc, inSampleSeconds)
+	if in <= 0 {
+		return 0
+	}
+	return AnnualizedReturnPerc(outSample.ReturnPerc, outSampleSeconds) / in
+}
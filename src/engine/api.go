@@ -12,3 +12,11 @@ type ExchangeAPI struct {
 	Position    func(PositionSideType) Position
 	CurrentTime func() time.Time
 }
+
+// Equity returns the balance plus the unrealized PNL of both position sides
+func (api *ExchangeAPI) Equity() float64 {
+	markPrice := api.MarkPrice()
+	long := api.Position(PositionSideLong)
+	short := api.Position(PositionSideShort)
+	return api.Balance() + long.PNL(markPrice) + short.PNL(markPrice)
+}
@@ -17,7 +17,7 @@ type Exchange struct {
 	sessionLong  Session
 	sessionShort Session
 
-	NotifyPositionUpdateCallback   func(Position)
+	NotifyFillCallback             func(Fill)
 	UpdateSimulationStatusCallback func(common.SimulatorStatus)
 
 	orderCounter int64 // used for order ID
@@ -33,6 +33,11 @@ func NewExchange() *Exchange {
 
 // PUBLIC METHODS
 func (e *Exchange) Init(balance float64, tick common.SymbolDataItem) {
+	// reset sessions so that the same exchange can be used for consecutive runs
+	e.sessionLong = *NewSession(PositionSideLong)
+	e.sessionShort = *NewSession(PositionSideShort)
+	e.orderCounter = 0
+
 	e.time = tick.Time
 	e.markPrice = tick.Price
 	e.balance = balance
@@ -123,83 +128,29 @@ func (e *Exchange) GetAPI() *ExchangeAPI {
 }
 
 // PRIVATE METHODS
-func (e *Exchange) getOrderToExecuteLong() *Order {
-	// TODO check max 1 order per side is executed
-	for _, o := range e.sessionLong.openOrders {
-		switch o.Type {
-		case OrderTypeMarket:
-			return &o
-		case OrderTypeLimit:
-			if (o.Side == SideBuy && e.markPrice <= o.Price) || (o.Side == SideSell && e.markPrice >= o.Price) {
-				return &o
-			}
-		case OrderTypeStop:
-			if (o.Side == SideBuy && e.markPrice >= o.TriggerPrice) || (o.Side == SideSell && e.markPrice <= o.TriggerPrice) {
-				return &o
-			}
-		case OrderTypeTrailing:
-			// TODO
-		}
+func (e *Exchange) getSession(positionSide PositionSideType) *Session {
+	if positionSide == PositionSideLong {
+		return &e.sessionLong
+	} else {
+		return &e.sessionShort
 	}
-	return nil
+}
+
+func (e *Exchange) getOrderToExecuteLong() *Order {
+	return e.sessionLong.orderToExecute(e.markPrice)
 }
 
 func (e *Exchange) getOrderToExecuteShort() *Order {
-	// TODO check max 1 order per side is executed
-	for _, o := range e.sessionShort.openOrders {
-		switch o.Type {
-		case OrderTypeMarket:
-			return &o
-		case OrderTypeLimit:
-			if (o.Side == SideSell && e.markPrice >= o.Price) || (o.Side == SideBuy && e.markPrice <= o.Price) {
-				return &o
-			}
-		case OrderTypeStop:
-			if (o.Side == SideSell && e.markPrice <= o.TriggerPrice) || (o.Side == SideBuy && e.markPrice >= o.TriggerPrice) {
-				return &o
-			}
-		case OrderTypeTrailing:
-			// TODO
-		}
-	}
-	return nil
+	return e.sessionShort.orderToExecute(e.markPrice)
 }
 
 func (e *Exchange) executeOrder(order Order) {
 	log.Debugf("Exchange: execute order %s", order.String())
-	if order.PositionSide == PositionSideLong {
-		if _, ok := e.sessionLong.openOrders[order.ID]; ok {
-			delete(e.sessionLong.openOrders, order.ID)
-		} else {
-			log.Panic("Order id not found in open orders")
-		}
-		e.sessionLong.orderAmount = order.Amount
-		e.sessionLong.orderPrice = order.Price
-		realizedProfit := e.sessionLong.position.Update(order)
-		e.sessionLong.realizedProfit = realizedProfit
-		e.balance += realizedProfit
-		if !order.IsTP { // don't update grid reached to 0 if is TP order, this is for the statistics
-			e.sessionLong.gridReached = order.GridNumber
-		}
-		log.Debugf("Exchange: updated position %s", e.sessionLong.position.String())
-		e.NotifyPositionUpdateCallback(e.sessionLong.position)
-	} else {
-		if _, ok := e.sessionShort.openOrders[order.ID]; ok {
-			delete(e.sessionShort.openOrders, order.ID)
-		} else {
-			log.Panic("Order id not found in open orders")
-		}
-		e.sessionShort.orderAmount = order.Amount
-		e.sessionShort.orderPrice = order.Price
-		realizedProfit := e.sessionShort.position.Update(order)
-		e.sessionShort.realizedProfit = realizedProfit
-		e.balance += realizedProfit
-		if !order.IsTP { // don't update grid reached to 0 if is TP order, this is for the statistics
-			e.sessionShort.gridReached = order.GridNumber
-		}
-		log.Debugf("Exchange: updated position %s", e.sessionShort.position.String())
-		e.NotifyPositionUpdateCallback(e.sessionShort.position)
-	}
+	session := e.getSession(order.PositionSide)
+	realizedProfit := session.execute(order)
+	e.balance += realizedProfit
+	log.Debugf("Exchange: updated position %s", session.position.String())
+	e.NotifyFillCallback(newFill(order, session.position, realizedProfit, e.time))
 }
 
 func (e *Exchange) placeOrder(order Order) {
@@ -209,44 +160,15 @@ func (e *Exchange) placeOrder(order Order) {
 
 	order.ID = fmt.Sprint(e.orderCounter)
 	e.orderCounter++
-	if order.PositionSide == PositionSideLong {
-		e.sessionLong.openOrders[order.ID] = order
-	} else {
-		e.sessionShort.openOrders[order.ID] = order
-	}
+	e.getSession(order.PositionSide).openOrders[order.ID] = order
 }
 
 func (e *Exchange) cancelOrder(order Order) bool {
-	if order.PositionSide == PositionSideLong {
-		if _, ok := e.sessionLong.openOrders[order.ID]; ok {
-			delete(e.sessionLong.openOrders, order.ID)
-			return true
-		} else {
-			return false
-		}
-	} else {
-		if _, ok := e.sessionShort.openOrders[order.ID]; ok {
-			delete(e.sessionShort.openOrders, order.ID)
-			return true
-		} else {
-			return false
-		}
-	}
+	return e.getSession(order.PositionSide).cancelOrder(order)
 }
 
 func (e *Exchange) getOpenOrders(positionSide PositionSideType) []Order {
-	var orders = make([]Order, 0)
-
-	if positionSide == PositionSideLong {
-		for _, order := range e.sessionLong.openOrders {
-			orders = append(orders, order)
-		}
-	} else {
-		for _, order := range e.sessionShort.openOrders {
-			orders = append(orders, order)
-		}
-	}
-	return orders
+	return e.getSession(positionSide).getOpenOrders()
 }
 
 func (e *Exchange) getMarkPrice() float64 {
@@ -258,19 +180,11 @@ func (e *Exchange) getBalance() float64 {
 }
 
 func (e *Exchange) getGridReached(positionSide PositionSideType) int64 {
-	if positionSide == PositionSideLong {
-		return e.sessionLong.gridReached
-	} else {
-		return e.sessionShort.gridReached
-	}
+	return e.getSession(positionSide).gridReached
 }
 
 func (e *Exchange) getPosition(positionSide PositionSideType) Position {
-	if positionSide == PositionSideLong {
-		return e.sessionLong.position
-	} else {
-		return e.sessionShort.position
-	}
+	return e.getSession(positionSide).position
 }
 
 func (e *Exchange) getCurrentTime() time.Time {
@@ -1,6 +1,9 @@
 package engine
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type OrderType string
 type SideType string
@@ -29,6 +32,29 @@ type Order struct {
 	IsTP         bool             `json:"isTP"`
 }
 
+// Fill is notified by the exchange every time an order is executed
+type Fill struct {
+	Order          Order
+	Price          float64 // execution price (mark price for market orders)
+	Position       Position
+	RealizedProfit float64
+	Time           time.Time
+}
+
+func newFill(order Order, position Position, realizedProfit float64, fillTime time.Time) Fill {
+	price := order.Price
+	if order.Type == OrderTypeMarket {
+		price = position.MarkPrice
+	}
+	return Fill{
+		Order:          order,
+		Price:          price,
+		Position:       position,
+		RealizedProfit: realizedProfit,
+		Time:           fillTime,
+	}
+}
+
 func (order Order) String() string {
 	switch order.Type {
 	case OrderTypeLimit:
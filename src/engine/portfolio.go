@@ -0,0 +1,184 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"example.com/gobot-simulator/src/common"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type symbolBook struct {
+	markPrice    float64
+	sessionLong  Session
+	sessionShort Session
+	profit       float64 // cumulative realized profit of both sides
+}
+
+func newSymbolBook(symbol string) *symbolBook {
+	book := &symbolBook{
+		sessionLong:  *NewSession(PositionSideLong),
+		sessionShort: *NewSession(PositionSideShort),
+	}
+	book.sessionLong.position.Symbol = symbol
+	book.sessionShort.position.Symbol = symbol
+	return book
+}
+
+func (b *symbolBook) getSession(positionSide PositionSideType) *Session {
+	if positionSide == PositionSideLong {
+		return &b.sessionLong
+	} else {
+		return &b.sessionShort
+	}
+}
+
+// PortfolioExchange simulates an account trading several symbols: every symbol
+// has its own mark price and long/short sessions, the wallet balance is shared.
+type PortfolioExchange struct {
+	time    time.Time
+	balance float64
+	symbols []string
+	books   map[string]*symbolBook
+
+	NotifyFillCallback            func(Fill)
+	UpdatePortfolioStatusCallback func(common.PortfolioStatus)
+
+	orderCounter int64 // used for order ID
+}
+
+func NewPortfolioExchange(symbols []string) *PortfolioExchange {
+	sorted := append([]string{}, symbols...)
+	sort.Strings(sorted)
+	return &PortfolioExchange{
+		symbols: sorted,
+		books:   make(map[string]*symbolBook),
+	}
+}
+
+// PUBLIC METHODS
+func (e *PortfolioExchange) Init(balance float64, tick common.MultiSymbolTick) {
+	e.time = tick.Time
+	e.balance = balance
+	e.orderCounter = 0
+	for _, symbol := range e.symbols {
+		e.books[symbol] = newSymbolBook(symbol)
+		e.setMarkPrice(symbol, tick)
+	}
+	e.UpdatePortfolioStatusCallback(e.status())
+}
+
+func (e *PortfolioExchange) Next(tick common.MultiSymbolTick) {
+	e.time = tick.Time
+	for _, symbol := range e.symbols {
+		e.setMarkPrice(symbol, tick)
+	}
+
+	// execute at most one order per symbol and side
+	for _, symbol := range e.symbols {
+		book := e.books[symbol]
+		for _, session := range []*Session{&book.sessionLong, &book.sessionShort} {
+			if order := session.orderToExecute(book.markPrice); order != nil {
+				e.executeOrder(symbol, *order)
+			}
+		}
+	}
+	e.UpdatePortfolioStatusCallback(e.status())
+}
+
+func (e *PortfolioExchange) Symbols() []string {
+	return e.symbols
+}
+
+// GetAPI returns the exchange API restricted to one symbol, so that the same
+// worker used on the single symbol exchange can trade in the portfolio.
+func (e *PortfolioExchange) GetAPI(symbol string) *ExchangeAPI {
+	if !e.hasSymbol(symbol) {
+		log.Panicf("Symbol %s not traded by the portfolio exchange", symbol)
+	}
+	return &ExchangeAPI{
+		PlaceOrder: func(order Order) {
+			order.Symbol = symbol
+			e.placeOrder(order)
+		},
+		CancelOrder: func(order Order) bool {
+			return e.books[symbol].getSession(order.PositionSide).cancelOrder(order)
+		},
+		OpenOrders: func(positionSide PositionSideType) []Order {
+			return e.books[symbol].getSession(positionSide).getOpenOrders()
+		},
+		MarkPrice: func() float64 { return e.books[symbol].markPrice },
+		Balance:   func() float64 { return e.balance },
+		GridReached: func(positionSide PositionSideType) int64 {
+			return e.books[symbol].getSession(positionSide).gridReached
+		},
+		Position: func(positionSide PositionSideType) Position {
+			return e.books[symbol].getSession(positionSide).position
+		},
+		CurrentTime: func() time.Time { return e.time },
+	}
+}
+
+// PRIVATE METHODS
+func (e *PortfolioExchange) hasSymbol(symbol string) bool {
+	for _, s := range e.symbols {
+		if s == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *PortfolioExchange) setMarkPrice(symbol string, tick common.MultiSymbolTick) {
+	price, ok := tick.Prices[symbol]
+	if !ok {
+		log.Panicf("Missing price of symbol %s at %s", symbol, tick.Time.String())
+	}
+	book := e.books[symbol]
+	book.markPrice = common.RoundFloatWithPrecision(price, 6)
+	book.sessionLong.position.MarkPrice = book.markPrice
+	book.sessionShort.position.MarkPrice = book.markPrice
+}
+
+func (e *PortfolioExchange) executeOrder(symbol string, order Order) {
+	log.Debugf("PortfolioExchange: execute order %s", order.String())
+	book := e.books[symbol]
+	session := book.getSession(order.PositionSide)
+	realizedProfit := session.execute(order)
+	book.profit += realizedProfit
+	e.balance += realizedProfit
+	e.NotifyFillCallback(newFill(order, session.position, realizedProfit, e.time))
+}
+
+func (e *PortfolioExchange) placeOrder(order Order) {
+	if order.Amount == 0 {
+		log.Panic("Order amount is 0")
+	}
+
+	order.ID = fmt.Sprint(e.orderCounter)
+	e.orderCounter++
+	e.books[order.Symbol].getSession(order.PositionSide).openOrders[order.ID] = order
+}
+
+func (e *PortfolioExchange) status() common.PortfolioStatus {
+	status := common.PortfolioStatus{
+		Date:      e.time.String(),
+		Timestamp: e.time.Unix(),
+		Equity:    e.balance,
+		Symbols:   make([]common.SymbolStatus, 0, len(e.symbols)),
+	}
+	for _, symbol := range e.symbols {
+		book := e.books[symbol]
+		status.Symbols = append(status.Symbols, common.SymbolStatus{
+			Symbol:            symbol,
+			MarkPrice:         book.markPrice,
+			LongPositionSize:  book.sessionLong.position.Size,
+			ShortPositionSize: book.sessionShort.position.Size,
+			RealizedProfit:    book.profit,
+			UnrealizedPNL:     book.sessionLong.position.PNL(book.markPrice) + book.sessionShort.position.PNL(book.markPrice),
+		})
+	}
+	return status
+}
@@ -28,32 +28,37 @@ func (p *Position) Update(order Order) float64 {
 		log.Panic("Order position side is different from position side")
 	}
 
-	// Compute entry price
-	var orderPrice float64
+	// market orders are executed at the mark price
+	orderPrice := order.Price
 	if order.Type == OrderTypeMarket {
 		orderPrice = p.MarkPrice
-	} else {
-		orderPrice = order.Price
-	}
-	p.EntryPrice = (p.EntryPrice*p.Size + orderPrice*order.Amount) / (p.Size + order.Amount)
-	if math.IsNaN(p.EntryPrice) || math.IsInf(p.EntryPrice, 0) {
-		log.Panic("Invalid entry price")
 	}
-	p.EntryPrice = common.RoundFloatWithPrecision(p.EntryPrice, 6)
 
-	// Update position size
 	if (p.PositionSide == PositionSideLong && order.Side == SideBuy) || (p.PositionSide == PositionSideShort && order.Side == SideSell) {
+		// increase position: update entry price, no realized profit
+		p.EntryPrice = (p.EntryPrice*p.Size + orderPrice*order.Amount) / (p.Size + order.Amount)
+		if math.IsNaN(p.EntryPrice) || math.IsInf(p.EntryPrice, 0) {
+			log.Panic("Invalid entry price")
+		}
+		p.EntryPrice = common.RoundFloatWithPrecision(p.EntryPrice, 6)
 		p.Size += order.Amount
 		p.Size = common.RoundFloatWithPrecision(p.Size, 6)
-		return 0 // increase position: no realized profit
+		return 0
 	} else if (p.PositionSide == PositionSideLong && order.Side == SideSell) || (p.PositionSide == PositionSideShort && order.Side == SideBuy) {
-		pnl := p.PNL(order.Price)
+		// reduce position: the entry price does not change, realized profit of the amount closed
+		pnl := (orderPrice - p.EntryPrice) * order.Amount
+		if p.PositionSide == PositionSideShort {
+			pnl = -pnl
+		}
 		p.Size -= order.Amount
 		p.Size = common.RoundFloatWithPrecision(p.Size, 6)
 		if p.Size < 0 {
 			log.Panic("Position size is negative")
 		}
-		return pnl // reduce position: return realized profit
+		if p.Size == 0 {
+			p.EntryPrice = 0
+		}
+		return pnl
 	} else {
 		log.Panic("Unexpected combination of position and order side")
 		return -1
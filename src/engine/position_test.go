@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPositionUpdate(t *testing.T) {
+	tests := []struct {
+		name     string
+		position Position
+		order    Order
+		pnl      float64
+		size     float64
+		entry    float64
+	}{
+		{
+			name:     "increase long",
+			position: Position{PositionSide: PositionSideLong, EntryPrice: 100, Size: 1, MarkPrice: 100},
+			order:    *NewOrderLimit("", SideBuy, PositionSideLong, 1, 90),
+			pnl:      0, size: 2, entry: 95,
+		},
+		{
+			name:     "open from flat",
+			position: Position{PositionSide: PositionSideLong, MarkPrice: 100},
+			order:    *NewOrderLimit("", SideBuy, PositionSideLong, 2, 100),
+			pnl:      0, size: 2, entry: 100,
+		},
+		{
+			name:     "partial reduce long keeps the entry price",
+			position: Position{PositionSide: PositionSideLong, EntryPrice: 100, Size: 2, MarkPrice: 110},
+			order:    *NewOrderLimit("", SideSell, PositionSideLong, 0.5, 110),
+			pnl:      5, size: 1.5, entry: 100,
+		},
+		{
+			name:     "full close long realizes the whole profit",
+			position: Position{PositionSide: PositionSideLong, EntryPrice: 100, Size: 2, MarkPrice: 110},
+			order:    *NewOrderLimit("", SideSell, PositionSideLong, 2, 110),
+			pnl:      20, size: 0, entry: 0,
+		},
+		{
+			name:     "market close at the mark price",
+			position: Position{PositionSide: PositionSideLong, EntryPrice: 100, Size: 1, MarkPrice: 80},
+			order:    *NewOrderMarket("", SideSell, PositionSideLong, 1),
+			pnl:      -20, size: 0, entry: 0,
+		},
+		{
+			name:     "market open at the mark price",
+			position: Position{PositionSide: PositionSideShort, MarkPrice: 50},
+			order:    *NewOrderMarket("", SideSell, PositionSideShort, 3),
+			pnl:      0, size: 3, entry: 50,
+		},
+		{
+			name:     "increase short",
+			position: Position{PositionSide: PositionSideShort, EntryPrice: 100, Size: 1, MarkPrice: 100},
+			order:    *NewOrderLimit("", SideSell, PositionSideShort, 1, 110),
+			pnl:      0, size: 2, entry: 105,
+		},
+		{
+			name:     "partial reduce short",
+			position: Position{PositionSide: PositionSideShort, EntryPrice: 100, Size: 2, MarkPrice: 90},
+			order:    *NewOrderLimit("", SideBuy, PositionSideShort, 1, 90),
+			pnl:      10, size: 1, entry: 100,
+		},
+		{
+			name:     "full close short at a loss",
+			position: Position{PositionSide: PositionSideShort, EntryPrice: 100, Size: 1, MarkPrice: 120},
+			order:    *NewOrderLimit("", SideBuy, PositionSideShort, 1, 120),
+			pnl:      -20, size: 0, entry: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.position
+			pnl := p.Update(tt.order)
+			if math.Abs(pnl-tt.pnl) > 1e-9 || math.Abs(p.Size-tt.size) > 1e-9 || math.Abs(p.EntryPrice-tt.entry) > 1e-9 {
+				t.Errorf("got pnl %g, size %g, entry %g, want pnl %g, size %g, entry %g", pnl, p.Size, p.EntryPrice, tt.pnl, tt.size, tt.entry)
+			}
+		})
+	}
+}
+
+func TestPositionUpdatePanics(t *testing.T) {
+	tests := []struct {
+		name     string
+		position Position
+		order    Order
+	}{
+		{"other position side", Position{PositionSide: PositionSideLong, Size: 1}, *NewOrderLimit("", SideSell, PositionSideShort, 1, 100)},
+		{"reduce more than the size", Position{PositionSide: PositionSideLong, EntryPrice: 100, Size: 1}, *NewOrderLimit("", SideSell, PositionSideLong, 2, 100)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			p := tt.position
+			p.Update(tt.order)
+		})
+	}
+}
+
+func TestPositionPNL(t *testing.T) {
+	long := Position{PositionSide: PositionSideLong, EntryPrice: 100, Size: 2}
+	short := Position{PositionSide: PositionSideShort, EntryPrice: 100, Size: 2}
+	if pnl := long.PNL(110); pnl != 20 {
+		t.Errorf("long PNL %g, want 20", pnl)
+	}
+	if pnl := short.PNL(110); pnl != -20 {
+		t.Errorf("short PNL %g, want -20", pnl)
+	}
+}
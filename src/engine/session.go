@@ -1,5 +1,7 @@
 package engine
 
+import log "github.com/sirupsen/logrus"
+
 type Session struct {
 	position       Position
 	openOrders     map[string]Order
@@ -15,3 +17,58 @@ func NewSession(positionSide PositionSideType) *Session {
 		openOrders: make(map[string]Order),
 	}
 }
+
+// orderToExecute returns the first open order triggered by the mark price
+func (s *Session) orderToExecute(markPrice float64) *Order {
+	// TODO check max 1 order per side is executed
+	for _, o := range s.openOrders {
+		switch o.Type {
+		case OrderTypeMarket:
+			return &o
+		case OrderTypeLimit:
+			if (o.Side == SideBuy && markPrice <= o.Price) || (o.Side == SideSell && markPrice >= o.Price) {
+				return &o
+			}
+		case OrderTypeStop:
+			if (o.Side == SideBuy && markPrice >= o.TriggerPrice) || (o.Side == SideSell && markPrice <= o.TriggerPrice) {
+				return &o
+			}
+		case OrderTypeTrailing:
+			// TODO
+		}
+	}
+	return nil
+}
+
+// execute fills the order, updates the position and returns the realized profit
+func (s *Session) execute(order Order) float64 {
+	if _, ok := s.openOrders[order.ID]; ok {
+		delete(s.openOrders, order.ID)
+	} else {
+		log.Panic("Order id not found in open orders")
+	}
+	s.orderAmount = order.Amount
+	s.orderPrice = order.Price
+	realizedProfit := s.position.Update(order)
+	s.realizedProfit = realizedProfit
+	if !order.IsTP { // don't update grid reached to 0 if is TP order, this is for the statistics
+		s.gridReached = order.GridNumber
+	}
+	return realizedProfit
+}
+
+func (s *Session) cancelOrder(order Order) bool {
+	if _, ok := s.openOrders[order.ID]; ok {
+		delete(s.openOrders, order.ID)
+		return true
+	}
+	return false
+}
+
+func (s *Session) getOpenOrders() []Order {
+	orders := make([]Order, 0, len(s.openOrders))
+	for _, order := range s.openOrders {
+		orders = append(orders, order)
+	}
+	return orders
+}
@@ -0,0 +1,217 @@
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"example.com/gobot-simulator/src/engine"
+	"example.com/gobot-simulator/src/indicator"
+	"example.com/gobot-simulator/src/strategy"
+)
+
+// Filter is an entry condition checked by the worker before a strategy starts a
+// new cycle.
+type Filter interface {
+	Start(ctx strategy.Context)                // at strategy start, e.g. to add indicators
+	Allow(ctx strategy.Context) (bool, string) // the reason if the entry is blocked
+	CycleStarted(ctx strategy.Context)         // the entry has been allowed
+	OnFill(ctx strategy.Context, fill engine.Fill)
+}
+
+// base implements the optional methods of Filter
+type base struct{}
+
+func (base) Start(ctx strategy.Context) {}
+
+func (base) CycleStarted(ctx strategy.Context) {}
+
+func (base) OnFill(ctx strategy.Context, fill engine.Fill) {}
+
+// Trend allows long cycles only with the price above its EMA and short cycles
+// only with the price below.
+type Trend struct {
+	base
+	Interval time.Duration
+	Period   int
+	name     string
+}
+
+func NewTrend(interval time.Duration, period int) *Trend {
+	return &Trend{Interval: interval, Period: period}
+}
+
+func (f *Trend) Start(ctx strategy.Context) {
+	f.name = fmt.Sprintf("filter trend EMA %d %s", f.Period, f.Interval)
+	ctx.Indicators().Add(f.name, f.Interval, indicator.NewEMA(f.Period))
+}
+
+func (f *Trend) Allow(ctx strategy.Context) (bool, string) {
+	ema := ctx.Indicators().Get(f.name)
+	if !ema.Ready() {
+		return false, "trend EMA not ready"
+	}
+	price := ctx.MarkPrice()
+	if ctx.PositionSide() == engine.PositionSideLong && price < ema.Value() {
+		return false, fmt.Sprintf("price %.6f below EMA %.6f", price, ema.Value())
+	}
+	if ctx.PositionSide() == engine.PositionSideShort && price > ema.Value() {
+		return false, fmt.Sprintf("price %.6f above EMA %.6f", price, ema.Value())
+	}
+	return true, ""
+}
+
+// Volatility allows new cycles only with the ATR, in % of the price, within
+// [Min, Max] (Max 0 for no upper limit).
+type Volatility struct {
+	base
+	Interval time.Duration
+	Period   int
+	Min      float64
+	Max      float64
+	name     string
+}
+
+func NewVolatility(interval time.Duration, period int, min float64, max float64) *Volatility {
+	return &Volatility{Interval: interval, Period: period, Min: min, Max: max}
+}
+
+func (f *Volatility) Start(ctx strategy.Context) {
+	f.name = fmt.Sprintf("filter volatility ATR %d %s", f.Period, f.Interval)
+	ctx.Indicators().Add(f.name, f.Interval, indicator.NewATR(f.Period))
+}
+
+func (f *Volatility) Allow(ctx strategy.Context) (bool, string) {
+	atr := ctx.Indicators().Get(f.name)
+	if !atr.Ready() {
+		return false, "volatility ATR not ready"
+	}
+	volatility := atr.Value() / ctx.MarkPrice() * 100
+	if volatility < f.Min || (f.Max > 0 && volatility > f.Max) {
+		return false, fmt.Sprintf("volatility %.4f%% out of [%.4f%%, %.4f%%]", volatility, f.Min, f.Max)
+	}
+	return true, ""
+}
+
+// TimeWindow allows new cycles only on the given weekdays (all if empty) and
+// between StartHour and EndHour UTC, the window wrapping around midnight if
+// EndHour <= StartHour.
+type TimeWindow struct {
+	base
+	Weekdays  []time.Weekday
+	StartHour int
+	EndHour   int
+}
+
+func NewTimeWindow(weekdays []time.Weekday, startHour int, endHour int) *TimeWindow {
+	return &TimeWindow{Weekdays: weekdays, StartHour: startHour, EndHour: endHour}
+}
+
+func (f *TimeWindow) Allow(ctx strategy.Context) (bool, string) {
+	now := ctx.Time().UTC()
+	if len(f.Weekdays) > 0 {
+		allowed := false
+		for _, day := range f.Weekdays {
+			allowed = allowed || day == now.Weekday()
+		}
+		if !allowed {
+			return false, fmt.Sprintf("%s not allowed", now.Weekday())
+		}
+	}
+	hour := now.Hour()
+	inWindow := hour >= f.StartHour && hour < f.EndHour
+	if f.EndHour <= f.StartHour {
+		inWindow = hour >= f.StartHour || hour < f.EndHour
+	}
+	if f.StartHour == f.EndHour {
+		inWindow = true
+	}
+	if !inWindow {
+		return false, fmt.Sprintf("hour %d out of [%d, %d)", hour, f.StartHour, f.EndHour)
+	}
+	return true, ""
+}
+
+// Cooldown blocks new cycles for Duration after a cycle closed at a loss
+type Cooldown struct {
+	base
+	Duration time.Duration
+	until    time.Time
+}
+
+func NewCooldown(duration time.Duration) *Cooldown {
+	return &Cooldown{Duration: duration}
+}
+
+func (f *Cooldown) Start(ctx strategy.Context) { f.until = time.Time{} }
+
+func (f *Cooldown) Allow(ctx strategy.Context) (bool, string) {
+	if ctx.Time().Before(f.until) {
+		return false, fmt.Sprintf("cooldown after a loss until %s", f.until.UTC().Format(time.RFC3339))
+	}
+	return true, ""
+}
+
+func (f *Cooldown) OnFill(ctx strategy.Context, fill engine.Fill) {
+	if fill.Position.Size == 0 && fill.RealizedProfit < 0 {
+		f.until = fill.Time.Add(f.Duration)
+	}
+}
+
+// MaxCycles allows at most Max cycles per UTC day
+type MaxCycles struct {
+	base
+	Max   int
+	day   time.Time
+	count int
+}
+
+func NewMaxCycles(max int) *MaxCycles {
+	return &MaxCycles{Max: max}
+}
+
+func (f *MaxCycles) Start(ctx strategy.Context) {
+	f.day = time.Time{}
+	f.count = 0
+}
+
+func (f *MaxCycles) Allow(ctx strategy.Context) (bool, string) {
+	if f.today(ctx) && f.count >= f.Max {
+		return false, fmt.Sprintf("%d cycles already started today", f.count)
+	}
+	return true, ""
+}
+
+func (f *MaxCycles) CycleStarted(ctx strategy.Context) {
+	if !f.today(ctx) {
+		f.day = ctx.Time().UTC().Truncate(24 * time.Hour)
+		f.count = 0
+	}
+	f.count++
+}
+
+func (f *MaxCycles) today(ctx strategy.Context) bool {
+	return f.day.Equal(ctx.Time().UTC().Truncate(24 * time.Hour))
+}
+
+// AllowAll checks every filter, returning the reasons of the blocking ones
+func AllowAll(ctx strategy.Context, filters []Filter) (bool, string) {
+	reasons := make([]string, 0)
+	for _, f := range filters {
+		if ok, reason := f.Allow(ctx); !ok {
+			reasons = append(reasons, reason)
+		}
+	}
+	return len(reasons) == 0, strings.Join(reasons, "; ")
+}
+
+// Config returns the type and the exported fields of the filter, e.g.
+// `*filter.Cooldown {"Duration":3600000000000}`, identifying its configuration
+func Config(f Filter) string {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return fmt.Sprintf("%T", f)
+	}
+	return fmt.Sprintf("%T %s", f, data)
+}
@@ -0,0 +1,195 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/gobot-simulator/src/common"
+	"example.com/gobot-simulator/src/engine"
+	"example.com/gobot-simulator/src/indicator"
+	"example.com/gobot-simulator/src/strategy"
+)
+
+// testContext implements the part of strategy.Context the filters use
+type testContext struct {
+	strategy.Context
+	now          time.Time
+	price        float64
+	positionSide engine.PositionSideType
+	indicators   *indicator.Manager
+}
+
+func newTestContext(now time.Time) *testContext {
+	return &testContext{now: now, price: 100, positionSide: engine.PositionSideLong, indicators: indicator.NewManager()}
+}
+
+func (c *testContext) Time() time.Time                       { return c.now }
+func (c *testContext) MarkPrice() float64                    { return c.price }
+func (c *testContext) PositionSide() engine.PositionSideType { return c.positionSide }
+func (c *testContext) Indicators() *indicator.Manager        { return c.indicators }
+
+// feed updates the indicators with one tick per minute from the context time
+func (c *testContext) feed(prices ...float64) {
+	for _, price := range prices {
+		c.indicators.Update(common.SymbolDataItem{Time: c.now, Price: price})
+		c.now = c.now.Add(time.Minute)
+	}
+}
+
+var monday = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func closingFill(at time.Time, size float64, profit float64) engine.Fill {
+	return engine.Fill{Position: engine.Position{Size: size}, RealizedProfit: profit, Time: at}
+}
+
+func TestCooldown(t *testing.T) {
+	tests := []struct {
+		name  string
+		fill  engine.Fill
+		after time.Duration
+		allow bool
+	}{
+		{"blocked after a loss", closingFill(monday, 0, -5), 30 * time.Minute, false},
+		{"allowed when the cooldown ends", closingFill(monday, 0, -5), time.Hour, true},
+		{"a profitable close does not block", closingFill(monday, 0, 5), 30 * time.Minute, true},
+		{"a partial close does not block", closingFill(monday, 1, -5), 30 * time.Minute, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newTestContext(monday)
+			f := NewCooldown(time.Hour)
+			f.Start(ctx)
+			f.OnFill(ctx, tt.fill)
+			ctx.now = monday.Add(tt.after)
+			if ok, reason := f.Allow(ctx); ok != tt.allow {
+				t.Errorf("got %v (%s), want %v", ok, reason, tt.allow)
+			}
+		})
+	}
+}
+
+func TestMaxCycles(t *testing.T) {
+	ctx := newTestContext(monday.Add(10 * time.Hour))
+	f := NewMaxCycles(2)
+	f.Start(ctx)
+	for i := 0; i < 2; i++ {
+		if ok, reason := f.Allow(ctx); !ok {
+			t.Fatalf("cycle %d blocked: %s", i+1, reason)
+		}
+		f.CycleStarted(ctx)
+	}
+	if ok, _ := f.Allow(ctx); ok {
+		t.Errorf("third cycle of the day allowed")
+	}
+	ctx.now = monday.Add(24 * time.Hour)
+	if ok, reason := f.Allow(ctx); !ok {
+		t.Errorf("first cycle of the next day blocked: %s", reason)
+	}
+}
+
+func TestTimeWindow(t *testing.T) {
+	tests := []struct {
+		name     string
+		weekdays []time.Weekday
+		start    int
+		end      int
+		at       time.Time
+		allow    bool
+	}{
+		{"inside the window", nil, 8, 16, monday.Add(8 * time.Hour), true},
+		{"end hour excluded", nil, 8, 16, monday.Add(16 * time.Hour), false},
+		{"wrapping around midnight, late", nil, 22, 2, monday.Add(23 * time.Hour), true},
+		{"wrapping around midnight, early", nil, 22, 2, monday.Add(time.Hour), true},
+		{"wrapping around midnight, outside", nil, 22, 2, monday.Add(12 * time.Hour), false},
+		{"equal hours allow the whole day", nil, 5, 5, monday.Add(20 * time.Hour), true},
+		{"allowed weekday", []time.Weekday{time.Monday}, 0, 0, monday, true},
+		{"weekday not allowed", []time.Weekday{time.Saturday, time.Sunday}, 0, 0, monday, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewTimeWindow(tt.weekdays, tt.start, tt.end)
+			if ok, reason := f.Allow(newTestContext(tt.at)); ok != tt.allow {
+				t.Errorf("got %v (%s), want %v", ok, reason, tt.allow)
+			}
+		})
+	}
+}
+
+func TestTrend(t *testing.T) {
+	tests := []struct {
+		name         string
+		positionSide engine.PositionSideType
+		price        float64
+		allow        bool
+	}{
+		{"long above the EMA", engine.PositionSideLong, 110, true},
+		{"long below the EMA", engine.PositionSideLong, 90, false},
+		{"short below the EMA", engine.PositionSideShort, 90, true},
+		{"short above the EMA", engine.PositionSideShort, 110, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newTestContext(monday)
+			ctx.positionSide = tt.positionSide
+			f := NewTrend(time.Minute, 2)
+			f.Start(ctx)
+			if ok, _ := f.Allow(ctx); ok {
+				t.Fatalf("allowed before the EMA is ready")
+			}
+			ctx.feed(100, 100, 100, 100)
+			ctx.price = tt.price
+			if ok, reason := f.Allow(ctx); ok != tt.allow {
+				t.Errorf("got %v (%s), want %v", ok, reason, tt.allow)
+			}
+		})
+	}
+}
+
+func TestVolatility(t *testing.T) {
+	tests := []struct {
+		name  string
+		min   float64
+		max   float64
+		allow bool
+	}{
+		{"within the range", 0, 0, true},
+		{"below the minimum", 50, 0, false},
+		{"above the maximum", 0, 0.001, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newTestContext(monday)
+			f := NewVolatility(time.Minute, 2, tt.min, tt.max)
+			f.Start(ctx)
+			if ok, _ := f.Allow(ctx); ok {
+				t.Fatalf("allowed before the ATR is ready")
+			}
+			ctx.feed(100, 102, 98, 101, 99, 100)
+			if ok, reason := f.Allow(ctx); ok != tt.allow {
+				t.Errorf("got %v (%s), want %v", ok, reason, tt.allow)
+			}
+		})
+	}
+}
+
+func TestAllowAll(t *testing.T) {
+	ctx := newTestContext(monday.Add(12 * time.Hour))
+	cooldown := NewCooldown(time.Hour)
+	cooldown.OnFill(ctx, closingFill(ctx.now, 0, -1))
+	filters := []Filter{NewTimeWindow(nil, 0, 0), cooldown, NewTimeWindow([]time.Weekday{time.Sunday}, 0, 0)}
+
+	ok, reason := AllowAll(ctx, filters)
+	if ok || !strings.Contains(reason, "cooldown") || !strings.Contains(reason, "; Monday not allowed") {
+		t.Errorf("got %v (%s), want the reasons of both blocking filters", ok, reason)
+	}
+	if ok, reason := AllowAll(ctx, filters[:1]); !ok || reason != "" {
+		t.Errorf("got %v (%s), want allowed without reasons", ok, reason)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	if got, want := Config(NewMaxCycles(3)), `*filter.MaxCycles {"Max":3}`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,136 @@
+package indicator
+
+import "math"
+
+// SMA is the simple moving average of the close
+type SMA struct {
+	window *window
+}
+
+func NewSMA(period int) *SMA {
+	checkPeriod("SMA", period)
+	return &SMA{window: newWindow(period)}
+}
+
+func (i *SMA) Update(bar Bar) { i.window.push(bar.Close) }
+
+func (i *SMA) Ready() bool { return i.window.full() }
+
+func (i *SMA) Value() float64 { return i.window.mean() }
+
+// EMA is the exponential moving average of the close, started from the SMA of
+// the first period bars.
+type EMA struct {
+	period int
+	alpha  float64
+	count  int
+	value  float64
+}
+
+func NewEMA(period int) *EMA {
+	checkPeriod("EMA", period)
+	return &EMA{period: period, alpha: 2 / float64(period+1)}
+}
+
+func (i *EMA) Update(bar Bar) {
+	i.count++
+	if i.count <= i.period {
+		i.value += (bar.Close - i.value) / float64(i.count)
+		return
+	}
+	i.value += i.alpha * (bar.Close - i.value)
+}
+
+func (i *EMA) Ready() bool { return i.count >= i.period }
+
+func (i *EMA) Value() float64 { return i.value }
+
+// StdDev is the rolling standard deviation of the close
+type StdDev struct {
+	window *window
+}
+
+func NewStdDev(period int) *StdDev {
+	checkPeriod("StdDev", period)
+	return &StdDev{window: newWindow(period)}
+}
+
+func (i *StdDev) Update(bar Bar) { i.window.push(bar.Close) }
+
+func (i *StdDev) Ready() bool { return i.window.full() }
+
+func (i *StdDev) Value() float64 { return i.window.stdDev() }
+
+// ReturnsStdDev is the rolling standard deviation of the log returns of the
+// close, in %.
+type ReturnsStdDev struct {
+	window    *window
+	prevClose float64
+}
+
+func NewReturnsStdDev(period int) *ReturnsStdDev {
+	checkPeriod("ReturnsStdDev", period)
+	return &ReturnsStdDev{window: newWindow(period)}
+}
+
+func (i *ReturnsStdDev) Update(bar Bar) {
+	if i.prevClose != 0 {
+		i.window.push(math.Log(bar.Close/i.prevClose) * 100)
+	}
+	i.prevClose = bar.Close
+}
+
+func (i *ReturnsStdDev) Ready() bool { return i.window.full() }
+
+func (i *ReturnsStdDev) Value() float64 { return i.window.stdDev() }
+
+// Bollinger are the Bollinger Bands: SMA of the close (the value) plus and minus
+// k standard deviations.
+type Bollinger struct {
+	window *window
+	k      float64
+}
+
+func NewBollinger(period int, k float64) *Bollinger {
+	checkPeriod("Bollinger", period)
+	return &Bollinger{window: newWindow(period), k: k}
+}
+
+func (i *Bollinger) Update(bar Bar) { i.window.push(bar.Close) }
+
+func (i *Bollinger) Ready() bool { return i.window.full() }
+
+func (i *Bollinger) Value() float64 { return i.window.mean() }
+
+func (i *Bollinger) Upper() float64 { return i.window.mean() + i.k*i.window.stdDev() }
+
+func (i *Bollinger) Lower() float64 { return i.window.mean() - i.k*i.window.stdDev() }
+
+// VWAP is the rolling volume weighted average of the typical price. Without
+// volume in the data the bars have the same weight.
+type VWAP struct {
+	prices  *window // typical price
+	weights *window // typical price * volume
+	volumes *window
+}
+
+func NewVWAP(period int) *VWAP {
+	checkPeriod("VWAP", period)
+	return &VWAP{prices: newWindow(period), weights: newWindow(period), volumes: newWindow(period)}
+}
+
+func (i *VWAP) Update(bar Bar) {
+	typical := (bar.High + bar.Low + bar.Close) / 3
+	i.prices.push(typical)
+	i.weights.push(typical * bar.Volume)
+	i.volumes.push(bar.Volume)
+}
+
+func (i *VWAP) Ready() bool { return i.prices.full() }
+
+func (i *VWAP) Value() float64 {
+	if i.volumes.sum <= 0 {
+		return i.prices.mean()
+	}
+	return i.weights.sum / i.volumes.sum
+}
@@ -0,0 +1,59 @@
+package indicator
+
+import (
+	"math"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Bar is the candle of an interval, built from the ticks
+type Bar struct {
+	Start  time.Time
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume float64
+}
+
+// BarBuilder aggregates the ticks into bars of a fixed interval, aligned to the clock
+type BarBuilder struct {
+	interval time.Duration
+	current  Bar
+	started  bool
+}
+
+func NewBarBuilder(interval time.Duration) *BarBuilder {
+	if interval <= 0 {
+		log.Panicf("Invalid bar interval %s, must be positive", interval)
+	}
+	return &BarBuilder{interval: interval}
+}
+
+func (b *BarBuilder) Interval() time.Duration { return b.interval }
+
+// Update adds a tick and returns the bar closed by it, if any
+func (b *BarBuilder) Update(t time.Time, price float64, volume float64) (Bar, bool) {
+	start := t.Truncate(b.interval)
+	if !b.started {
+		b.open(start, price, volume)
+		return Bar{}, false
+	}
+	if start.After(b.current.Start) {
+		closed := b.current
+		b.open(start, price, volume)
+		return closed, true
+	}
+	b.current.High = math.Max(b.current.High, price)
+	b.current.Low = math.Min(b.current.Low, price)
+	b.current.Close = price
+	b.current.Volume += volume
+	return Bar{}, false
+}
+
+// PRIVATE METHODS
+func (b *BarBuilder) open(start time.Time, price float64, volume float64) {
+	b.started = true
+	b.current = Bar{Start: start, Open: price, High: price, Low: price, Close: price, Volume: volume}
+}
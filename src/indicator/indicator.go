@@ -0,0 +1,70 @@
+package indicator
+
+import (
+	"sort"
+	"time"
+
+	"example.com/gobot-simulator/src/common"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Indicator is updated with every closed bar, in constant time
+type Indicator interface {
+	Update(bar Bar)
+	Ready() bool // enough bars to compute the value
+	Value() float64
+}
+
+// Manager updates the indicators of a strategy from the ticks, each one on the
+// bars of its own interval.
+type Manager struct {
+	builders   map[time.Duration]*BarBuilder
+	byInterval map[time.Duration][]Indicator
+	intervals  []time.Duration
+	byName     map[string]Indicator
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		builders:   make(map[time.Duration]*BarBuilder),
+		byInterval: make(map[time.Duration][]Indicator),
+		byName:     make(map[string]Indicator),
+	}
+}
+
+// Add registers the indicator under name, updated on bars of interval, and returns it.
+// If an indicator is already registered under name, it is returned instead.
+func (m *Manager) Add(name string, interval time.Duration, indicator Indicator) Indicator {
+	if existing, ok := m.byName[name]; ok {
+		return existing
+	}
+	if interval <= 0 {
+		log.Panicf("Invalid interval %s for indicator %s", interval, name)
+	}
+	if _, ok := m.builders[interval]; !ok {
+		m.builders[interval] = NewBarBuilder(interval)
+		m.intervals = append(m.intervals, interval)
+		sort.Slice(m.intervals, func(i, j int) bool { return m.intervals[i] < m.intervals[j] })
+	}
+	m.byInterval[interval] = append(m.byInterval[interval], indicator)
+	m.byName[name] = indicator
+	return indicator
+}
+
+// Get returns the indicator added under name, nil if none
+func (m *Manager) Get(name string) Indicator {
+	return m.byName[name]
+}
+
+func (m *Manager) Update(tick common.SymbolDataItem) {
+	for _, interval := range m.intervals {
+		bar, closed := m.builders[interval].Update(tick.Time, tick.Price, tick.Volume)
+		if !closed {
+			continue
+		}
+		for _, indicator := range m.byInterval[interval] {
+			indicator.Update(bar)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package indicator
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"example.com/gobot-simulator/src/common"
+)
+
+func TestIndicators(t *testing.T) {
+	tests := []struct {
+		name      string
+		indicator Indicator
+		bars      []Bar
+		ready     bool
+		value     float64
+	}{
+		{"SMA not ready", NewSMA(3), closes(1, 2), false, 1.5},
+		{"SMA", NewSMA(3), closes(1, 2, 3, 4, 5), true, 4},
+		{"EMA started from the SMA", NewEMA(3), closes(1, 2, 3), true, 2},
+		{"EMA", NewEMA(3), closes(1, 2, 3, 4, 5), true, 4},
+		{"StdDev", NewStdDev(3), closes(1, 3, 4, 5), true, math.Sqrt(2.0 / 3)},
+		{"ReturnsStdDev needs period returns", NewReturnsStdDev(2), closes(100, 110), false, 0},
+		{"ReturnsStdDev", NewReturnsStdDev(2), closes(100, 110, 99), true, (math.Log(1.1) - math.Log(0.9)) * 100 / 2},
+		{"Bollinger", NewBollinger(3, 2), closes(3, 4, 5), true, 4},
+		{"VWAP", NewVWAP(2), []Bar{{High: 10, Low: 10, Close: 10, Volume: 1}, {High: 20, Low: 20, Close: 20, Volume: 3}}, true, 17.5},
+		{"VWAP of the typical price", NewVWAP(1), []Bar{{High: 12, Low: 6, Close: 9, Volume: 2}}, true, 9},
+		{"VWAP without volume", NewVWAP(2), closes(10, 20), true, 15},
+		{"RSI only gains", NewRSI(2), closes(1, 2, 3), true, 100},
+		{"RSI balanced", NewRSI(2), closes(1, 2, 1), true, 50},
+		{"RSI flat", NewRSI(2), closes(1, 1, 1), true, 50},
+		{"RSI smoothed", NewRSI(2), closes(1, 3, 2, 4), true, 100 - 100/7.0},
+		{"ATR", NewATR(2), []Bar{{High: 10, Low: 8, Close: 9}, {High: 12, Low: 9, Close: 11}, {High: 11, Low: 10, Close: 10}}, true, 1.75},
+		{"ATR gap from the previous close", NewATR(1), []Bar{{High: 10, Low: 10, Close: 10}, {High: 15, Low: 14, Close: 15}}, true, 5},
+		{"Donchian", NewDonchian(2), []Bar{{High: 10, Low: 8}, {High: 12, Low: 9}, {High: 11, Low: 10}}, true, 10.5},
+		{"Donchian not ready", NewDonchian(3), []Bar{{High: 10, Low: 8}, {High: 12, Low: 9}}, false, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, bar := range tt.bars {
+				tt.indicator.Update(bar)
+			}
+			if tt.indicator.Ready() != tt.ready || math.Abs(tt.indicator.Value()-tt.value) > 1e-9 {
+				t.Errorf("got ready %t value %g, want %t %g", tt.indicator.Ready(), tt.indicator.Value(), tt.ready, tt.value)
+			}
+		})
+	}
+}
+
+func TestInvalidPeriods(t *testing.T) {
+	tests := []struct {
+		name string
+		new  func()
+	}{
+		{"SMA zero", func() { NewSMA(0) }},
+		{"EMA zero", func() { NewEMA(0) }},
+		{"EMA negative", func() { NewEMA(-3) }},
+		{"StdDev zero", func() { NewStdDev(0) }},
+		{"ReturnsStdDev negative", func() { NewReturnsStdDev(-1) }},
+		{"Bollinger zero", func() { NewBollinger(0, 2) }},
+		{"VWAP zero", func() { NewVWAP(0) }},
+		{"RSI zero", func() { NewRSI(0) }},
+		{"ATR negative", func() { NewATR(-14) }},
+		{"Donchian zero", func() { NewDonchian(0) }},
+		{"bar interval zero", func() { NewBarBuilder(0) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			tt.new()
+		})
+	}
+}
+
+func TestBands(t *testing.T) {
+	bollinger := NewBollinger(3, 2)
+	donchian := NewDonchian(3)
+	for _, bar := range []Bar{{High: 4, Low: 2, Close: 3}, {High: 6, Low: 3, Close: 4}, {High: 5, Low: 1, Close: 5}, {High: 7, Low: 4, Close: 4}} {
+		bollinger.Update(bar)
+		donchian.Update(bar)
+	}
+	std := math.Sqrt(2.0 / 9)
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"Bollinger upper", bollinger.Upper(), 13.0/3 + 2*std},
+		{"Bollinger lower", bollinger.Lower(), 13.0/3 - 2*std},
+		{"Donchian upper", donchian.Upper(), 7},
+		{"Donchian lower", donchian.Lower(), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if math.Abs(tt.got-tt.want) > 1e-9 {
+				t.Errorf("got %g, want %g", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBarBuilder(t *testing.T) {
+	t0 := time.Unix(1620000000, 0).Truncate(time.Minute)
+	ticks := []struct {
+		offset time.Duration
+		price  float64
+		volume float64
+	}{
+		{0, 10, 1},
+		{20 * time.Second, 12, 2},
+		{40 * time.Second, 9, 3},
+		{50 * time.Second, 11, 4},
+		{3 * time.Minute, 20, 5}, // closes the first bar, the empty minutes have no bar
+		{3*time.Minute + 30*time.Second, 21, 6},
+		{4 * time.Minute, 22, 7},
+	}
+	want := []Bar{
+		{Start: t0, Open: 10, High: 12, Low: 9, Close: 11, Volume: 10},
+		{Start: t0.Add(3 * time.Minute), Open: 20, High: 21, Low: 20, Close: 21, Volume: 11},
+	}
+	builder := NewBarBuilder(time.Minute)
+	bars := make([]Bar, 0)
+	for _, tick := range ticks {
+		if bar, closed := builder.Update(t0.Add(tick.offset), tick.price, tick.volume); closed {
+			bars = append(bars, bar)
+		}
+	}
+	if len(bars) != len(want) {
+		t.Fatalf("got %d bars, want %d", len(bars), len(want))
+	}
+	for i := range bars {
+		if bars[i] != want[i] {
+			t.Errorf("bar %d: got %+v, want %+v", i, bars[i], want[i])
+		}
+	}
+}
+
+func TestManager(t *testing.T) {
+	m := NewManager()
+	sma := m.Add("sma", time.Minute, NewSMA(2))
+	if again := m.Add("sma", time.Hour, NewSMA(5)); again != sma {
+		t.Error("adding an existing name did not return the registered indicator")
+	}
+	if m.Get("ema") != nil {
+		t.Error("got an indicator not added")
+	}
+	t0 := time.Unix(1620000000, 0).Truncate(time.Minute)
+	for i, price := range []float64{1, 2, 3, 4} {
+		m.Update(common.SymbolDataItem{Time: t0.Add(time.Duration(i) * time.Minute), Price: price})
+	}
+	// the last bar is still open
+	if !m.Get("sma").Ready() || m.Get("sma").Value() != 2.5 {
+		t.Errorf("got ready %t value %g, want true 2.5", m.Get("sma").Ready(), m.Get("sma").Value())
+	}
+}
+
+func closes(values ...float64) []Bar {
+	bars := make([]Bar, len(values))
+	for i, v := range values {
+		bars[i] = Bar{Open: v, High: v, Low: v, Close: v}
+	}
+	return bars
+}
@@ -0,0 +1,141 @@
+package indicator
+
+import "math"
+
+// RSI is the relative strength index of the close, with Wilder's smoothing
+type RSI struct {
+	period    int
+	count     int
+	prevClose float64
+	avgGain   float64
+	avgLoss   float64
+}
+
+func NewRSI(period int) *RSI {
+	checkPeriod("RSI", period)
+	return &RSI{period: period}
+}
+
+func (i *RSI) Update(bar Bar) {
+	if i.count == 0 && i.prevClose == 0 {
+		i.prevClose = bar.Close
+		return
+	}
+	change := bar.Close - i.prevClose
+	i.prevClose = bar.Close
+	gain, loss := math.Max(change, 0), math.Max(-change, 0)
+
+	i.count++
+	if i.count <= i.period {
+		// simple average of the first period changes
+		i.avgGain += (gain - i.avgGain) / float64(i.count)
+		i.avgLoss += (loss - i.avgLoss) / float64(i.count)
+		return
+	}
+	p := float64(i.period)
+	i.avgGain = (i.avgGain*(p-1) + gain) / p
+	i.avgLoss = (i.avgLoss*(p-1) + loss) / p
+}
+
+func (i *RSI) Ready() bool { return i.count >= i.period }
+
+func (i *RSI) Value() float64 {
+	if i.avgLoss == 0 {
+		if i.avgGain == 0 {
+			return 50
+		}
+		return 100
+	}
+	return 100 - 100/(1+i.avgGain/i.avgLoss)
+}
+
+// ATR is the average true range, with Wilder's smoothing
+type ATR struct {
+	period    int
+	count     int
+	prevClose float64
+	value     float64
+}
+
+func NewATR(period int) *ATR {
+	checkPeriod("ATR", period)
+	return &ATR{period: period}
+}
+
+func (i *ATR) Update(bar Bar) {
+	trueRange := bar.High - bar.Low
+	if i.prevClose != 0 {
+		trueRange = math.Max(trueRange, math.Max(math.Abs(bar.High-i.prevClose), math.Abs(bar.Low-i.prevClose)))
+	}
+	i.prevClose = bar.Close
+
+	i.count++
+	if i.count <= i.period {
+		i.value += (trueRange - i.value) / float64(i.count)
+		return
+	}
+	p := float64(i.period)
+	i.value = (i.value*(p-1) + trueRange) / p
+}
+
+func (i *ATR) Ready() bool { return i.count >= i.period }
+
+func (i *ATR) Value() float64 { return i.value }
+
+// Donchian is the Donchian channel: highest high and lowest low of the last
+// period bars, the value being the middle. The extremes are kept in monotonic
+// queues, amortized constant time per update.
+type Donchian struct {
+	period int
+	count  int
+	highs  []indexedValue
+	lows   []indexedValue
+}
+
+type indexedValue struct {
+	index int
+	value float64
+}
+
+func NewDonchian(period int) *Donchian {
+	checkPeriod("Donchian", period)
+	return &Donchian{period: period}
+}
+
+func (i *Donchian) Update(bar Bar) {
+	i.count++
+	for len(i.highs) > 0 && i.highs[len(i.highs)-1].value <= bar.High {
+		i.highs = i.highs[:len(i.highs)-1]
+	}
+	i.highs = append(i.highs, indexedValue{i.count, bar.High})
+	for len(i.lows) > 0 && i.lows[len(i.lows)-1].value >= bar.Low {
+		i.lows = i.lows[:len(i.lows)-1]
+	}
+	i.lows = append(i.lows, indexedValue{i.count, bar.Low})
+
+	// drop the values out of the window
+	for i.highs[0].index <= i.count-i.period {
+		i.highs = i.highs[1:]
+	}
+	for i.lows[0].index <= i.count-i.period {
+		i.lows = i.lows[1:]
+	}
+}
+
+func (i *Donchian) Ready() bool { return i.count >= i.period }
+
+func (i *Donchian) Value() float64 { return (i.Upper() + i.Lower()) / 2 }
+
+func (i *Donchian) Upper() float64 {
+	if len(i.highs) == 0 {
+		return 0
+	}
+	return i.highs[0].value
+}
+
+func (i *Donchian) Lower() float64 {
+	if len(i.lows) == 0 {
+		return 0
+	}
+	return i.lows[0].value
+}
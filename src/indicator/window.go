@@ -0,0 +1,64 @@
+package indicator
+
+import (
+	"math"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// window is a ring buffer keeping the sum and the sum of squares of its values
+type window struct {
+	values []float64
+	next   int
+	count  int
+	sum    float64
+	sumSq  float64
+}
+
+func newWindow(period int) *window {
+	checkPeriod("window", period)
+	return &window{values: make([]float64, period)}
+}
+
+// push adds a value and returns the one leaving the window, if full
+func (w *window) push(value float64) (float64, bool) {
+	var old float64
+	full := w.count == len(w.values)
+	if full {
+		old = w.values[w.next]
+		w.sum -= old
+		w.sumSq -= old * old
+	} else {
+		w.count++
+	}
+	w.values[w.next] = value
+	w.next = (w.next + 1) % len(w.values)
+	w.sum += value
+	w.sumSq += value * value
+	return old, full
+}
+
+func (w *window) full() bool { return w.count == len(w.values) }
+
+func (w *window) mean() float64 {
+	if w.count == 0 {
+		return 0
+	}
+	return w.sum / float64(w.count)
+}
+
+func (w *window) stdDev() float64 {
+	if w.count == 0 {
+		return 0
+	}
+	mean := w.mean()
+	return math.Sqrt(math.Max(w.sumSq/float64(w.count)-mean*mean, 0))
+}
+
+// PRIVATE FUNCTIONS
+// checkPeriod panics if the period of the indicator is not positive
+func checkPeriod(indicator string, period int) {
+	if period <= 0 {
+		log.Panicf("Invalid %s period %d, must be positive", indicator, period)
+	}
+}
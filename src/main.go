@@ -22,15 +22,72 @@ func main() {
 		LogFormat: "[%lvl%] %msg%\n",
 	})
 
+	if len(os.Args) > 1 && os.Args[1] == "rank" {
+		rank(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "sensitivity" {
+		sensitivity(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "scenario" {
+		scenarios(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "stress" {
+		stress(os.Args[2:])
+		return
+	}
+
 	// symbolData := common.NewSymbolDataFromProcessedFile("../datasets/LTCUSDT_1s.csv")
 	symbolData := common.NewSymbolDataFromTickDataFolder("../datasets/test_doge")
+	// synthetic data, e.g. a flash crash of 30% over 15 minutes recovering half of it in 2 hours
+	// symbolData := synthetic.MustGenerate(synthetic.Path{Symbol: "SYNTHETIC", Start: time.Now(), Step: time.Second, Duration: 7 * 24 * time.Hour, Price: 100, Seed: 1},
+	// 	synthetic.Scenario{Base: synthetic.GBM{Volatility: 0.8}, Shocks: []synthetic.Shock{synthetic.FlashCrash(3*24*time.Hour, 30, 15*time.Minute, 50, 2*time.Hour)}})
 	resultsFolder := "../results/"
 	simulator := simulator.NewSimulator(symbolData, resultsFolder)
 
-	pars := strategy.StrategyParameters{GO: 5, GS: 0.3, SF: 1.5, OS: 1, OF: 2, TS: 0.3, SL: 0.3}
-	strategy := strategy.NewStrategy(strategy.StrategyTypeAntiMartingala, "", engine.PositionSideLong, pars)
+	pars, err := strategy.DefaultParameters(strategy.StrategyTypeAntiMartingala)
+	if err != nil {
+		log.Panic(err)
+	}
+	pars.OF = 2
+	strategy, err := strategy.NewStrategy(strategy.StrategyTypeAntiMartingala, "", engine.PositionSideLong, pars)
+	if err != nil {
+		log.Panic(err)
+	}
+	if analysis, err := simulator.AnalyzeGrid(*strategy); err == nil {
+		log.Info(analysis)
+	}
 	simulator.RunSingleSimulation(*strategy)
 
-	// TODO parameters combination
-	// simulator.RunMultipleSimulations()
+	// parameters sweep, only parameters used by the strategy can be varied
+	// completed runs are kept in the results store and skipped when the sweep is restarted
+	// resultsStore, _ := store.Open(resultsFolder + "runs.jsonl")
+	// simulator.SetResultsStore(resultsStore, "DOGE_1s")
+	// simulator.RunSweep(strategy.StrategyTypeAntiMartingala, engine.PositionSideLong, pars, map[string][]float64{"GO": {3, 5}, "GS": {0.3, 0.5}})
+
+	// sampled sweep, ranges with zero bounds use the bounds of the parameter
+	// simulator.RunSample(strategy.StrategyTypeAntiMartingala, engine.PositionSideLong, pars, map[string]strategy.ParameterRange{"GO": {Min: 3, Max: 8}, "GS": {}}, 100, 1, strategy.SamplingLatinHypercube)
+
+	// genetic optimizer, one simulator per worker, resumed from the population file if it exists
+	// space, _ := strategy.NewParameterSpace(strategy.StrategyTypeAntiMartingala, pars, map[string]strategy.ParameterRange{"GO": {}, "GS": {}, "TS": {}})
+	// evaluator := optimizer.NewEvaluator(func() *simulator.Simulator { return simulator.NewSimulator(symbolData, resultsFolder) }, strategy.StrategyTypeAntiMartingala, engine.PositionSideLong, optimizer.Objective{Metric: common.MetricCalmar, MaxDrawdownPerc: 20}, 0)
+	// optimizer.NewGenetic(optimizer.GeneticConfig{Population: 40, Generations: 20, TournamentSize: 3, CrossoverRate: 0.8, MutationRate: 0.2, Elitism: 2, Seed: 1, PopulationFile: resultsFolder + "population.json"}, space, evaluator).Run()
+
+	// Bayesian optimizer, seeded by and appending to the history file
+	// optimizer.NewTPE(optimizer.TPEConfig{Budget: 100, BatchSize: 8, InitialSamples: 20, Candidates: 24, Gamma: 0.25, Seed: 1, HistoryFile: resultsFolder + "history.jsonl"}, space, evaluator).Run()
+
+	// walk-forward: sweep in-sample, run the best out-of-sample
+	// simulator.RunWalkForward(strategy.StrategyTypeAntiMartingala, engine.PositionSideLong, pars, map[string][]float64{"GS": {0.3, 0.5}}, simulator.WalkForwardConfig{InSample: 30 * 24 * time.Hour, OutOfSample: 7 * 24 * time.Hour, Metric: common.MetricCalmar})
+
+	// return with and without compounding
+	// simulator.RunSizingComparison(*strategy, strategy.Sizing{}, strategy.Sizing{Mode: strategy.SizingFixedNotional, Amount: 1000})
+
+	// Monte Carlo: the strategy on paths bootstrapped from the data by blocks of one hour of returns
+	// simulator.RunMonteCarlo(*strategy, simulator.MonteCarloConfig{Paths: 200, Method: common.PathBlockBootstrap, BlockSize: 3600, RuinLossPerc: 50, Seed: 1})
+
+	// stress test on the scenarios of the library, see the scenario and stress commands
+	// library, _ := scenario.OpenLibrary("../scenarios.json")
+	// simulator.RunStress(*strategy, library, library.Select("crash"), []store.Condition{{Field: "maxDrawdownPerc", Operator: "<", Value: 30}})
 }
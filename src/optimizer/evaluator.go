@@ -0,0 +1,207 @@
+package optimizer
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	"example.com/gobot-simulator/src/common"
+	"example.com/gobot-simulator/src/engine"
+	"example.com/gobot-simulator/src/simulator"
+	"example.com/gobot-simulator/src/strategy"
+)
+
+// Evaluation is the result of the run of a parameter set
+type Evaluation struct {
+	StrategyType strategy.StrategyType       `json:"strategyType"`
+	PositionSide engine.PositionSideType     `json:"positionSide"`
+	Parameters   strategy.StrategyParameters `json:"parameters"`
+	Leverage     float64                     `json:"leverage"`
+	Sizing       strategy.Sizing             `json:"sizing"`
+	Label        string                      `json:"label"`
+	Metrics      common.Metrics              `json:"metrics"`
+	Score        float64                     `json:"score"`
+	Error        string                      `json:"error,omitempty"` // the strategy could not be run
+	Dataset      string                      `json:"dataset"`         // see simulator.DatasetID
+	Objective    Objective                   `json:"objective"`       // of the score
+}
+
+// evaluationKey identifies the run of an evaluation, the objective aside
+type evaluationKey struct {
+	StrategyType strategy.StrategyType
+	PositionSide engine.PositionSideType
+	Parameters   strategy.StrategyParameters
+	Leverage     float64
+	Sizing       strategy.Sizing
+	Dataset      string
+}
+
+func (k evaluationKey) String() string {
+	return fmt.Sprintf("%s %s, leverage %g, sizing %s %g reserve %g, data %s",
+		k.StrategyType, k.PositionSide, k.Leverage, k.Sizing.Mode, k.Sizing.Amount, k.Sizing.Reserve, k.Dataset)
+}
+
+// Evaluator runs parameter sets of a strategy in parallel, every worker owning
+// its simulator since simulators are not safe for concurrent use. Results are
+// cached by run.
+type Evaluator struct {
+	strategyType strategy.StrategyType
+	positionSide engine.PositionSideType
+	objective    Objective
+	newSimulator func() *simulator.Simulator
+	simulators   []*simulator.Simulator
+	cache        map[evaluationKey]Evaluation
+}
+
+// NewEvaluator creates an evaluator with the given number of workers, the
+// number of CPUs if 0. newSimulator is called once per worker and must return
+// simulators with the same data and settings.
+func NewEvaluator(newSimulator func() *simulator.Simulator, strategyType strategy.StrategyType, positionSide engine.PositionSideType, objective Objective, workers int) *Evaluator {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	return &Evaluator{
+		strategyType: strategyType,
+		positionSide: positionSide,
+		objective:    objective,
+		newSimulator: newSimulator,
+		simulators:   make([]*simulator.Simulator, workers),
+		cache:        make(map[evaluationKey]Evaluation),
+	}
+}
+
+// PUBLIC METHODS
+// Evaluate returns the evaluations of the parameter sets, in the same order
+func (e *Evaluator) Evaluate(sets []strategy.StrategyParameters) []Evaluation {
+	evaluations := make([]Evaluation, len(sets))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for w := range e.simulators {
+		wg.Add(1)
+		go func(sim *simulator.Simulator) {
+			defer wg.Done()
+			for i := range jobs {
+				key := e.key(sets[i])
+				mu.Lock()
+				cached, ok := e.cache[key]
+				mu.Unlock()
+				if !ok {
+					cached = e.run(sim, sets[i])
+					mu.Lock()
+					e.cache[key] = cached
+					mu.Unlock()
+				}
+				evaluations[i] = cached
+			}
+		}(e.simulator(w))
+	}
+	for i := range sets {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return evaluations
+}
+
+// Seed adds to the cache the previous evaluations, rescored with the objective
+// of the evaluator, and returns them. It fails if an evaluation was not run
+// with the strategy and the settings of the evaluator.
+func (e *Evaluator) Seed(evaluations []Evaluation) ([]Evaluation, error) {
+	seeded := make([]Evaluation, len(evaluations))
+	for i, ev := range evaluations {
+		if !e.Matches(ev) {
+			return nil, fmt.Errorf("evaluation %s was run with %s, expected %s", ev.Label, ev.key(), e.key(ev.Parameters))
+		}
+		seeded[i] = e.Rescore(ev)
+	}
+	for _, ev := range seeded {
+		e.cache[ev.key()] = ev
+	}
+	return seeded, nil
+}
+
+// Matches returns true if the evaluation was run with the strategy type,
+// position side, leverage, sizing and data of the evaluator
+func (e *Evaluator) Matches(ev Evaluation) bool {
+	return ev.key() == e.key(ev.Parameters)
+}
+
+// Rescore returns the evaluation scored with the objective of the evaluator
+func (e *Evaluator) Rescore(ev Evaluation) Evaluation {
+	ev.Objective = e.objective
+	if ev.Error != "" {
+		ev.Score = failedScore
+	} else {
+		ev.Score = e.objective.Score(ev.Metrics)
+	}
+	return ev
+}
+
+// Dataset identifies the data the parameter sets are run on
+func (e *Evaluator) Dataset() string {
+	return e.simulator(0).DatasetID()
+}
+
+func (e *Evaluator) Objective() Objective { return e.objective }
+
+// PRIVATE METHODS
+// key identifies the run of the parameter set by the evaluator
+func (e *Evaluator) key(pars strategy.StrategyParameters) evaluationKey {
+	sim := e.simulator(0)
+	return evaluationKey{
+		StrategyType: e.strategyType,
+		PositionSide: e.positionSide,
+		Parameters:   pars,
+		Leverage:     sim.Leverage(),
+		Sizing:       sim.Sizing(),
+		Dataset:      sim.DatasetID(),
+	}
+}
+
+// simulator returns the simulator of the worker, created at first use
+func (e *Evaluator) simulator(w int) *simulator.Simulator {
+	if e.simulators[w] == nil {
+		e.simulators[w] = e.newSimulator()
+	}
+	return e.simulators[w]
+}
+
+func (e *Evaluator) run(sim *simulator.Simulator, pars strategy.StrategyParameters) Evaluation {
+	evaluation := Evaluation{
+		StrategyType: e.strategyType,
+		PositionSide: e.positionSide,
+		Parameters:   pars,
+		Leverage:     sim.Leverage(),
+		Sizing:       sim.Sizing(),
+		Score:        failedScore,
+		Dataset:      sim.DatasetID(),
+		Objective:    e.objective,
+	}
+	s, err := strategy.NewStrategy(e.strategyType, "", e.positionSide, pars)
+	if err != nil {
+		evaluation.Error = err.Error()
+		return evaluation
+	}
+	evaluation.Label = (*s).String()
+	summary, err := sim.Evaluate(*s)
+	if err != nil {
+		evaluation.Error = err.Error()
+		return evaluation
+	}
+	evaluation.Metrics = summary.Metrics
+	evaluation.Score = e.objective.Score(summary.Metrics)
+	return evaluation
+}
+
+// PRIVATE FUNCTIONS
+func (ev Evaluation) key() evaluationKey {
+	return evaluationKey{
+		StrategyType: ev.StrategyType,
+		PositionSide: ev.PositionSide,
+		Parameters:   ev.Parameters,
+		Leverage:     ev.Leverage,
+		Sizing:       ev.Sizing,
+		Dataset:      ev.Dataset,
+	}
+}
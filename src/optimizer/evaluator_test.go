@@ -0,0 +1,145 @@
+package optimizer
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/gobot-simulator/src/common"
+	"example.com/gobot-simulator/src/engine"
+	"example.com/gobot-simulator/src/simulator"
+	"example.com/gobot-simulator/src/strategy"
+)
+
+const toyLabel = "toy"
+
+func newTestSimulator() *simulator.Simulator {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := &common.SymbolData{Symbol: "TEST"}
+	for i := 0; i < 3; i++ {
+		data.Data = append(data.Data, common.SymbolDataItem{Time: start.Add(time.Duration(i) * time.Hour), Price: 100})
+	}
+	return simulator.NewSimulator(data, "")
+}
+
+func newTestEvaluator() *Evaluator {
+	return NewEvaluator(newTestSimulator, strategy.StrategyTypeMartingala, engine.PositionSideLong,
+		Objective{Metric: common.MetricNetProfit}, 2)
+}
+
+// newToySpace returns the space of GO in [1, 100] with every parameter set
+// cached in the evaluator, the net profit being the toy objective
+// -(GO - optimum)^2: the optimizers never run the simulator.
+func newToySpace(t *testing.T, e *Evaluator, optimum float64) *strategy.ParameterSpace {
+	base := defaultParameters(t)
+	space, err := strategy.NewParameterSpace(strategy.StrategyTypeMartingala, base, map[string]strategy.ParameterRange{"GO": {Min: 1, Max: 100}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for value := 1.0; value <= 100; value++ {
+		pars := base
+		if err := strategy.SetParameter(&pars, "GO", value); err != nil {
+			t.Fatal(err)
+		}
+		ev := matchingEvaluation(e, pars)
+		ev.Label = toyLabel
+		ev.Metrics = common.Metrics{NetProfit: -(value - optimum) * (value - optimum)}
+		e.cache[e.key(pars)] = e.Rescore(ev)
+	}
+	return space
+}
+
+// matchingEvaluation returns an evaluation of the parameter set run with the
+// settings of the evaluator
+func matchingEvaluation(e *Evaluator, pars strategy.StrategyParameters) Evaluation {
+	sim := e.simulator(0)
+	return Evaluation{
+		StrategyType: e.strategyType,
+		PositionSide: e.positionSide,
+		Parameters:   pars,
+		Leverage:     sim.Leverage(),
+		Sizing:       sim.Sizing(),
+		Dataset:      sim.DatasetID(),
+	}
+}
+
+func defaultParameters(t *testing.T) strategy.StrategyParameters {
+	pars, err := strategy.DefaultParameters(strategy.StrategyTypeMartingala)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pars
+}
+
+func gridOrders(t *testing.T, pars strategy.StrategyParameters) float64 {
+	value, err := strategy.GetParameter(pars, "GO")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
+
+func TestSeed(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(ev *Evaluation)
+		err    string
+	}{
+		{"matching evaluation", func(ev *Evaluation) {}, ""},
+		{"other strategy type", func(ev *Evaluation) { ev.StrategyType = strategy.StrategyTypeAntiMartingala }, "AntiMartingala"},
+		{"other position side", func(ev *Evaluation) { ev.PositionSide = engine.PositionSideShort }, "SHORT"},
+		{"other leverage", func(ev *Evaluation) { ev.Leverage = 3 }, "leverage 3"},
+		{"other sizing", func(ev *Evaluation) { ev.Sizing = strategy.Sizing{Mode: strategy.SizingFixedNotional, Amount: 10} }, "FIXED_NOTIONAL"},
+		{"other data", func(ev *Evaluation) { ev.Dataset = "other" }, "data other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEvaluator()
+			pars := defaultParameters(t)
+			ev := matchingEvaluation(e, pars)
+			ev.Label = toyLabel
+			ev.Metrics = common.Metrics{NetProfit: 42}
+			ev.Objective = Objective{Metric: common.MetricSharpe}
+			tt.change(&ev)
+
+			seeded, err := e.Seed([]Evaluation{ev})
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) || e.Matches(ev) {
+					t.Errorf("got error %v, want one naming %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if seeded[0].Score != 42 || seeded[0].Objective != e.Objective() {
+				t.Errorf("got score %g with %s, want 42 with %s", seeded[0].Score, seeded[0].Objective.String(), e.Objective().String())
+			}
+			if got := e.Evaluate([]strategy.StrategyParameters{pars})[0]; got.Label != toyLabel || got.Score != 42 {
+				t.Errorf("got %s scoring %g, want the seeded evaluation", got.Label, got.Score)
+			}
+		})
+	}
+}
+
+func TestRescore(t *testing.T) {
+	e := NewEvaluator(newTestSimulator, strategy.StrategyTypeMartingala, engine.PositionSideLong,
+		Objective{Metric: common.MetricNetProfit, MaxDrawdownPerc: 20}, 1)
+	tests := []struct {
+		name string
+		ev   Evaluation
+		want float64
+	}{
+		{"within the drawdown", Evaluation{Metrics: common.Metrics{NetProfit: 10, MaxDrawdownPerc: 15}}, 10},
+		{"beyond the drawdown", Evaluation{Metrics: common.Metrics{NetProfit: 10, MaxDrawdownPerc: 25}}, infeasibleScore - 5},
+		{"failed run", Evaluation{Error: "invalid grid"}, failedScore},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := e.Rescore(tt.ev).Score; math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("got %g, want %g", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package optimizer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"reflect"
+	"sort"
+
+	"example.com/gobot-simulator/src/strategy"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const mutationSigma = 0.1 // standard deviation of a mutation, in the unit range of a parameter
+
+// GeneticConfig sets the evolutionary optimizer. The population is saved after
+// every generation to PopulationFile, if set, and the optimizer resumes from it
+// when the file exists, rescoring it with the current objective. The population
+// must have been evaluated on the same data.
+type GeneticConfig struct {
+	Population     int     `json:"population"`
+	Generations    int     `json:"generations"`    // evolved after the initial population
+	TournamentSize int     `json:"tournamentSize"` // individuals compared to select a parent
+	CrossoverRate  float64 `json:"crossoverRate"`  // probability of a uniform crossover, otherwise the first parent is copied
+	MutationRate   float64 `json:"mutationRate"`   // probability of mutating every gene
+	Elitism        int     `json:"elitism"`        // best individuals kept as they are
+	Seed           int64   `json:"seed"`
+	PopulationFile string  `json:"populationFile"`
+}
+
+// Individual is a point of the parameter space, one gene in [0, 1] per
+// parameter, with its evaluation.
+type Individual struct {
+	Genes []float64 `json:"genes"`
+	Evaluation
+}
+
+// Population is the state of the optimizer after a generation
+type Population struct {
+	Generation  int          `json:"generation"`
+	Names       []string     `json:"names"` // parameter of every gene
+	Individuals []Individual `json:"individuals"`
+}
+
+// Best returns the individual with the highest score
+func (p *Population) Best() Individual {
+	best := p.Individuals[0]
+	for _, ind := range p.Individuals[1:] {
+		if ind.Score > best.Score {
+			best = ind
+		}
+	}
+	return best
+}
+
+// Genetic maximizes the objective of the evaluator over the parameter space
+// with tournament selection, uniform crossover, gaussian mutation and elitism.
+type Genetic struct {
+	config    GeneticConfig
+	space     *strategy.ParameterSpace
+	evaluator *Evaluator
+}
+
+func NewGenetic(config GeneticConfig, space *strategy.ParameterSpace, evaluator *Evaluator) *Genetic {
+	return &Genetic{config: config, space: space, evaluator: evaluator}
+}
+
+// PUBLIC METHODS
+// Run evolves the population and returns the last one
+func (g *Genetic) Run() (*Population, error) {
+	if g.config.Population < 2 || g.config.TournamentSize < 1 || g.config.Elitism >= g.config.Population {
+		return nil, fmt.Errorf("invalid genetic config %+v", g.config)
+	}
+
+	population, err := g.load()
+	if err != nil {
+		return nil, err
+	}
+	if population != nil {
+		log.Infof("Genetic: resuming from generation %d of %s", population.Generation, g.config.PopulationFile)
+	} else {
+		population = g.initialPopulation()
+		g.report(population)
+		g.save(population)
+	}
+
+	for population.Generation < g.config.Generations {
+		population = g.nextGeneration(population)
+		g.report(population)
+		g.save(population)
+	}
+	return population, nil
+}
+
+// PRIVATE METHODS
+func (g *Genetic) initialPopulation() *Population {
+	random := g.random(0)
+	population := &Population{Names: g.space.Names()}
+	for i := 0; i < g.config.Population; i++ {
+		genes := make([]float64, g.space.Dimensions())
+		for d := range genes {
+			genes[d] = random.Float64()
+		}
+		population.Individuals = append(population.Individuals, Individual{Genes: genes})
+	}
+	g.evaluate(population.Individuals)
+	return population
+}
+
+func (g *Genetic) nextGeneration(population *Population) *Population {
+	random := g.random(population.Generation + 1)
+	ranked := append([]Individual{}, population.Individuals...)
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Score > ranked[j].Score })
+
+	next := &Population{Generation: population.Generation + 1, Names: population.Names}
+	next.Individuals = append(next.Individuals, ranked[:g.config.Elitism]...)
+	children := make([]Individual, 0, g.config.Population-g.config.Elitism)
+	for len(next.Individuals)+len(children) < g.config.Population {
+		first, second := g.tournament(random, ranked), g.tournament(random, ranked)
+		children = append(children, Individual{Genes: g.mutate(random, g.crossover(random, first.Genes, second.Genes))})
+	}
+	g.evaluate(children)
+	next.Individuals = append(next.Individuals, children...)
+	return next
+}
+
+func (g *Genetic) tournament(random *rand.Rand, individuals []Individual) Individual {
+	best := individuals[random.Intn(len(individuals))]
+	for k := 1; k < g.config.TournamentSize; k++ {
+		if candidate := individuals[random.Intn(len(individuals))]; candidate.Score > best.Score {
+			best = candidate
+		}
+	}
+	return best
+}
+
+func (g *Genetic) crossover(random *rand.Rand, first []float64, second []float64) []float64 {
+	child := append([]float64{}, first...)
+	if random.Float64() >= g.config.CrossoverRate {
+		return child
+	}
+	for d := range child {
+		if random.Intn(2) == 1 {
+			child[d] = second[d]
+		}
+	}
+	return child
+}
+
+func (g *Genetic) mutate(random *rand.Rand, genes []float64) []float64 {
+	for d := range genes {
+		if random.Float64() < g.config.MutationRate {
+			genes[d] = math.Min(math.Max(genes[d]+random.NormFloat64()*mutationSigma, 0), 1)
+		}
+	}
+	return genes
+}
+
+func (g *Genetic) evaluate(individuals []Individual) {
+	sets := make([]strategy.StrategyParameters, len(individuals))
+	for i, ind := range individuals {
+		sets[i] = g.space.Parameters(ind.Genes)
+	}
+	for i, evaluation := range g.evaluator.Evaluate(sets) {
+		individuals[i].Evaluation = evaluation
+	}
+}
+
+// random returns the source of a generation, so that resumed runs draw the same
+// numbers as uninterrupted ones
+func (g *Genetic) random(generation int) *rand.Rand {
+	return rand.New(rand.NewSource(g.config.Seed + int64(generation)))
+}
+
+func (g *Genetic) report(population *Population) {
+	best := population.Best()
+	fmt.Printf("Generation %d/%d best %s -> score %.4f (%s), %s\n", population.Generation, g.config.Generations,
+		best.Label, best.Score, g.evaluator.Objective().String(), best.Metrics.String())
+}
+
+func (g *Genetic) save(population *Population) {
+	if g.config.PopulationFile == "" {
+		return
+	}
+	data, err := json.MarshalIndent(population, "", "  ")
+	if err != nil {
+		log.Errorf("Error encoding population: %s", err)
+		return
+	}
+	// write and rename, not to lose the previous population if interrupted
+	tmp := g.config.PopulationFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		log.Errorf("Error writing population to file %s: %s", tmp, err)
+		return
+	}
+	if err := os.Rename(tmp, g.config.PopulationFile); err != nil {
+		log.Errorf("Error writing population to file %s: %s", g.config.PopulationFile, err)
+	}
+}
+
+// load returns the saved population, nil if there is none
+func (g *Genetic) load() (*Population, error) {
+	if g.config.PopulationFile == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(g.config.PopulationFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	population := &Population{}
+	if err := json.Unmarshal(data, population); err != nil {
+		return nil, fmt.Errorf("could not decode population %s: %w", g.config.PopulationFile, err)
+	}
+	if !reflect.DeepEqual(population.Names, g.space.Names()) {
+		return nil, fmt.Errorf("population %s has parameters %v, expected %v", g.config.PopulationFile, population.Names, g.space.Names())
+	}
+	if len(population.Individuals) == 0 {
+		return nil, fmt.Errorf("population %s is empty", g.config.PopulationFile)
+	}
+	evaluations := make([]Evaluation, len(population.Individuals))
+	for i, ind := range population.Individuals {
+		evaluations[i] = ind.Evaluation
+	}
+	seeded, err := g.evaluator.Seed(evaluations)
+	if err != nil {
+		return nil, fmt.Errorf("population %s: %w", g.config.PopulationFile, err)
+	}
+	for i := range population.Individuals {
+		population.Individuals[i].Evaluation = seeded[i]
+	}
+	return population, nil
+}
@@ -0,0 +1,69 @@
+package optimizer
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func testGeneticConfig() GeneticConfig {
+	return GeneticConfig{Population: 8, Generations: 10, TournamentSize: 2, CrossoverRate: 0.5, MutationRate: 0.5, Elitism: 1, Seed: 1}
+}
+
+func TestGeneticToyObjective(t *testing.T) {
+	e := newTestEvaluator()
+	g := NewGenetic(testGeneticConfig(), newToySpace(t, e, 37), e)
+
+	population := g.initialPopulation()
+	best := population.Best().Score
+	for population.Generation < g.config.Generations {
+		population = g.nextGeneration(population)
+		if len(population.Individuals) != g.config.Population {
+			t.Fatalf("generation %d has %d individuals", population.Generation, len(population.Individuals))
+		}
+		if score := population.Best().Score; score < best {
+			t.Fatalf("best score %g of generation %d below %g, the elite was lost", score, population.Generation, best)
+		}
+		best = population.Best().Score
+	}
+	winner := population.Best()
+	if winner.Label != toyLabel {
+		t.Fatalf("the simulator was run")
+	}
+	if got := gridOrders(t, winner.Parameters); got != 37 {
+		t.Errorf("got GO %g, want 37", got)
+	}
+}
+
+func TestGeneticResume(t *testing.T) {
+	e := newTestEvaluator()
+	space := newToySpace(t, e, 37)
+	config := testGeneticConfig()
+	uninterrupted, err := NewGenetic(config, space, e).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config.PopulationFile = filepath.Join(t.TempDir(), "population.json")
+	config.Generations = 4
+	if _, err := NewGenetic(config, space, e).Run(); err != nil {
+		t.Fatal(err)
+	}
+	config.Generations = testGeneticConfig().Generations
+	resumed, err := NewGenetic(config, space, e).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(resumed, uninterrupted) {
+		t.Errorf("resumed population differs from the uninterrupted one")
+	}
+}
+
+func TestGeneticInvalidConfig(t *testing.T) {
+	e := newTestEvaluator()
+	config := testGeneticConfig()
+	config.Elitism = config.Population
+	if _, err := NewGenetic(config, newToySpace(t, e, 37), e).Run(); err == nil {
+		t.Errorf("got no error with the whole population kept as elite")
+	}
+}
@@ -0,0 +1,56 @@
+package optimizer
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// LoadHistory reads the evaluations of a history file, one JSON object per line.
+// A missing file is an empty history, lines that cannot be decoded (e.g. cut by
+// an interruption) are skipped.
+func LoadHistory(filepath string) ([]Evaluation, error) {
+	evaluations := make([]Evaluation, 0)
+	file, err := os.Open(filepath)
+	if errors.Is(err, os.ErrNotExist) {
+		return evaluations, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var evaluation Evaluation
+		if err := json.Unmarshal(scanner.Bytes(), &evaluation); err != nil {
+			log.Warnf("Skipping history line of %s: %s", filepath, err)
+			continue
+		}
+		evaluations = append(evaluations, evaluation)
+	}
+	return evaluations, scanner.Err()
+}
+
+// AppendHistory appends the evaluations to a history file
+func AppendHistory(filepath string, evaluations []Evaluation) error {
+	file, err := os.OpenFile(filepath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	for _, evaluation := range evaluations {
+		line, err := json.Marshal(evaluation)
+		if err != nil {
+			return err
+		}
+		w.Write(line)
+		w.WriteString("\n")
+	}
+	return w.Flush()
+}
@@ -0,0 +1,33 @@
+package optimizer
+
+import (
+	"fmt"
+
+	"example.com/gobot-simulator/src/common"
+)
+
+const (
+	infeasibleScore = -1e9 // runs breaking the drawdown constraint, minus the excess
+	failedScore     = -2e9 // strategies that could not be run
+)
+
+// Objective is the metric maximized by the optimizers, the max drawdown being
+// an optional constraint: runs beyond it always score below the other ones.
+type Objective struct {
+	Metric          common.MetricName `json:"metric"`
+	MaxDrawdownPerc float64           `json:"maxDrawdownPerc"` // 0 for no constraint
+}
+
+func (o Objective) Score(m common.Metrics) float64 {
+	if o.MaxDrawdownPerc > 0 && m.MaxDrawdownPerc > o.MaxDrawdownPerc {
+		return infeasibleScore - (m.MaxDrawdownPerc - o.MaxDrawdownPerc)
+	}
+	return m.Score(o.Metric)
+}
+
+func (o Objective) String() string {
+	if o.MaxDrawdownPerc > 0 {
+		return fmt.Sprintf("%s with max drawdown %.2f%%", o.Metric, o.MaxDrawdownPerc)
+	}
+	return string(o.Metric)
+}
@@ -0,0 +1,248 @@
+package optimizer
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"example.com/gobot-simulator/src/common"
+	"example.com/gobot-simulator/src/strategy"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	tpeMinBandwidth  = 0.02 // of the Parzen kernels, in the unit range of a parameter
+	tpeMaxBandwidth  = 0.5
+	tpeMaxCandidates = 100 // draws per accepted candidate before giving up on the constraints
+)
+
+// TPEConfig sets the Bayesian optimizer. Evaluations are appended to
+// HistoryFile, if set, and the ones found there at start on the same data seed
+// the model, rescored with the current objective. A history file is meant for
+// one strategy type and position side.
+type TPEConfig struct {
+	Budget         int     `json:"budget"`         // new evaluations
+	BatchSize      int     `json:"batchSize"`      // proposals evaluated in parallel
+	InitialSamples int     `json:"initialSamples"` // random proposals before the model is used
+	Candidates     int     `json:"candidates"`     // drawn from the good density for every proposal
+	Gamma          float64 `json:"gamma"`          // fraction of the observations modeled as good
+	Seed           int64   `json:"seed"`
+	HistoryFile    string  `json:"historyFile"`
+}
+
+// TPE is a Tree-structured Parzen Estimator: observations are split into good
+// and bad ones by score, every parameter being modeled by a Parzen density for
+// each group, and the proposals maximize the ratio of the good density over the
+// bad one. Batches are proposed assuming the pending points score as the worst
+// observation.
+type TPE struct {
+	config    TPEConfig
+	space     *strategy.ParameterSpace
+	evaluator *Evaluator
+	random    *rand.Rand
+	observed  []observation
+
+	Constraint func(pars strategy.StrategyParameters) bool // false for parameter sets never proposed
+}
+
+type observation struct {
+	point []float64
+	score float64
+}
+
+func NewTPE(config TPEConfig, space *strategy.ParameterSpace, evaluator *Evaluator) *TPE {
+	return &TPE{config: config, space: space, evaluator: evaluator, random: rand.New(rand.NewSource(config.Seed))}
+}
+
+// PUBLIC METHODS
+// Run evaluates the budget of proposals and returns the best evaluation, the
+// history included.
+func (t *TPE) Run() (Evaluation, error) {
+	if t.config.Budget <= 0 || t.config.BatchSize <= 0 || t.config.Candidates <= 0 || t.config.Gamma <= 0 || t.config.Gamma >= 1 {
+		return Evaluation{}, fmt.Errorf("invalid TPE config %+v", t.config)
+	}
+
+	best := Evaluation{Score: math.Inf(-1)}
+	if t.config.HistoryFile != "" {
+		history, err := LoadHistory(t.config.HistoryFile)
+		if err != nil {
+			return best, err
+		}
+		seeded, err := t.evaluator.Seed(history)
+		if err != nil {
+			return best, fmt.Errorf("history %s: %w", t.config.HistoryFile, err)
+		}
+		for _, evaluation := range seeded {
+			if point, ok := t.space.Point(evaluation.Parameters); ok {
+				t.observed = append(t.observed, observation{point: point, score: evaluation.Score})
+				if evaluation.Score > best.Score {
+					best = evaluation
+				}
+			}
+		}
+		log.Infof("TPE: %d observations from history %s", len(t.observed), t.config.HistoryFile)
+	}
+
+	for evaluated := 0; evaluated < t.config.Budget; {
+		points := t.Propose(int(math.Min(float64(t.config.BatchSize), float64(t.config.Budget-evaluated))))
+		if len(points) == 0 {
+			log.Warn("TPE: no more parameter sets satisfying the constraints")
+			break
+		}
+		sets := make([]strategy.StrategyParameters, len(points))
+		for i, point := range points {
+			sets[i] = t.space.Parameters(point)
+		}
+		evaluations := t.evaluator.Evaluate(sets)
+		for i, evaluation := range evaluations {
+			t.observed = append(t.observed, observation{point: points[i], score: evaluation.Score})
+			if evaluation.Score > best.Score {
+				best = evaluation
+			}
+		}
+		if t.config.HistoryFile != "" {
+			if err := AppendHistory(t.config.HistoryFile, evaluations); err != nil {
+				log.Errorf("Error writing history to file %s: %s", t.config.HistoryFile, err)
+			}
+		}
+		evaluated += len(points)
+		fmt.Printf("TPE %d/%d best %s -> score %.4f (%s), %s\n", evaluated, t.config.Budget,
+			best.Label, best.Score, t.evaluator.Objective().String(), best.Metrics.String())
+	}
+	return best, nil
+}
+
+// Propose returns n new points given the observations, random ones until there
+// are enough observations for the model.
+func (t *TPE) Propose(n int) [][]float64 {
+	observed := append([]observation{}, t.observed...)
+	points := make([][]float64, 0, n)
+	for len(points) < n {
+		var point []float64
+		if len(observed) < t.config.InitialSamples || len(observed) < 2 {
+			point = t.randomPoint(observed)
+		} else {
+			point = t.modelPoint(observed)
+		}
+		if point == nil {
+			break
+		}
+		points = append(points, point)
+		// constant liar: the pending point is assumed as bad as the worst observation
+		worst := math.Inf(1)
+		for _, o := range observed {
+			worst = math.Min(worst, o.score)
+		}
+		if math.IsInf(worst, 1) {
+			worst = failedScore
+		}
+		observed = append(observed, observation{point: point, score: worst})
+	}
+	return points
+}
+
+// PRIVATE METHODS
+func (t *TPE) randomPoint(observed []observation) []float64 {
+	for k := 0; k < tpeMaxCandidates; k++ {
+		point := make([]float64, t.space.Dimensions())
+		for d := range point {
+			point[d] = t.random.Float64()
+		}
+		if t.acceptable(point, observed) {
+			return point
+		}
+	}
+	return nil
+}
+
+func (t *TPE) modelPoint(observed []observation) []float64 {
+	sorted := append([]observation{}, observed...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].score > sorted[j].score })
+	nGood := int(math.Max(1, math.Ceil(t.config.Gamma*float64(len(sorted)))))
+	good, bad := newParzen(sorted[:nGood], t.space.Dimensions()), newParzen(sorted[nGood:], t.space.Dimensions())
+
+	var best []float64
+	bestRatio := math.Inf(-1)
+	for drawn, accepted := 0, 0; accepted < t.config.Candidates && drawn < t.config.Candidates*tpeMaxCandidates; drawn++ {
+		point := good.sample(t.random)
+		if !t.acceptable(point, observed) {
+			continue
+		}
+		accepted++
+		if ratio := good.logDensity(point) - bad.logDensity(point); ratio > bestRatio {
+			best, bestRatio = point, ratio
+		}
+	}
+	return best
+}
+
+// acceptable returns true if the parameters of the point satisfy the constraint
+// and have not been observed yet
+func (t *TPE) acceptable(point []float64, observed []observation) bool {
+	pars := t.space.Parameters(point)
+	if t.Constraint != nil && !t.Constraint(pars) {
+		return false
+	}
+	for _, o := range observed {
+		if t.space.Parameters(o.point) == pars {
+			return false
+		}
+	}
+	return true
+}
+
+// parzen is a product of one dimensional Parzen densities on [0, 1] mixed with
+// a uniform prior, weighted as one more observation.
+type parzen struct {
+	points     [][]float64
+	bandwidths []float64
+}
+
+func newParzen(observed []observation, dimensions int) *parzen {
+	p := &parzen{bandwidths: make([]float64, dimensions)}
+	for _, o := range observed {
+		p.points = append(p.points, o.point)
+	}
+	n := float64(len(p.points))
+	for d := range p.bandwidths {
+		values := make([]float64, len(p.points))
+		for i, point := range p.points {
+			values[i] = point[d]
+		}
+		_, std := common.MeanStd(values)
+		// wide kernels with few observations, not to exploit too early
+		minBandwidth := math.Max(tpeMinBandwidth, 0.5/math.Sqrt(n+1))
+		p.bandwidths[d] = math.Min(math.Max(1.06*std*math.Pow(n+1, -0.2), minBandwidth), tpeMaxBandwidth)
+	}
+	return p
+}
+
+// sample draws around a random observation, uniformly from the prior sometimes
+func (p *parzen) sample(random *rand.Rand) []float64 {
+	point := make([]float64, len(p.bandwidths))
+	k := random.Intn(len(p.points) + 1)
+	for d := range point {
+		if k == len(p.points) {
+			point[d] = random.Float64()
+			continue
+		}
+		point[d] = math.Min(math.Max(p.points[k][d]+random.NormFloat64()*p.bandwidths[d], 0), 1)
+	}
+	return point
+}
+
+func (p *parzen) logDensity(point []float64) float64 {
+	logDensity := 0.0
+	n := float64(len(p.points) + 1)
+	for d, x := range point {
+		density := 1 / n // uniform prior
+		for _, o := range p.points {
+			z := (x - o[d]) / p.bandwidths[d]
+			density += math.Exp(-z*z/2) / (p.bandwidths[d] * math.Sqrt(2*math.Pi)) / n
+		}
+		logDensity += math.Log(density)
+	}
+	return logDensity
+}
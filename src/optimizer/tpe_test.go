@@ -0,0 +1,78 @@
+package optimizer
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"example.com/gobot-simulator/src/strategy"
+)
+
+func testTPEConfig() TPEConfig {
+	return TPEConfig{Budget: 30, BatchSize: 3, InitialSamples: 8, Candidates: 24, Gamma: 0.25, Seed: 1}
+}
+
+func TestTPEToyObjective(t *testing.T) {
+	e := newTestEvaluator()
+	tpe := NewTPE(testTPEConfig(), newToySpace(t, e, 37), e)
+	best, err := tpe.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if best.Label != toyLabel {
+		t.Fatalf("the simulator was run")
+	}
+	if got := gridOrders(t, best.Parameters); math.Abs(got-37) > 2 {
+		t.Errorf("got GO %g, want 37 within 2", got)
+	}
+	if len(tpe.observed) != testTPEConfig().Budget {
+		t.Errorf("got %d observations, want %d", len(tpe.observed), testTPEConfig().Budget)
+	}
+}
+
+func TestTPEConstraint(t *testing.T) {
+	e := newTestEvaluator()
+	space := newToySpace(t, e, 37)
+	config := testTPEConfig()
+	tpe := NewTPE(config, space, e)
+	tpe.Constraint = func(pars strategy.StrategyParameters) bool {
+		return math.Mod(gridOrders(t, pars), 2) == 0
+	}
+	if _, err := tpe.Run(); err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[float64]bool)
+	for _, o := range tpe.observed {
+		value := gridOrders(t, space.Parameters(o.point))
+		if math.Mod(value, 2) != 0 {
+			t.Errorf("proposed GO %g breaking the constraint", value)
+		}
+		if seen[value] {
+			t.Errorf("proposed GO %g twice", value)
+		}
+		seen[value] = true
+	}
+}
+
+func TestTPEHistory(t *testing.T) {
+	e := newTestEvaluator()
+	space := newToySpace(t, e, 37)
+	config := testTPEConfig()
+	config.HistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	first, err := NewTPE(config, space, e).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resumed := NewTPE(config, space, e)
+	best, err := resumed.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resumed.observed) != 2*config.Budget {
+		t.Errorf("got %d observations, want the %d of the history and the new ones", len(resumed.observed), 2*config.Budget)
+	}
+	if best.Score < first.Score {
+		t.Errorf("got best score %g, below %g of the history", best.Score, first.Score)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"example.com/gobot-simulator/src/store"
+	"example.com/gobot-simulator/src/strategy"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// rank lists the runs of a results store, e.g.
+// rank -store ../results/runs.jsonl -by sharpe -where "maxDrawdownPerc<30" -top 20 -csv top.csv
+func rank(args []string) {
+	flags := flag.NewFlagSet("rank", flag.ExitOnError)
+	storePath := flags.String("store", "../results/runs.jsonl", "results store")
+	by := flags.String("by", "netProfit", "metric or parameter to rank by")
+	ascending := flags.Bool("asc", false, "ascending order")
+	where := flags.String("where", "", "comma separated conditions, e.g. \"maxDrawdownPerc<30,GO>=5\"")
+	top := flags.Int("top", 20, "number of runs, 0 for all")
+	dataset := flags.String("dataset", "", "only the runs of the dataset")
+	strategyType := flags.String("strategy", "", "only the runs of the strategy type")
+	status := flags.String("status", string(store.StatusCompleted), "only the runs with the status")
+	csvPath := flags.String("csv", "", "export the runs to the CSV file")
+	flags.Parse(args)
+
+	query := store.Query{
+		Dataset:      *dataset,
+		StrategyType: strategy.StrategyType(*strategyType),
+		Status:       store.Status(*status),
+		OrderBy:      *by,
+		Ascending:    *ascending,
+		Limit:        *top,
+	}
+	for _, s := range strings.Split(*where, ",") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		condition, err := store.ParseCondition(s)
+		if err != nil {
+			log.Panic(err)
+		}
+		query.Where = append(query.Where, condition)
+	}
+
+	resultsStore, err := store.Open(*storePath)
+	if err != nil {
+		log.Panic(err)
+	}
+	defer resultsStore.Close()
+	records, err := resultsStore.Query(query)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "#\tID\tDataset\tRun\tNet profit\tReturn\tMax DD\tSharpe\tCalmar\tCycles\t")
+	for i, r := range records {
+		m := r.Summary.Metrics
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%.2f\t%.2f%%\t%.2f%%\t%.2f\t%.2f\t%d\t\n",
+			i+1, r.ID, r.Dataset, r.Summary.Label, m.NetProfit, m.ReturnPerc, m.MaxDrawdownPerc, m.Sharpe, m.Calmar, m.Cycles)
+	}
+	w.Flush()
+	fmt.Printf("%d runs of %d in %s\n", len(records), len(resultsStore.Records()), *storePath)
+
+	if *csvPath != "" {
+		if err := store.WriteCSV(*csvPath, records); err != nil {
+			log.Errorf("Error writing runs to file %s: %s", *csvPath, err)
+		} else {
+			log.Infof("Runs saved to %s", *csvPath)
+		}
+	}
+}
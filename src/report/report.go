@@ -0,0 +1,593 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"example.com/gobot-simulator/src/common"
+)
+
+const (
+	maxChartPoints   = 2000
+	histogramBins    = 20
+	monteCarloWorstN = 10 // paths listed in the Monte Carlo report
+)
+
+// WriteSingleRunReport writes a self contained HTML report (inline SVG charts, no
+// external resources) of a single simulation run.
+func WriteSingleRunReport(filepath string, title string, result *common.SimulatorResult) error {
+	history := result.History()
+	if len(history) == 0 {
+		return fmt.Errorf("no simulation status to report")
+	}
+
+	var body strings.Builder
+	if result.IsHedged() {
+		body.WriteString(hedgeMetricsTable(result.Metrics(), result.LongMetrics(), result.ShortMetrics()))
+	} else {
+		body.WriteString(metricsTable([]string{"Metric", "Value"}, result.Metrics().Table()))
+	}
+	body.WriteString(benchmarkTable(result.BenchmarkMetrics()))
+	body.WriteString(priceChart(history))
+	body.WriteString(equityChart(history, result.Benchmarks()))
+	body.WriteString(drawdownChart(history))
+	body.WriteString(positionChart(history))
+	body.WriteString(gridDepthHistogram(history))
+
+	return writePage(filepath, title, body.String())
+}
+
+// WriteComparisonReport writes a self contained HTML report comparing the runs of
+// a sweep, typically the top N by some metric, against the benchmarks.
+func WriteComparisonReport(filepath string, title string, summaries []common.RunSummary, benchmarks []common.Benchmark) error {
+	if len(summaries) == 0 {
+		return fmt.Errorf("no run to report")
+	}
+
+	var body strings.Builder
+	header := []string{"#", "Run"}
+	for _, row := range summaries[0].Metrics.Table() {
+		header = append(header, row[0])
+	}
+	rows := make([][]string, 0)
+	for i, s := range summaries {
+		row := []string{fmt.Sprint(i + 1), s.Label}
+		for _, r := range s.Metrics.Table() {
+			row = append(row, r[1])
+		}
+		rows = append(rows, row)
+	}
+	body.WriteString(table(header, rows))
+
+	equity := &lineChart{title: "Equity", yLabel: "$"}
+	drawdown := &lineChart{title: "Drawdown", yLabel: "%"}
+	for i, s := range summaries {
+		x := toFloat(s.Timestamps)
+		name := fmt.Sprintf("#%d", i+1)
+		equity.series = append(equity.series, series{name: name, color: color(i), x: x, y: s.Equity})
+		drawdown.series = append(drawdown.series, series{name: name, color: color(i), x: x, y: common.DrawdownSeries(s.Equity)})
+	}
+	equity.series = append(equity.series, benchmarkSeries(benchmarks, len(summaries))...)
+	body.WriteString(section(equity.render()))
+	body.WriteString(section(drawdown.render()))
+
+	return writePage(filepath, title, body.String())
+}
+
+// WriteWalkForwardReport writes a self contained HTML report of a walk-forward
+// optimization: the windows with their efficiency and the stitched
+// out-of-sample equity against the benchmarks.
+func WriteWalkForwardReport(filepath string, title string, result *common.WalkForwardResult, benchmarks []common.Benchmark) error {
+	if len(result.Windows) == 0 {
+		return fmt.Errorf("no window to report")
+	}
+
+	var body strings.Builder
+	rows := result.Metrics.Table()
+	rows = append(rows, [2]string{"Walk-forward efficiency", fmt.Sprintf("%.2f", result.Efficiency)})
+	body.WriteString(metricsTable([]string{"Out-of-sample metric", "Value"}, rows))
+
+	header := []string{"#", "In-sample", "Out-of-sample", "Chosen run", "Runs",
+		"IS " + string(result.Metric), "OOS " + string(result.Metric), "IS return", "OOS return", "OOS max drawdown", "Efficiency"}
+	windows := make([][]string, 0, len(result.Windows))
+	for i, w := range result.Windows {
+		windows = append(windows, []string{fmt.Sprint(i + 1),
+			formatRange(w.InSampleStart, w.InSampleEnd), formatRange(w.OutSampleStart, w.OutSampleEnd),
+			w.Label, fmt.Sprint(w.Runs),
+			fmt.Sprintf("%.2f", w.InSample.Score(result.Metric)), fmt.Sprintf("%.2f", w.OutSample.Score(result.Metric)),
+			fmt.Sprintf("%.2f%%", w.InSample.ReturnPerc), fmt.Sprintf("%.2f%%", w.OutSample.ReturnPerc),
+			fmt.Sprintf("%.2f%%", w.OutSample.MaxDrawdownPerc), fmt.Sprintf("%.2f", w.Efficiency)})
+	}
+	body.WriteString(table(header, windows))
+
+	x := toFloat(result.Timestamps)
+	equity := &lineChart{title: "Stitched out-of-sample equity", yLabel: "$"}
+	equity.series = append(equity.series, series{name: "Out-of-sample", color: color(0), x: x, y: result.Equity})
+	equity.series = append(equity.series, benchmarkSeries(benchmarks, 1)...)
+	for _, w := range result.Windows[1:] {
+		equity.markers = append(equity.markers, marker{x: float64(w.OutSampleStart.Unix()), y: equityAt(result, w.OutSampleStart.Unix()), color: color(3), title: "new window " + w.Label})
+	}
+	body.WriteString(section(equity.render()))
+	drawdown := &lineChart{title: "Drawdown", yLabel: "%", series: []series{{name: "Drawdown", color: color(3), fill: true, x: x, y: common.DrawdownSeries(result.Equity)}}}
+	body.WriteString(section(drawdown.render()))
+
+	return writePage(filepath, title, body.String())
+}
+
+// WritePortfolioReport writes a self contained HTML report of a multi symbol
+// portfolio run, with per symbol metrics and drawdown correlation.
+func WritePortfolioReport(filepath string, title string, result *common.PortfolioResult) error {
+	history := result.History()
+	if len(history) == 0 {
+		return fmt.Errorf("no portfolio status to report")
+	}
+	symbols := result.Symbols()
+
+	// metrics of the portfolio and of every symbol
+	header := []string{"Metric", "Portfolio"}
+	// fills and cycles are not tracked at portfolio level
+	omit := []string{"fills", "cycles", "maxGridReached"}
+	columns := [][][2]string{result.Metrics().Table(omit...)}
+	for _, symbol := range symbols {
+		header = append(header, symbol)
+		columns = append(columns, result.SymbolMetrics(symbol).Table(omit...))
+	}
+	rows := make([][]string, 0)
+	for i, row := range columns[0] {
+		r := []string{row[0]}
+		for _, c := range columns {
+			r = append(r, c[i][1])
+		}
+		rows = append(rows, r)
+	}
+
+	var body strings.Builder
+	body.WriteString(table(header, rows))
+
+	// drawdown correlation
+	corr := result.DrawdownCorrelation()
+	corrRows := make([][]string, len(symbols))
+	for i, symbol := range symbols {
+		corrRows[i] = []string{symbol}
+		for j := range symbols {
+			corrRows[i] = append(corrRows[i], fmt.Sprintf("%.2f", corr[i][j]))
+		}
+	}
+	body.WriteString(`<div class="section"><b>Drawdown correlation</b></div>`)
+	body.WriteString(table(append([]string{""}, symbols...), corrRows))
+
+	// equity and drawdown of the portfolio and of every symbol
+	indexes := common.DownsampleIndexes(len(history), maxChartPoints)
+	equity := &lineChart{title: "Equity", yLabel: "$"}
+	drawdown := &lineChart{title: "Drawdown", yLabel: "%"}
+	total := make([]float64, len(history))
+	for i, st := range history {
+		total[i] = st.TotalEquity()
+	}
+	addSeries := func(name string, c string, timestamps []int64, values []float64) {
+		e := series{name: name, color: c}
+		d := series{name: name, color: c}
+		dd := common.DrawdownSeries(values)
+		for _, i := range indexes {
+			e.x = append(e.x, float64(timestamps[i]))
+			e.y = append(e.y, values[i])
+			d.x = append(d.x, float64(timestamps[i]))
+			d.y = append(d.y, dd[i])
+		}
+		equity.series = append(equity.series, e)
+		drawdown.series = append(drawdown.series, d)
+	}
+	timestamps := make([]int64, len(history))
+	for i, st := range history {
+		timestamps[i] = st.Timestamp
+	}
+	addSeries("Portfolio", "#000", timestamps, total)
+	for i, symbol := range symbols {
+		_, values := result.SymbolEquity(symbol)
+		addSeries(symbol, color(i), timestamps, values)
+	}
+	body.WriteString(section(equity.render()))
+	body.WriteString(section(drawdown.render()))
+
+	return writePage(filepath, title, body.String())
+}
+
+// WriteSensitivityReport writes a self contained HTML report of the sensitivity
+// of a sweep: the robustness of the top runs, a curve for every parameter and a
+// heatmap for every pair of parameters.
+func WriteSensitivityReport(filepath string, title string, result common.SensitivityResult) error {
+	if len(result.Parameters) == 0 {
+		return fmt.Errorf("no varied parameter to report")
+	}
+
+	var body strings.Builder
+	others := "marginalized (mean of the runs)"
+	if result.Fixed {
+		others = "fixed at the best run " + result.Best
+	}
+	body.WriteString(section(fmt.Sprintf("<p>Score: %s, higher is better. Parameters not shown are %s.</p>",
+		html.EscapeString(string(result.Metric)), html.EscapeString(others))))
+
+	rows := make([][]string, 0, len(result.Robustness))
+	for i, r := range result.Robustness {
+		rows = append(rows, []string{fmt.Sprint(i + 1), r.Label, formatValue(r.Score), fmt.Sprint(r.Neighbours),
+			formatValue(r.NeighbourMean), formatValue(r.NeighbourMin), formatValue(r.Robust), fmt.Sprintf("%.2f", r.Retention)})
+	}
+	body.WriteString(table([]string{"#", "Run", "Score", "Neighbours", "Neighbour mean", "Neighbour min", "Robust score", "Retention"}, rows))
+
+	for _, c := range result.Curves {
+		body.WriteString(section(sensitivityChart(c, result.Metric).render()))
+	}
+	var heatmaps strings.Builder
+	for _, h := range result.Heatmaps {
+		heatmaps.WriteString(heatmap(h, result.Metric).render())
+	}
+	body.WriteString(section(heatmaps.String()))
+
+	return writePage(filepath, title, body.String())
+}
+
+// WriteSensitivityCharts writes every curve and heatmap of the sensitivity
+// result to its own SVG file, named after prefix and the parameters.
+func WriteSensitivityCharts(prefix string, result common.SensitivityResult) error {
+	for _, c := range result.Curves {
+		if err := os.WriteFile(prefix+"_sensitivity_"+c.Parameter+".svg", []byte(sensitivityChart(c, result.Metric).render()), 0644); err != nil {
+			return err
+		}
+	}
+	for _, h := range result.Heatmaps {
+		if err := os.WriteFile(prefix+"_heatmap_"+h.X+"_"+h.Y+".svg", []byte(heatmap(h, result.Metric).render()), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteMonteCarloReport writes a self contained HTML report of a Monte Carlo
+// test: the distributions of the paths against the historical run, their
+// histograms and the worst paths.
+func WriteMonteCarloReport(filepath string, title string, result *common.MonteCarloResult) error {
+	if len(result.Paths) == 0 {
+		return fmt.Errorf("no path to report")
+	}
+
+	var body strings.Builder
+	body.WriteString(metricsTable([]string{"Metric", "Value"}, [][2]string{
+		{"Paths", fmt.Sprint(len(result.Paths))},
+		{"Failed paths", fmt.Sprint(result.Failed)},
+		{"Historical return", fmt.Sprintf("%.2f%%", result.Historical.ReturnPerc)},
+		{"Historical max drawdown", fmt.Sprintf("%.2f%%", result.Historical.MaxDrawdownPerc)},
+		{fmt.Sprintf("Probability of ruin (-%.0f%%)", result.RuinLossPerc), fmt.Sprintf("%.2f%%", result.RuinProbability*100)},
+		{"Probability of liquidation", fmt.Sprintf("%.2f%%", result.LiquidationProbability*100)},
+	}))
+	distributions := make([][]string, 0, 2)
+	for _, d := range []struct {
+		name string
+		d    common.Distribution
+	}{{"Return %", result.Return}, {"Max drawdown %", result.MaxDrawdown}} {
+		distributions = append(distributions, []string{d.name, fmt.Sprintf("%.2f", d.d.Mean), fmt.Sprintf("%.2f", d.d.Std), fmt.Sprintf("%.2f", d.d.Min),
+			fmt.Sprintf("%.2f", d.d.P5), fmt.Sprintf("%.2f", d.d.P25), fmt.Sprintf("%.2f", d.d.Median), fmt.Sprintf("%.2f", d.d.P75), fmt.Sprintf("%.2f", d.d.P95), fmt.Sprintf("%.2f", d.d.Max)})
+	}
+	body.WriteString(table([]string{"Distribution", "Mean", "Std", "Min", "P5", "P25", "Median", "P75", "P95", "Max"}, distributions))
+
+	returns, drawdowns := make([]float64, 0, len(result.Paths)), make([]float64, 0, len(result.Paths))
+	completed := make([]common.MonteCarloPath, 0, len(result.Paths))
+	for _, p := range result.Paths {
+		if p.Error == "" {
+			returns = append(returns, p.Metrics.ReturnPerc)
+			drawdowns = append(drawdowns, p.Metrics.MaxDrawdownPerc)
+			completed = append(completed, p)
+		}
+	}
+	body.WriteString(histogram("Return of the paths", "%", returns))
+	body.WriteString(histogram("Max drawdown of the paths", "%", drawdowns))
+
+	sort.SliceStable(completed, func(i, j int) bool { return completed[i].Metrics.ReturnPerc < completed[j].Metrics.ReturnPerc })
+	if len(completed) > monteCarloWorstN {
+		completed = completed[:monteCarloWorstN]
+	}
+	worst := make([][]string, 0, len(completed))
+	for _, p := range completed {
+		worst = append(worst, []string{fmt.Sprint(p.Index), fmt.Sprintf("%.2f%%", p.Metrics.ReturnPerc), fmt.Sprintf("%.2f%%", p.Metrics.MaxDrawdownPerc),
+			fmt.Sprintf("%.2f", p.MinEquity), fmt.Sprint(p.Metrics.MaxGridReached), fmt.Sprint(p.Ruined), fmt.Sprint(p.Liquidated)})
+	}
+	body.WriteString(table([]string{"Worst path", "Return", "Max drawdown", "Min equity", "Max grid", "Ruined", "Liquidated"}, worst))
+
+	return writePage(filepath, title, body.String())
+}
+
+// PRIVATE FUNCTIONS
+func priceChart(history []common.SimulatorStatus) string {
+	chart := &lineChart{title: "Price and fills", yLabel: "$"}
+	indexes := common.DownsampleIndexes(len(history), maxChartPoints)
+	price := series{name: "Mark price", color: "#444"}
+	for _, i := range indexes {
+		price.x = append(price.x, float64(history[i].Timestamp))
+		price.y = append(price.y, history[i].MarkPrice)
+	}
+	chart.series = append(chart.series, price)
+
+	// fills, colored by grid level (take profits and stop losses in black)
+	levels := map[int64]bool{}
+	for i := 1; i < len(history); i++ {
+		prev, st := history[i-1], history[i]
+		if st.LongOrderAmount != 0 {
+			chart.markers = append(chart.markers, fillMarker(st.Timestamp, st.LongOrderPrice, st.MarkPrice, st.LongOrderAmount,
+				"LONG", st.LongGridReached, st.LongPositionSize < prev.LongPositionSize, levels))
+		}
+		if st.ShortOrderAmount != 0 {
+			chart.markers = append(chart.markers, fillMarker(st.Timestamp, st.ShortOrderPrice, st.MarkPrice, st.ShortOrderAmount,
+				"SHORT", st.ShortGridReached, st.ShortPositionSize < prev.ShortPositionSize, levels))
+		}
+	}
+	for level := int64(0); level <= maxKey(levels); level++ {
+		if levels[level] {
+			chart.legend = append(chart.legend, series{name: fmt.Sprintf("grid %d", level), color: color(int(level) + 1)})
+		}
+	}
+	chart.legend = append(chart.legend, series{name: "TP/SL", color: "#000"})
+	return section(chart.render())
+}
+
+func fillMarker(timestamp int64, orderPrice float64, markPrice float64, amount float64, side string, grid int64, isReduce bool, levels map[int64]bool) marker {
+	price := orderPrice
+	if price == 0 { // market order
+		price = markPrice
+	}
+	m := marker{x: float64(timestamp), y: price}
+	if isReduce {
+		m.color = "#000"
+		m.title = fmt.Sprintf("%s TP/SL %.6f @ %.6f", side, amount, price)
+	} else {
+		levels[grid] = true
+		m.color = color(int(grid) + 1)
+		m.title = fmt.Sprintf("%s grid %d: %.6f @ %.6f", side, grid, amount, price)
+	}
+	return m
+}
+
+func equityChart(history []common.SimulatorStatus, benchmarks []common.Benchmark) string {
+	chart := &lineChart{title: "Equity", yLabel: "$"}
+	total := series{name: "Equity (mark to market)", color: color(0)}
+	wallet := series{name: "Wallet balance", color: color(1), dash: true}
+	for _, i := range common.DownsampleIndexes(len(history), maxChartPoints) {
+		x := float64(history[i].Timestamp)
+		total.x = append(total.x, x)
+		total.y = append(total.y, history[i].TotalEquity())
+		wallet.x = append(wallet.x, x)
+		wallet.y = append(wallet.y, history[i].Equity)
+	}
+	chart.series = append(chart.series, total, wallet)
+	chart.series = append(chart.series, benchmarkSeries(benchmarks, 2)...)
+	return section(chart.render())
+}
+
+// benchmarkSeries returns dashed series for the benchmarks, with colors starting
+// from the given palette index.
+func benchmarkSeries(benchmarks []common.Benchmark, firstColor int) []series {
+	out := make([]series, 0, len(benchmarks))
+	for i, b := range benchmarks {
+		s := series{name: b.Name, color: color(firstColor + i), dash: true}
+		for _, j := range common.DownsampleIndexes(len(b.Equity), maxChartPoints) {
+			s.x = append(s.x, float64(b.Timestamps[j]))
+			s.y = append(s.y, b.Equity[j])
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func benchmarkTable(metrics []common.BenchmarkMetrics) string {
+	if len(metrics) == 0 {
+		return ""
+	}
+	header := []string{"Benchmark", "Return", "Max drawdown", "Excess return", "Alpha", "Beta"}
+	rows := make([][]string, 0, len(metrics))
+	for _, m := range metrics {
+		rows = append(rows, []string{m.Name,
+			fmt.Sprintf("%.2f%%", m.ReturnPerc), fmt.Sprintf("%.2f%%", m.MaxDrawdownPerc),
+			fmt.Sprintf("%.2f%%", m.ExcessReturnPerc), fmt.Sprintf("%.4f", m.Alpha), fmt.Sprintf("%.4f", m.Beta)})
+	}
+	return table(header, rows)
+}
+
+func drawdownChart(history []common.SimulatorStatus) string {
+	// drawdown is computed on the full history so that no peak is missed
+	equity := make([]float64, len(history))
+	for i, st := range history {
+		equity[i] = st.TotalEquity()
+	}
+	dd := common.DrawdownSeries(equity)
+
+	s := series{name: "Drawdown", color: color(3), fill: true}
+	for _, i := range common.DownsampleIndexes(len(history), maxChartPoints) {
+		s.x = append(s.x, float64(history[i].Timestamp))
+		s.y = append(s.y, dd[i])
+	}
+	chart := &lineChart{title: "Drawdown", yLabel: "%", series: []series{s}}
+	return section(chart.render())
+}
+
+func positionChart(history []common.SimulatorStatus) string {
+	long := series{name: "Long size", color: color(2), fill: true}
+	short := series{name: "Short size", color: color(3), fill: true}
+	for _, i := range common.DownsampleIndexes(len(history), maxChartPoints) {
+		x := float64(history[i].Timestamp)
+		long.x = append(long.x, x)
+		long.y = append(long.y, history[i].LongPositionSize)
+		short.x = append(short.x, x)
+		short.y = append(short.y, -history[i].ShortPositionSize)
+	}
+	chart := &lineChart{title: "Position size", yLabel: "size", series: []series{long, short}}
+	return section(chart.render())
+}
+
+func gridDepthHistogram(history []common.SimulatorStatus) string {
+	counts := map[int64]bool{}
+	hist := map[int64]float64{}
+	for _, depth := range common.GridDepths(history) {
+		counts[depth] = true
+		hist[depth]++
+	}
+	chart := &barChart{title: "Grid depth reached per cycle", yLabel: "cycles"}
+	for level := int64(0); level <= maxKey(counts); level++ {
+		chart.labels = append(chart.labels, fmt.Sprint(level))
+		chart.values = append(chart.values, hist[level])
+	}
+	return section(chart.render())
+}
+
+func sensitivityChart(c common.SensitivityCurve, metric common.MetricName) *lineChart {
+	chart := &lineChart{title: fmt.Sprintf("%s by %s", metric, c.Parameter), xLabel: c.Parameter, yLabel: string(metric)}
+	mean, min, max := series{name: "Mean", color: color(0)}, series{name: "Min", color: color(3), dash: true}, series{name: "Max", color: color(2), dash: true}
+	for i, v := range c.Values {
+		if c.Counts[i] == 0 {
+			continue
+		}
+		mean.x, mean.y = append(mean.x, v), append(mean.y, c.Mean[i])
+		min.x, min.y = append(min.x, v), append(min.y, c.Min[i])
+		max.x, max.y = append(max.x, v), append(max.y, c.Max[i])
+		chart.markers = append(chart.markers, marker{x: v, y: c.Mean[i], color: color(0),
+			title: fmt.Sprintf("%s %g: mean %g over %d runs", c.Parameter, v, c.Mean[i], c.Counts[i])})
+	}
+	chart.series = []series{mean, min, max}
+	return chart
+}
+
+func heatmap(h common.Heatmap, metric common.MetricName) *heatmapChart {
+	chart := &heatmapChart{title: fmt.Sprintf("%s by %s and %s", metric, h.X, h.Y), xLabel: h.X, yLabel: h.Y,
+		xValues: h.XValues, yValues: h.YValues, values: h.Scores, empty: make([][]bool, len(h.Counts))}
+	for j, row := range h.Counts {
+		chart.empty[j] = make([]bool, len(row))
+		for i, count := range row {
+			chart.empty[j][i] = count == 0
+		}
+	}
+	return chart
+}
+
+// histogram counts the values in equal bins between their min and max
+func histogram(title string, unit string, values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	min, max := values[0], values[0]
+	for _, v := range values {
+		min, max = math.Min(min, v), math.Max(max, v)
+	}
+	bins := histogramBins
+	if max == min {
+		bins = 1
+	}
+	width := (max - min) / float64(bins)
+	chart := &barChart{title: title, yLabel: "paths", labels: make([]string, bins), values: make([]float64, bins)}
+	for i := range chart.labels {
+		chart.labels[i] = formatValue(min+(float64(i)+0.5)*width) + unit
+	}
+	for _, v := range values {
+		i := bins - 1
+		if width > 0 {
+			i = int(math.Min((v-min)/width, float64(bins-1)))
+		}
+		chart.values[i]++
+	}
+	return section(chart.render())
+}
+
+func metricsTable(header []string, rows [][2]string) string {
+	r := make([][]string, len(rows))
+	for i, row := range rows {
+		r[i] = []string{row[0], row[1]}
+	}
+	return table(header, r)
+}
+
+func hedgeMetricsTable(combined common.Metrics, long common.Metrics, short common.Metrics) string {
+	longTable, shortTable := long.Table(), short.Table()
+	rows := make([][]string, 0)
+	for i, row := range combined.Table() {
+		rows = append(rows, []string{row[0], row[1], longTable[i][1], shortTable[i][1]})
+	}
+	return table([]string{"Metric", "Combined", "Long", "Short"}, rows)
+}
+
+func table(header []string, rows [][]string) string {
+	var sb strings.Builder
+	sb.WriteString(`<table><tr>`)
+	for _, h := range header {
+		fmt.Fprintf(&sb, `<th>%s</th>`, html.EscapeString(h))
+	}
+	sb.WriteString(`</tr>`)
+	for _, row := range rows {
+		sb.WriteString(`<tr>`)
+		for _, v := range row {
+			fmt.Fprintf(&sb, `<td>%s</td>`, html.EscapeString(v))
+		}
+		sb.WriteString(`</tr>`)
+	}
+	sb.WriteString(`</table>`)
+	return section(sb.String())
+}
+
+func section(content string) string {
+	return `<div class="section">` + content + `</div>`
+}
+
+func writePage(filepath string, title string, body string) error {
+	file, err := os.OpenFile(filepath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	fmt.Fprintf(w, `<!DOCTYPE html><html><head><meta charset="utf-8"><title>%s</title><style>
+body { font-family: sans-serif; margin: 20px; }
+table { border-collapse: collapse; font-size: 12px; }
+th, td { border: 1px solid #ccc; padding: 3px 8px; text-align: right; }
+th { background: #f3f3f3; }
+.section { margin-bottom: 20px; }
+</style></head><body><h2>%s</h2>
+`, html.EscapeString(title), html.EscapeString(title))
+	w.WriteString(body)
+	w.WriteString("\n</body></html>\n")
+	return w.Flush()
+}
+
+func formatRange(from time.Time, to time.Time) string {
+	return from.UTC().Format("2006-01-02 15:04") + " - " + to.UTC().Format("2006-01-02 15:04")
+}
+
+// equityAt returns the stitched equity at the first point not before timestamp
+func equityAt(result *common.WalkForwardResult, timestamp int64) float64 {
+	for i, t := range result.Timestamps {
+		if t >= timestamp {
+			return result.Equity[i]
+		}
+	}
+	return result.Equity[len(result.Equity)-1]
+}
+
+func toFloat(values []int64) []float64 {
+	out := make([]float64, len(values))
+	for i, v := range values {
+		out[i] = float64(v)
+	}
+	return out
+}
+
+func maxKey(m map[int64]bool) int64 {
+	max := int64(-1)
+	for k := range m {
+		if k > max {
+			max = k
+		}
+	}
+	return max
+}
@@ -0,0 +1,299 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	chartWidth   = 1100
+	chartHeight  = 260
+	marginLeft   = 70
+	marginRight  = 20
+	marginTop    = 30
+	marginBottom = 30
+)
+
+var palette = []string{
+	"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd",
+	"#8c564b", "#e377c2", "#7f7f7f", "#bcbd22", "#17becf",
+}
+
+func color(i int) string {
+	return palette[i%len(palette)]
+}
+
+type series struct {
+	name  string
+	color string
+	x     []float64 // unix timestamps
+	y     []float64
+	fill  bool // fill the area between the series and the zero line
+	dash  bool
+}
+
+type marker struct {
+	x     float64
+	y     float64
+	color string
+	title string
+}
+
+type lineChart struct {
+	title   string
+	xLabel  string // numeric x axis if set, time otherwise
+	yLabel  string
+	series  []series
+	markers []marker
+	legend  []series // additional legend entries (e.g. marker colors)
+}
+
+type bounds struct {
+	xMin, xMax, yMin, yMax float64
+}
+
+func (c *lineChart) bounds() bounds {
+	b := bounds{math.Inf(1), math.Inf(-1), math.Inf(1), math.Inf(-1)}
+	update := func(x, y float64) {
+		b.xMin, b.xMax = math.Min(b.xMin, x), math.Max(b.xMax, x)
+		b.yMin, b.yMax = math.Min(b.yMin, y), math.Max(b.yMax, y)
+	}
+	for _, s := range c.series {
+		for i := range s.x {
+			update(s.x[i], s.y[i])
+		}
+		if s.fill {
+			b.yMin, b.yMax = math.Min(b.yMin, 0), math.Max(b.yMax, 0)
+		}
+	}
+	for _, m := range c.markers {
+		update(m.x, m.y)
+	}
+	if math.IsInf(b.xMin, 0) {
+		return bounds{0, 1, 0, 1}
+	}
+	if b.xMax == b.xMin {
+		b.xMax = b.xMin + 1
+	}
+	if b.yMax == b.yMin {
+		b.yMax = b.yMin + 1
+	}
+	pad := (b.yMax - b.yMin) * 0.05
+	b.yMin -= pad
+	b.yMax += pad
+	return b
+}
+
+func (c *lineChart) render() string {
+	b := c.bounds()
+	plotW := float64(chartWidth - marginLeft - marginRight)
+	plotH := float64(chartHeight - marginTop - marginBottom)
+	px := func(x float64) float64 { return marginLeft + (x-b.xMin)/(b.xMax-b.xMin)*plotW }
+	py := func(y float64) float64 { return marginTop + (b.yMax-y)/(b.yMax-b.yMin)*plotH }
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="11">`, chartWidth, chartHeight)
+	fmt.Fprintf(&sb, `<text x="%d" y="18" font-size="14" font-weight="bold">%s</text>`, marginLeft, html.EscapeString(c.title))
+	writeAxes(&sb, b, px, py, c.yLabel, c.xLabel == "")
+	if c.xLabel != "" {
+		writeNumericXAxis(&sb, b, px, c.xLabel)
+	}
+
+	for _, s := range c.series {
+		if len(s.x) == 0 {
+			continue
+		}
+		points := make([]string, len(s.x))
+		for i := range s.x {
+			points[i] = fmt.Sprintf("%.1f,%.1f", px(s.x[i]), py(s.y[i]))
+		}
+		if s.fill {
+			zero := py(math.Max(math.Min(0, b.yMax), b.yMin))
+			fmt.Fprintf(&sb, `<polygon points="%.1f,%.1f %s %.1f,%.1f" fill="%s" fill-opacity="0.3" stroke="none"/>`,
+				px(s.x[0]), zero, strings.Join(points, " "), px(s.x[len(s.x)-1]), zero, s.color)
+		}
+		dash := ""
+		if s.dash {
+			dash = ` stroke-dasharray="4,3"`
+		}
+		fmt.Fprintf(&sb, `<polyline points="%s" fill="none" stroke="%s" stroke-width="1.2"%s/>`, strings.Join(points, " "), s.color, dash)
+	}
+
+	for _, m := range c.markers {
+		fmt.Fprintf(&sb, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"><title>%s</title></circle>`,
+			px(m.x), py(m.y), m.color, html.EscapeString(m.title))
+	}
+
+	writeLegend(&sb, append(append([]series{}, c.series...), c.legend...))
+	sb.WriteString(`</svg>`)
+	return sb.String()
+}
+
+type barChart struct {
+	title  string
+	yLabel string
+	labels []string
+	values []float64
+}
+
+func (c *barChart) render() string {
+	b := bounds{0, float64(len(c.values)), 0, 1}
+	for _, v := range c.values {
+		b.yMax = math.Max(b.yMax, v)
+	}
+	plotW := float64(chartWidth - marginLeft - marginRight)
+	plotH := float64(chartHeight - marginTop - marginBottom)
+	px := func(x float64) float64 { return marginLeft + (x-b.xMin)/(b.xMax-b.xMin)*plotW }
+	py := func(y float64) float64 { return marginTop + (b.yMax-y)/(b.yMax-b.yMin)*plotH }
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="11">`, chartWidth, chartHeight)
+	fmt.Fprintf(&sb, `<text x="%d" y="18" font-size="14" font-weight="bold">%s</text>`, marginLeft, html.EscapeString(c.title))
+	writeAxes(&sb, b, px, py, c.yLabel, false)
+
+	barW := plotW / math.Max(float64(len(c.values)), 1)
+	for i, v := range c.values {
+		x := px(float64(i))
+		fmt.Fprintf(&sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s: %g</title></rect>`,
+			x+barW*0.1, py(v), barW*0.8, py(0)-py(v), color(0), html.EscapeString(c.labels[i]), v)
+		fmt.Fprintf(&sb, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`,
+			x+barW/2, chartHeight-marginBottom+14, html.EscapeString(c.labels[i]))
+	}
+	sb.WriteString(`</svg>`)
+	return sb.String()
+}
+
+func writeAxes(sb *strings.Builder, b bounds, px, py func(float64) float64, yLabel string, timeAxis bool) {
+	left, right := float64(marginLeft), float64(chartWidth-marginRight)
+	top, bottom := float64(marginTop), float64(chartHeight-marginBottom)
+	fmt.Fprintf(sb, `<rect x="%.0f" y="%.0f" width="%.0f" height="%.0f" fill="none" stroke="#ccc"/>`, left, top, right-left, bottom-top)
+
+	const ticks = 5
+	for i := 0; i <= ticks; i++ {
+		y := b.yMin + (b.yMax-b.yMin)*float64(i)/ticks
+		fmt.Fprintf(sb, `<line x1="%.0f" x2="%.0f" y1="%.1f" y2="%.1f" stroke="#eee"/>`, left, right, py(y), py(y))
+		fmt.Fprintf(sb, `<text x="%.0f" y="%.1f" text-anchor="end">%s</text>`, left-4, py(y)+4, formatValue(y))
+	}
+	if timeAxis {
+		for i := 0; i <= ticks; i++ {
+			x := b.xMin + (b.xMax-b.xMin)*float64(i)/ticks
+			label := time.Unix(int64(x), 0).UTC().Format("2006-01-02 15:04")
+			fmt.Fprintf(sb, `<text x="%.1f" y="%.0f" text-anchor="middle">%s</text>`, px(x), bottom+14, label)
+		}
+	}
+	if yLabel != "" {
+		fmt.Fprintf(sb, `<text x="12" y="%.0f" transform="rotate(-90 12 %.0f)" text-anchor="middle">%s</text>`,
+			(top+bottom)/2, (top+bottom)/2, html.EscapeString(yLabel))
+	}
+}
+
+func writeNumericXAxis(sb *strings.Builder, b bounds, px func(float64) float64, xLabel string) {
+	const ticks = 5
+	bottom := float64(chartHeight - marginBottom)
+	for i := 0; i <= ticks; i++ {
+		x := b.xMin + (b.xMax-b.xMin)*float64(i)/ticks
+		fmt.Fprintf(sb, `<text x="%.1f" y="%.0f" text-anchor="middle">%s</text>`, px(x), bottom+14, formatValue(x))
+	}
+	fmt.Fprintf(sb, `<text x="%d" y="%.0f" text-anchor="end" font-weight="bold">%s</text>`, chartWidth-marginRight, bottom+28, html.EscapeString(xLabel))
+}
+
+func writeLegend(sb *strings.Builder, entries []series) {
+	x := float64(chartWidth - marginRight)
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].name == "" {
+			continue
+		}
+		x -= float64(len(entries[i].name))*6 + 24
+		fmt.Fprintf(sb, `<rect x="%.0f" y="9" width="10" height="10" fill="%s"/>`, x, entries[i].color)
+		fmt.Fprintf(sb, `<text x="%.0f" y="18">%s</text>`, x+14, html.EscapeString(entries[i].name))
+	}
+}
+
+// heatmapChart colors every cell from red (lowest) to green (highest), cells
+// without a value being left grey
+type heatmapChart struct {
+	title   string
+	xLabel  string
+	yLabel  string
+	xValues []float64
+	yValues []float64
+	values  [][]float64 // by row, following yValues
+	empty   [][]bool
+}
+
+func (c *heatmapChart) render() string {
+	const width, height = 540, 380
+	left, right, top, bottom := float64(marginLeft), float64(width-marginRight), float64(marginTop), float64(height-marginBottom-14)
+	cellW := (right - left) / math.Max(float64(len(c.xValues)), 1)
+	cellH := (bottom - top) / math.Max(float64(len(c.yValues)), 1)
+
+	min, max := math.Inf(1), math.Inf(-1)
+	for j := range c.values {
+		for i, v := range c.values[j] {
+			if !c.empty[j][i] {
+				min, max = math.Min(min, v), math.Max(max, v)
+			}
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="11">`, width, height)
+	fmt.Fprintf(&sb, `<text x="%d" y="18" font-size="14" font-weight="bold">%s</text>`, marginLeft, html.EscapeString(c.title))
+	for j, y := range c.yValues {
+		// first row at the bottom
+		cy := bottom - float64(j+1)*cellH
+		fmt.Fprintf(&sb, `<text x="%.0f" y="%.1f" text-anchor="end">%s</text>`, left-4, cy+cellH/2+4, formatValue(y))
+		for i, x := range c.xValues {
+			cx := left + float64(i)*cellW
+			fill, title := "#f3f3f3", fmt.Sprintf("%s %g, %s %g: no run", c.xLabel, x, c.yLabel, y)
+			if !c.empty[j][i] {
+				fill, title = heatColor(c.values[j][i], min, max), fmt.Sprintf("%s %g, %s %g: %g", c.xLabel, x, c.yLabel, y, c.values[j][i])
+			}
+			fmt.Fprintf(&sb, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s" stroke="#fff"><title>%s</title></rect>`,
+				cx, cy, cellW, cellH, fill, html.EscapeString(title))
+			if !c.empty[j][i] && cellW >= 40 && cellH >= 14 {
+				fmt.Fprintf(&sb, `<text x="%.1f" y="%.1f" text-anchor="middle" font-size="10">%s</text>`, cx+cellW/2, cy+cellH/2+4, formatValue(c.values[j][i]))
+			}
+		}
+	}
+	for i, x := range c.xValues {
+		fmt.Fprintf(&sb, `<text x="%.1f" y="%.0f" text-anchor="middle">%s</text>`, left+(float64(i)+0.5)*cellW, bottom+14, formatValue(x))
+	}
+	fmt.Fprintf(&sb, `<text x="%.0f" y="%.0f" text-anchor="middle" font-weight="bold">%s</text>`, (left+right)/2, bottom+30, html.EscapeString(c.xLabel))
+	fmt.Fprintf(&sb, `<text x="12" y="%.0f" transform="rotate(-90 12 %.0f)" text-anchor="middle" font-weight="bold">%s</text>`,
+		(top+bottom)/2, (top+bottom)/2, html.EscapeString(c.yLabel))
+	sb.WriteString(`</svg>`)
+	return sb.String()
+}
+
+// heatColor interpolates from red to yellow to green
+func heatColor(v float64, min float64, max float64) string {
+	t := 0.5
+	if max > min {
+		t = (v - min) / (max - min)
+	}
+	r, g := 1.0, 1.0
+	if t < 0.5 {
+		g = 0.3 + 1.4*t
+	} else {
+		r = 1 - 1.4*(t-0.5)
+	}
+	return fmt.Sprintf("#%02x%02x%02x", int(r*230), int(g*200), 80)
+}
+
+func formatValue(v float64) string {
+	abs := math.Abs(v)
+	switch {
+	case abs >= 1000:
+		return fmt.Sprintf("%.0f", v)
+	case abs >= 10:
+		return fmt.Sprintf("%.1f", v)
+	case abs >= 0.1:
+		return fmt.Sprintf("%.3f", v)
+	default:
+		return fmt.Sprintf("%.5f", v)
+	}
+}
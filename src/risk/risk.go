@@ -0,0 +1,360 @@
+package risk
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"example.com/gobot-simulator/src/common"
+	"example.com/gobot-simulator/src/engine"
+	"example.com/gobot-simulator/src/filter"
+	"example.com/gobot-simulator/src/strategy"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type Limit string
+
+const (
+	LimitDrawdown         Limit = "DRAWDOWN"          // % of the account equity from its peak
+	LimitDailyLoss        Limit = "DAILY_LOSS"        // % of the account equity at the start of the UTC day
+	LimitPositionNotional Limit = "POSITION_NOTIONAL" // notional of a position side
+	LimitGridDepth        Limit = "GRID_DEPTH"        // grid reached, deeper grid orders are never placed
+	LimitOpenOrders       Limit = "OPEN_ORDERS"       // open orders of the account, only rejecting orders
+)
+
+type Action string
+
+const (
+	ActionBlockEntries Action = "BLOCK_ENTRIES" // no new cycle
+	ActionFlatten      Action = "FLATTEN"       // cancel the orders, close the positions and block new entries
+	ActionStop         Action = "STOP"          // flatten and stop the run
+)
+
+// Rule triggers its action when the limit goes beyond the threshold (the grid
+// depth when it is reached). Orders that would break a position notional, grid
+// depth or open orders limit are rejected before reaching the exchange: a
+// rejection does not trigger the action, which applies only to the limits
+// reached by the account.
+type Rule struct {
+	Limit     Limit   `json:"limit"`
+	Threshold float64 `json:"threshold"`
+	Action    Action  `json:"action"`
+}
+
+// Flattener cancels the orders of a strategy through its context, so that the
+// strategy is notified, and closes its positions at market
+type Flattener interface {
+	Flatten()
+}
+
+// Manager enforces the rules on an account, sitting between the workers and the
+// exchange APIs. Actions triggered by the daily loss last until the end of the
+// UTC day, the other ones until the end of the run.
+type Manager struct {
+	rules      []Rule
+	apis       []*engine.ExchangeAPI // one per symbol of the account
+	flatteners []Flattener
+
+	peakEquity     float64
+	dayStart       time.Time
+	dayStartEquity float64
+	triggered      map[Limit]bool
+	blocked        bool      // no new cycle until the end of the run
+	blockedUntil   time.Time // no new cycle until the end of the day
+	flattened      bool      // no order increasing a position until the end of the run
+	flattenedUntil time.Time // no order increasing a position until the end of the day
+	stopped        bool
+
+	EventCallback func(common.SimulatorEvent)
+}
+
+func NewManager(rules ...Rule) *Manager {
+	return &Manager{
+		rules:     rules,
+		triggered: make(map[Limit]bool),
+	}
+}
+
+// PUBLIC METHODS
+// Wrap returns the APIs given to the workers, checking the orders against the
+// rules: one API per symbol of the account, the equity of the account being
+// the sum of their equity. To be called at the start of every run, before
+// Start.
+func (m *Manager) Wrap(apis ...*engine.ExchangeAPI) []*engine.ExchangeAPI {
+	m.apis = apis
+	wrapped := make([]*engine.ExchangeAPI, len(apis))
+	for i, api := range apis {
+		api := api
+		w := *api
+		w.PlaceOrder = func(order engine.Order) { m.placeOrder(api, order) }
+		wrapped[i] = &w
+	}
+	return wrapped
+}
+
+// WrapOne wraps the API of an account with a single symbol
+func (m *Manager) WrapOne(api *engine.ExchangeAPI) *engine.ExchangeAPI {
+	return m.Wrap(api)[0]
+}
+
+// SetFlatteners sets the workers of the run, flattened by the actions before
+// the orders left on the APIs. To be called at the start of every run, before
+// Start.
+func (m *Manager) SetFlatteners(flatteners ...Flattener) {
+	m.flatteners = flatteners
+}
+
+// Start resets the state of the manager at the start of a run
+func (m *Manager) Start() {
+	equity := m.equity()
+	m.peakEquity = equity
+	m.dayStart = m.now().UTC().Truncate(24 * time.Hour)
+	m.dayStartEquity = equity
+	m.triggered = make(map[Limit]bool)
+	m.blocked, m.blockedUntil = false, time.Time{}
+	m.flattened, m.flattenedUntil = false, time.Time{}
+	m.stopped = false
+}
+
+// Check evaluates the account limits, to be called at every tick
+func (m *Manager) Check() {
+	if m.stopped {
+		return
+	}
+	now := m.now()
+	equity := m.equity()
+	if day := now.UTC().Truncate(24 * time.Hour); day.After(m.dayStart) {
+		m.dayStart = day
+		m.dayStartEquity = equity
+		m.triggered[LimitDailyLoss] = false
+	}
+	m.peakEquity = math.Max(m.peakEquity, equity)
+
+	for _, rule := range m.rules {
+		var value float64
+		switch rule.Limit {
+		case LimitDrawdown:
+			value = lossPerc(m.peakEquity, equity)
+		case LimitDailyLoss:
+			value = lossPerc(m.dayStartEquity, equity)
+		case LimitPositionNotional:
+			value = m.maxNotional()
+		case LimitGridDepth:
+			value = m.maxGridReached()
+			if value >= rule.Threshold {
+				m.trigger(rule, fmt.Sprintf("%s %.0f reached", rule.Limit, value))
+			}
+			continue
+		default:
+			continue // checked on the orders
+		}
+		if value > rule.Threshold {
+			m.trigger(rule, fmt.Sprintf("%s %.2f beyond %.2f", rule.Limit, value, rule.Threshold))
+		}
+	}
+}
+
+func (m *Manager) Rules() []Rule { return m.rules }
+
+func (m *Manager) Stopped() bool { return m.stopped }
+
+// Done returns true once the run is stopped and the positions closed
+func (m *Manager) Done() bool {
+	if !m.stopped {
+		return false
+	}
+	for _, api := range m.apis {
+		if api.Position(engine.PositionSideLong).Size != 0 || api.Position(engine.PositionSideShort).Size != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// EntryFilter returns the entry filter blocking new cycles after an action
+func (m *Manager) EntryFilter() filter.Filter {
+	return &entryFilter{manager: m}
+}
+
+// PRIVATE METHODS
+// placeOrder places the order on the API unless it increases a position while
+// the positions are flattened or it breaks a limit of the orders. Rejected
+// orders are dropped without triggering the action of the rule.
+func (m *Manager) placeOrder(api *engine.ExchangeAPI, order engine.Order) {
+	if !m.increases(order) {
+		api.PlaceOrder(order)
+		return
+	}
+	if m.stopped || m.flattened || m.now().Before(m.flattenedUntil) {
+		log.Debugf("Risk: order %s rejected, positions flattened", order.String())
+		return
+	}
+
+	price := order.Price
+	if order.Type == engine.OrderTypeMarket {
+		price = api.MarkPrice()
+	}
+	for _, rule := range m.rules {
+		var value float64
+		switch rule.Limit {
+		case LimitPositionNotional:
+			value = (api.Position(order.PositionSide).Size + order.Amount) * price
+		case LimitGridDepth:
+			if float64(order.GridNumber) > rule.Threshold {
+				log.Debugf("Risk: order %s rejected, grid beyond %.0f", order.String(), rule.Threshold)
+				return
+			}
+			continue
+		case LimitOpenOrders:
+			value = float64(m.openOrders() + 1)
+		default:
+			continue
+		}
+		if value > rule.Threshold {
+			log.Debugf("Risk: order %s rejected, %s %.2f beyond %.2f", order.String(), rule.Limit, value, rule.Threshold)
+			return
+		}
+	}
+	api.PlaceOrder(order)
+}
+
+// trigger applies the action of the rule, once until the rule is reset
+func (m *Manager) trigger(rule Rule, reason string) {
+	if m.triggered[rule.Limit] {
+		return
+	}
+	m.triggered[rule.Limit] = true
+
+	m.logEvent(fmt.Sprintf("%s: %s", rule.Action, reason))
+
+	// the daily loss lasts until the end of the day, the other limits until
+	// the end of the run
+	flatten := rule.Action == ActionFlatten || rule.Action == ActionStop
+	if rule.Limit == LimitDailyLoss {
+		until := m.now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+		if until.After(m.blockedUntil) {
+			m.blockedUntil = until
+		}
+		if flatten && until.After(m.flattenedUntil) {
+			m.flattenedUntil = until
+		}
+	} else {
+		m.blocked = true
+		m.flattened = m.flattened || flatten
+	}
+	if flatten {
+		m.flatten()
+	}
+	if rule.Action == ActionStop {
+		m.stopped = true
+	}
+}
+
+// flatten closes the positions through the workers, then cancels and closes at
+// market what is left on the APIs
+func (m *Manager) flatten() {
+	for _, f := range m.flatteners {
+		f.Flatten()
+	}
+	for _, api := range m.apis {
+		for _, positionSide := range []engine.PositionSideType{engine.PositionSideLong, engine.PositionSideShort} {
+			for _, order := range api.OpenOrders(positionSide) {
+				api.CancelOrder(order)
+			}
+			position := api.Position(positionSide)
+			if position.Size == 0 {
+				continue
+			}
+			side := engine.SideSell
+			if positionSide == engine.PositionSideShort {
+				side = engine.SideBuy
+			}
+			api.PlaceOrder(*engine.NewOrderMarket(position.Symbol, side, positionSide, position.Size))
+		}
+	}
+}
+
+// increases returns true if the order opens or increases a position
+func (m *Manager) increases(order engine.Order) bool {
+	return (order.PositionSide == engine.PositionSideLong && order.Side == engine.SideBuy) ||
+		(order.PositionSide == engine.PositionSideShort && order.Side == engine.SideSell)
+}
+
+func (m *Manager) now() time.Time {
+	return m.apis[0].CurrentTime()
+}
+
+// equity returns the equity of the account, summed over its symbols
+func (m *Manager) equity() float64 {
+	equity := 0.0
+	for _, api := range m.apis {
+		equity += api.Equity()
+	}
+	return equity
+}
+
+// maxNotional returns the largest notional of a position side of the account
+func (m *Manager) maxNotional() float64 {
+	notional := 0.0
+	for _, api := range m.apis {
+		for _, positionSide := range []engine.PositionSideType{engine.PositionSideLong, engine.PositionSideShort} {
+			notional = math.Max(notional, api.Position(positionSide).Size*api.MarkPrice())
+		}
+	}
+	return notional
+}
+
+func (m *Manager) maxGridReached() float64 {
+	grid := 0.0
+	for _, api := range m.apis {
+		grid = math.Max(grid, float64(api.GridReached(engine.PositionSideLong)))
+		grid = math.Max(grid, float64(api.GridReached(engine.PositionSideShort)))
+	}
+	return grid
+}
+
+func (m *Manager) openOrders() int {
+	count := 0
+	for _, api := range m.apis {
+		count += len(api.OpenOrders(engine.PositionSideLong)) + len(api.OpenOrders(engine.PositionSideShort))
+	}
+	return count
+}
+
+func (m *Manager) logEvent(message string) {
+	now := m.now()
+	event := common.SimulatorEvent{Date: now.String(), Timestamp: now.Unix(), Type: common.EventRisk, Message: message}
+	log.Debugf("Risk: event %s", event.String())
+	if m.EventCallback != nil {
+		m.EventCallback(event)
+	}
+}
+
+// entryFilter blocks new cycles while an action of the manager lasts
+type entryFilter struct {
+	manager *Manager
+}
+
+func (f *entryFilter) Start(ctx strategy.Context) {}
+
+func (f *entryFilter) Allow(ctx strategy.Context) (bool, string) {
+	if f.manager.stopped || f.manager.blocked || ctx.Time().Before(f.manager.blockedUntil) {
+		return false, "blocked by the risk manager"
+	}
+	return true, ""
+}
+
+func (f *entryFilter) CycleStarted(ctx strategy.Context) {}
+
+func (f *entryFilter) OnFill(ctx strategy.Context, fill engine.Fill) {}
+
+// PRIVATE FUNCTIONS
+// lossPerc returns the loss from reference to equity in % of reference, 0 if
+// the reference is not positive
+func lossPerc(reference float64, equity float64) float64 {
+	if reference <= 0 {
+		return 0
+	}
+	return (reference - equity) / reference * 100
+}
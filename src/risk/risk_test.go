@@ -0,0 +1,236 @@
+package risk
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/gobot-simulator/src/common"
+	"example.com/gobot-simulator/src/engine"
+	"example.com/gobot-simulator/src/strategy"
+)
+
+// testAccount is a single symbol account executing the market orders at once
+// and keeping the other orders open
+type testAccount struct {
+	now       time.Time
+	price     float64
+	balance   float64
+	positions map[engine.PositionSideType]*engine.Position
+	orders    []engine.Order
+	placed    []engine.Order
+}
+
+func newTestAccount() *testAccount {
+	return &testAccount{
+		now:     time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		price:   100,
+		balance: 1000,
+		positions: map[engine.PositionSideType]*engine.Position{
+			engine.PositionSideLong:  {Symbol: "TEST", PositionSide: engine.PositionSideLong},
+			engine.PositionSideShort: {Symbol: "TEST", PositionSide: engine.PositionSideShort},
+		},
+	}
+}
+
+func (a *testAccount) api() *engine.ExchangeAPI {
+	return &engine.ExchangeAPI{
+		PlaceOrder: func(order engine.Order) {
+			a.placed = append(a.placed, order)
+			if order.Type != engine.OrderTypeMarket {
+				a.orders = append(a.orders, order)
+				return
+			}
+			position := a.positions[order.PositionSide]
+			position.MarkPrice = a.price
+			a.balance += position.Update(order)
+		},
+		CancelOrder: func(order engine.Order) bool {
+			for i, o := range a.orders {
+				if o.ID == order.ID {
+					a.orders = append(a.orders[:i], a.orders[i+1:]...)
+					return true
+				}
+			}
+			return false
+		},
+		OpenOrders: func(positionSide engine.PositionSideType) []engine.Order {
+			orders := make([]engine.Order, 0)
+			for _, o := range a.orders {
+				if o.PositionSide == positionSide {
+					orders = append(orders, o)
+				}
+			}
+			return orders
+		},
+		MarkPrice:   func() float64 { return a.price },
+		Balance:     func() float64 { return a.balance },
+		GridReached: func(engine.PositionSideType) int64 { return 0 },
+		Position: func(positionSide engine.PositionSideType) engine.Position {
+			return *a.positions[positionSide]
+		},
+		CurrentTime: func() time.Time { return a.now },
+	}
+}
+
+// open sets the size of the position side, entered at the current price
+func (a *testAccount) open(positionSide engine.PositionSideType, size float64) {
+	position := a.positions[positionSide]
+	position.EntryPrice = a.price
+	position.Size = size
+}
+
+type testFlattener struct {
+	calls int
+}
+
+func (f *testFlattener) Flatten() { f.calls++ }
+
+// testContext implements the part of strategy.Context the entry filter uses
+type testContext struct {
+	strategy.Context
+	account *testAccount
+}
+
+func (c testContext) Time() time.Time { return c.account.now }
+
+func limitBuy(amount float64, grid int64) engine.Order {
+	order := engine.NewOrderLimit("TEST", engine.SideBuy, engine.PositionSideLong, amount, 100)
+	order.GridNumber = grid
+	return *order
+}
+
+func TestRejectedOrders(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     Rule
+		position float64
+		open     int
+		order    engine.Order
+		placed   bool
+	}{
+		{"within the position notional", Rule{LimitPositionNotional, 1000, ActionBlockEntries}, 5, 0, limitBuy(4, 1), true},
+		{"beyond the position notional", Rule{LimitPositionNotional, 1000, ActionBlockEntries}, 5, 0, limitBuy(6, 1), false},
+		{"reducing orders are never rejected", Rule{LimitPositionNotional, 100, ActionBlockEntries}, 5,
+			0, *engine.NewOrderLimit("TEST", engine.SideSell, engine.PositionSideLong, 5, 110), true},
+		{"at the grid depth", Rule{LimitGridDepth, 3, ActionBlockEntries}, 0, 0, limitBuy(1, 3), true},
+		{"beyond the grid depth", Rule{LimitGridDepth, 3, ActionBlockEntries}, 0, 0, limitBuy(1, 4), false},
+		{"within the open orders", Rule{LimitOpenOrders, 2, ActionBlockEntries}, 0, 1, limitBuy(1, 1), true},
+		{"beyond the open orders", Rule{LimitOpenOrders, 2, ActionBlockEntries}, 0, 2, limitBuy(1, 1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account := newTestAccount()
+			account.open(engine.PositionSideLong, tt.position)
+			for i := 0; i < tt.open; i++ {
+				account.orders = append(account.orders, limitBuy(1, 1))
+			}
+			m := NewManager(tt.rule)
+			api := m.WrapOne(account.api())
+			m.Start()
+
+			api.PlaceOrder(tt.order)
+			if placed := len(account.placed) == 1; placed != tt.placed {
+				t.Errorf("placed %v, want %v", placed, tt.placed)
+			}
+			m.Check()
+			if !tt.placed && m.blocked {
+				t.Errorf("a rejected order triggered the action")
+			}
+		})
+	}
+}
+
+func TestDrawdownFlatten(t *testing.T) {
+	account := newTestAccount()
+	account.open(engine.PositionSideLong, 10)
+	account.orders = append(account.orders, *engine.NewOrderLimit("TEST", engine.SideSell, engine.PositionSideLong, 10, 110))
+	flattener := &testFlattener{}
+	events := make([]common.SimulatorEvent, 0)
+	m := NewManager(Rule{LimitDrawdown, 10, ActionFlatten})
+	m.EventCallback = func(event common.SimulatorEvent) { events = append(events, event) }
+	api := m.WrapOne(account.api())
+	m.SetFlatteners(flattener)
+	m.Start()
+
+	account.price = 95
+	m.Check()
+	if flattener.calls != 0 || len(events) != 0 {
+		t.Fatalf("flattened at a 5%% drawdown")
+	}
+
+	account.price = 85
+	m.Check()
+	if flattener.calls != 1 {
+		t.Errorf("flattener called %d times, want 1", flattener.calls)
+	}
+	if size := account.positions[engine.PositionSideLong].Size; size != 0 {
+		t.Errorf("long position size %g after the flatten, want 0", size)
+	}
+	if len(account.orders) != 0 {
+		t.Errorf("%d orders left open after the flatten", len(account.orders))
+	}
+	if len(events) != 1 || events[0].Type != common.EventRisk || !strings.HasPrefix(events[0].Message, string(ActionFlatten)) {
+		t.Errorf("got events %v, want one flatten event", events)
+	}
+
+	m.Check()
+	if flattener.calls != 1 {
+		t.Errorf("flattened again while the limit is still beyond the threshold")
+	}
+	placed := len(account.placed)
+	api.PlaceOrder(limitBuy(1, 1))
+	if len(account.placed) != placed {
+		t.Errorf("order increasing a position placed after the flatten")
+	}
+	if ok, _ := m.EntryFilter().Allow(testContext{account: account}); ok {
+		t.Errorf("new cycle allowed after the flatten")
+	}
+	if m.Stopped() {
+		t.Errorf("flatten stopped the run")
+	}
+}
+
+func TestStop(t *testing.T) {
+	account := newTestAccount()
+	account.open(engine.PositionSideShort, 10)
+	m := NewManager(Rule{LimitPositionNotional, 500, ActionStop})
+	m.WrapOne(account.api())
+	m.Start()
+
+	m.Check()
+	if !m.Stopped() || !m.Done() {
+		t.Errorf("got stopped %v, done %v, want the run stopped with the positions closed", m.Stopped(), m.Done())
+	}
+}
+
+func TestDailyLoss(t *testing.T) {
+	account := newTestAccount()
+	account.open(engine.PositionSideLong, 10)
+	m := NewManager(Rule{LimitDailyLoss, 5, ActionBlockEntries})
+	m.WrapOne(account.api())
+	m.Start()
+	filter := m.EntryFilter()
+	ctx := testContext{account: account}
+
+	account.price = 90
+	m.Check()
+	if ok, _ := filter.Allow(ctx); ok {
+		t.Fatalf("new cycle allowed after the daily loss")
+	}
+	if size := account.positions[engine.PositionSideLong].Size; size != 10 {
+		t.Errorf("blocking the entries changed the position size to %g", size)
+	}
+
+	account.now = account.now.Add(11 * time.Hour)
+	m.Check()
+	if ok, _ := filter.Allow(ctx); ok {
+		t.Errorf("new cycle allowed the same day")
+	}
+
+	account.now = account.now.Add(time.Hour)
+	m.Check()
+	if ok, reason := filter.Allow(ctx); !ok {
+		t.Errorf("new cycle blocked the next day: %s", reason)
+	}
+}
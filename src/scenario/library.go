@@ -0,0 +1,157 @@
+package scenario
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"example.com/gobot-simulator/src/common"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Scenario is a named window of a dataset, e.g. a crash or a week-long range.
+// The dataset is a processed file or a folder of tick data.
+type Scenario struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	Dataset     string    `json:"dataset"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+}
+
+func (s Scenario) Validate() error {
+	if s.Name == "" || s.Dataset == "" {
+		return fmt.Errorf("scenario needs a name and a dataset")
+	}
+	if !s.Start.Before(s.End) {
+		return fmt.Errorf("scenario %s ends before it starts", s.Name)
+	}
+	return nil
+}
+
+func (s Scenario) HasTag(tag string) bool {
+	for _, t := range s.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Scenario) String() string {
+	return fmt.Sprintf("%s %v: %s %s - %s", s.Name, s.Tags, s.Dataset, s.Start.UTC().Format(time.RFC3339), s.End.UTC().Format(time.RFC3339))
+}
+
+// Library keeps the scenarios in a JSON file, sorted by name. Datasets are
+// loaded once and shared by their scenarios.
+type Library struct {
+	path      string
+	scenarios []Scenario
+	datasets  map[string]*common.SymbolData
+}
+
+// OpenLibrary loads the scenarios of the file, none if it does not exist
+func OpenLibrary(path string) (*Library, error) {
+	l := &Library{path: path, datasets: make(map[string]*common.SymbolData)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &l.scenarios); err != nil {
+		return nil, fmt.Errorf("could not decode scenario library %s: %w", path, err)
+	}
+	return l, nil
+}
+
+// PUBLIC METHODS
+// Add adds the scenario, replacing the one with the same name
+func (l *Library) Add(scenario Scenario) error {
+	if err := scenario.Validate(); err != nil {
+		return err
+	}
+	l.Remove(scenario.Name)
+	l.scenarios = append(l.scenarios, scenario)
+	sort.SliceStable(l.scenarios, func(i, j int) bool { return l.scenarios[i].Name < l.scenarios[j].Name })
+	return nil
+}
+
+// Remove removes the scenario, false if there is none with the name
+func (l *Library) Remove(name string) bool {
+	for i, s := range l.scenarios {
+		if s.Name == name {
+			l.scenarios = append(l.scenarios[:i], l.scenarios[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Library) Get(name string) (Scenario, bool) {
+	for _, s := range l.scenarios {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Scenario{}, false
+}
+
+// Select returns the scenarios having any of the tags, all of them without tags
+func (l *Library) Select(tags ...string) []Scenario {
+	selected := make([]Scenario, 0, len(l.scenarios))
+	for _, s := range l.scenarios {
+		match := len(tags) == 0
+		for _, tag := range tags {
+			match = match || s.HasTag(tag)
+		}
+		if match {
+			selected = append(selected, s)
+		}
+	}
+	return selected
+}
+
+// Save writes the scenarios to the library file
+func (l *Library) Save() error {
+	data, err := json.MarshalIndent(l.scenarios, "", "  ")
+	if err != nil {
+		return err
+	}
+	// write and rename, not to lose the library if interrupted
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.path)
+}
+
+// Data returns the window of the dataset of the scenario
+func (l *Library) Data(scenario Scenario) (*common.SymbolData, error) {
+	dataset, ok := l.datasets[scenario.Dataset]
+	if !ok {
+		info, err := os.Stat(scenario.Dataset)
+		if err != nil {
+			return nil, err
+		}
+		log.Infof("Loading dataset %s", scenario.Dataset)
+		if info.IsDir() {
+			dataset = common.NewSymbolDataFromTickDataFolder(scenario.Dataset)
+		} else {
+			dataset = common.NewSymbolDataFromProcessedFile(scenario.Dataset)
+		}
+		l.datasets[scenario.Dataset] = dataset
+	}
+	data := dataset.Slice(scenario.Start, scenario.End)
+	if len(data.Data) < 2 {
+		return nil, fmt.Errorf("no data for scenario %s in %s", scenario.Name, scenario.Dataset)
+	}
+	return data, nil
+}
+
+func (l *Library) Path() string { return l.path }
@@ -0,0 +1,158 @@
+package scenario
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var t0 = time.Unix(1620000000, 0).UTC()
+
+func hour(i int) time.Time { return t0.Add(time.Duration(i) * time.Hour) }
+
+func testScenario(name string, tags ...string) Scenario {
+	return Scenario{Name: name, Tags: tags, Dataset: "data.csv", Start: hour(0), End: hour(1)}
+}
+
+func names(scenarios []Scenario) []string {
+	n := make([]string, len(scenarios))
+	for i, s := range scenarios {
+		n[i] = s.Name
+	}
+	return n
+}
+
+// writeProcessedFile writes a price per minute over hours, in the format of
+// the processed files
+func writeProcessedFile(t *testing.T, hours int) string {
+	var b strings.Builder
+	b.WriteString("i,Timestamp,Price,Volume\n")
+	for i := 0; i < hours*60; i++ {
+		fmt.Fprintf(&b, "%d,%d,%f,%f\n", i, t0.Add(time.Duration(i)*time.Minute).Unix(), 100+float64(i), 0.0)
+	}
+	path := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestScenarioValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(s *Scenario)
+		valid  bool
+	}{
+		{"valid", func(s *Scenario) {}, true},
+		{"no name", func(s *Scenario) { s.Name = "" }, false},
+		{"no dataset", func(s *Scenario) { s.Dataset = "" }, false},
+		{"empty window", func(s *Scenario) { s.End = s.Start }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := testScenario("crash")
+			tt.change(&s)
+			if err := s.Validate(); (err == nil) != tt.valid {
+				t.Errorf("got error %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestLibrary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenarios.json")
+	l, err := OpenLibrary(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []Scenario{testScenario("range", "sideways"), testScenario("crash", "crash", "2021"), testScenario("rally", "2021")} {
+		if err := l.Add(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Add(testScenario("")); err == nil {
+		t.Errorf("invalid scenario added")
+	}
+	replaced := testScenario("range", "sideways", "2022")
+	if err := l.Add(replaced); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		tags []string
+		want []string
+	}{
+		{"all without tags", nil, []string{"crash", "rally", "range"}},
+		{"one tag", []string{"2021"}, []string{"crash", "rally"}},
+		{"any of the tags", []string{"crash", "2022"}, []string{"crash", "range"}},
+		{"unknown tag", []string{"bubble"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := names(l.Select(tt.tags...)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got, ok := l.Get("range"); !ok || !reflect.DeepEqual(got, replaced) {
+		t.Errorf("got %v, want the replaced scenario", got)
+	}
+	if !l.Remove("rally") || l.Remove("rally") {
+		t.Errorf("rally removed not exactly once")
+	}
+	if err := l.Save(); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := OpenLibrary(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reopened.Select(), l.Select()) {
+		t.Errorf("got %v after saving, want %v", reopened.Select(), l.Select())
+	}
+}
+
+func TestLibraryData(t *testing.T) {
+	dataset := writeProcessedFile(t, 3)
+	l, err := OpenLibrary(filepath.Join(t.TempDir(), "scenarios.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		scenario Scenario
+		first    float64 // price, 0 for an error
+		count    int
+	}{
+		{"first hour", Scenario{Name: "first", Dataset: dataset, Start: hour(0), End: hour(1)}, 100, 60},
+		{"window in the middle", Scenario{Name: "middle", Dataset: dataset, Start: hour(1), End: hour(2)}, 160, 60},
+		{"after the data", Scenario{Name: "after", Dataset: dataset, Start: hour(5), End: hour(6)}, 0, 0},
+		{"missing dataset", Scenario{Name: "missing", Dataset: dataset + ".missing", Start: hour(0), End: hour(1)}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := l.Data(tt.scenario)
+			if tt.first == 0 {
+				if err == nil {
+					t.Errorf("got %d prices, want an error", len(data.Data))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(data.Data) != tt.count || data.Data[0].Price != tt.first {
+				t.Errorf("got %d prices from %g, want %d from %g", len(data.Data), data.Data[0].Price, tt.count, tt.first)
+			}
+		})
+	}
+	if len(l.datasets) != 1 {
+		t.Errorf("got %d datasets loaded, want the shared one", len(l.datasets))
+	}
+}
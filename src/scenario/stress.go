@@ -0,0 +1,93 @@
+package scenario
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"example.com/gobot-simulator/src/common"
+	"example.com/gobot-simulator/src/store"
+)
+
+// Check is a risk threshold applied to the metrics of a run
+type Check struct {
+	Condition store.Condition `json:"condition"`
+	Value     float64         `json:"value"`
+	Passed    bool            `json:"passed"`
+}
+
+// StressResult is the run of a strategy on a scenario. A run that could not be
+// done, e.g. the grid does not fit the start price, fails.
+type StressResult struct {
+	Scenario Scenario       `json:"scenario"`
+	Metrics  common.Metrics `json:"metrics"`
+	Checks   []Check        `json:"checks"`
+	Passed   bool           `json:"passed"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// ValidateThresholds checks that the thresholds apply to metrics
+func ValidateThresholds(thresholds []store.Condition) error {
+	for _, t := range thresholds {
+		if _, ok := (common.Metrics{}).Value(t.Field); !ok {
+			return fmt.Errorf("threshold %s is not on a metric, expected one of %v", t.String(), common.MetricNames())
+		}
+	}
+	return nil
+}
+
+// NewStressResult checks the metrics of the run against the thresholds
+func NewStressResult(scenario Scenario, metrics common.Metrics, thresholds []store.Condition) StressResult {
+	result := StressResult{Scenario: scenario, Metrics: metrics, Passed: true}
+	for _, t := range thresholds {
+		value, _ := metrics.Value(t.Field)
+		check := Check{Condition: t, Value: value, Passed: t.Match(value)}
+		result.Checks = append(result.Checks, check)
+		result.Passed = result.Passed && check.Passed
+	}
+	return result
+}
+
+// NewFailedStressResult is the result of a scenario that could not be run
+func NewFailedStressResult(scenario Scenario, err error) StressResult {
+	return StressResult{Scenario: scenario, Error: err.Error()}
+}
+
+// WriteMatrix writes a row per scenario with the value of every threshold, the
+// failed ones being marked, and whether the scenario passed
+func WriteMatrix(out io.Writer, results []StressResult, thresholds []store.Condition) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprint(w, "Scenario\tReturn\t")
+	for _, t := range thresholds {
+		fmt.Fprintf(w, "%s\t", t.String())
+	}
+	fmt.Fprintln(w, "Result\t")
+	passed := 0
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t", r.Scenario.Name)
+		if r.Error != "" {
+			fmt.Fprintf(w, "-\t")
+			for range thresholds {
+				fmt.Fprint(w, "-\t")
+			}
+			fmt.Fprintf(w, "FAIL (%s)\t\n", r.Error)
+			continue
+		}
+		fmt.Fprintf(w, "%.2f%%\t", r.Metrics.ReturnPerc)
+		for _, c := range r.Checks {
+			mark := "ok"
+			if !c.Passed {
+				mark = "FAIL"
+			}
+			fmt.Fprintf(w, "%.2f %s\t", c.Value, mark)
+		}
+		if r.Passed {
+			passed++
+			fmt.Fprintln(w, "PASS\t")
+		} else {
+			fmt.Fprintln(w, "FAIL\t")
+		}
+	}
+	w.Flush()
+	fmt.Fprintf(out, "%d/%d scenarios passed\n", passed, len(results))
+}
@@ -0,0 +1,93 @@
+package scenario
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"example.com/gobot-simulator/src/common"
+	"example.com/gobot-simulator/src/store"
+)
+
+func conditions(t *testing.T, s ...string) []store.Condition {
+	parsed := make([]store.Condition, len(s))
+	for i, c := range s {
+		condition, err := store.ParseCondition(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed[i] = condition
+	}
+	return parsed
+}
+
+func TestValidateThresholds(t *testing.T) {
+	if err := ValidateThresholds(conditions(t, "maxDrawdownPerc<30", "returnPerc>0")); err != nil {
+		t.Errorf("got %v on metrics", err)
+	}
+	if err := ValidateThresholds(conditions(t, "GO>=5")); err == nil {
+		t.Errorf("got no error on a parameter")
+	}
+}
+
+func TestNewStressResult(t *testing.T) {
+	thresholds := conditions(t, "maxDrawdownPerc<30", "returnPerc>0")
+	tests := []struct {
+		name    string
+		metrics common.Metrics
+		checks  []bool
+	}{
+		{"both passed", common.Metrics{MaxDrawdownPerc: 10, ReturnPerc: 5}, []bool{true, true}},
+		{"drawdown failed", common.Metrics{MaxDrawdownPerc: 40, ReturnPerc: 5}, []bool{false, true}},
+		{"both failed", common.Metrics{MaxDrawdownPerc: 40, ReturnPerc: -5}, []bool{false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewStressResult(testScenario("crash"), tt.metrics, thresholds)
+			passed := true
+			for i, c := range result.Checks {
+				if c.Passed != tt.checks[i] {
+					t.Errorf("check %s passed %v, want %v", c.Condition.String(), c.Passed, tt.checks[i])
+				}
+				passed = passed && tt.checks[i]
+			}
+			if result.Passed != passed {
+				t.Errorf("got passed %v, want %v", result.Passed, passed)
+			}
+		})
+	}
+	if NewFailedStressResult(testScenario("crash"), errors.New("grid does not fit")).Passed {
+		t.Errorf("a scenario that could not be run passed")
+	}
+}
+
+func TestWriteMatrix(t *testing.T) {
+	thresholds := conditions(t, "maxDrawdownPerc<30")
+	results := []StressResult{
+		NewStressResult(testScenario("crash"), common.Metrics{MaxDrawdownPerc: 40}, thresholds),
+		NewStressResult(testScenario("range"), common.Metrics{MaxDrawdownPerc: 10, ReturnPerc: 2}, thresholds),
+		NewFailedStressResult(testScenario("rally"), errors.New("grid does not fit")),
+	}
+	var out strings.Builder
+	WriteMatrix(&out, results, thresholds)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("got %d lines, want a header, 3 scenarios and the total:\n%s", len(lines), out.String())
+	}
+	tests := []struct {
+		line int
+		want []string
+	}{
+		{1, []string{"crash", "40.00 FAIL", "FAIL"}},
+		{2, []string{"range", "2.00%", "10.00 ok", "PASS"}},
+		{3, []string{"rally", "FAIL (grid does not fit)"}},
+		{4, []string{"1/3 scenarios passed"}},
+	}
+	for _, tt := range tests {
+		for _, want := range tt.want {
+			if !strings.Contains(lines[tt.line], want) {
+				t.Errorf("line %q does not contain %q", lines[tt.line], want)
+			}
+		}
+	}
+}
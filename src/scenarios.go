@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"example.com/gobot-simulator/src/scenario"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// scenarios manages the scenario library, e.g.
+// scenario add -name may-2021-crash -dataset ../datasets/DOGE_1s.csv -from 2021-05-18 -to 2021-05-21 -tags crash,2021
+// scenario list -tags crash
+// scenario remove -name may-2021-crash
+func scenarios(args []string) {
+	if len(args) == 0 {
+		log.Panic("Expected scenario add, list or remove")
+	}
+	flags := flag.NewFlagSet("scenario "+args[0], flag.ExitOnError)
+	libraryPath := flags.String("library", "../scenarios.json", "scenario library")
+	name := flags.String("name", "", "name of the scenario")
+	description := flags.String("description", "", "description of the scenario")
+	dataset := flags.String("dataset", "", "processed file or tick data folder")
+	from := flags.String("from", "", "start of the window, RFC3339 or date")
+	to := flags.String("to", "", "end of the window, excluded, RFC3339 or date")
+	tags := flags.String("tags", "", "comma separated tags, any of them to list")
+	flags.Parse(args[1:])
+
+	library, err := scenario.OpenLibrary(*libraryPath)
+	if err != nil {
+		log.Panic(err)
+	}
+	switch args[0] {
+	case "add":
+		sc := scenario.Scenario{Name: *name, Description: *description, Tags: splitList(*tags), Dataset: *dataset, Start: parseTime(*from), End: parseTime(*to)}
+		if err := library.Add(sc); err != nil {
+			log.Panic(err)
+		}
+		fmt.Println("Added " + sc.String())
+	case "remove":
+		if !library.Remove(*name) {
+			log.Panicf("No scenario %s in %s", *name, library.Path())
+		}
+		fmt.Println("Removed " + *name)
+	case "list":
+		for _, sc := range library.Select(splitList(*tags)...) {
+			fmt.Println(sc.String())
+			if sc.Description != "" {
+				fmt.Println("  " + sc.Description)
+			}
+		}
+		return
+	default:
+		log.Panicf("Unknown scenario command %s, expected add, list or remove", args[0])
+	}
+	if err := library.Save(); err != nil {
+		log.Panic(err)
+	}
+}
+
+func parseTime(s string) time.Time {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	log.Panicf("Invalid time %s, expected RFC3339 or 2006-01-02", s)
+	return time.Time{}
+}
+
+// splitList splits a comma separated list, empty for an empty string
+func splitList(s string) []string {
+	list := make([]string, 0)
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
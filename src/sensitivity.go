@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"example.com/gobot-simulator/src/common"
+	"example.com/gobot-simulator/src/engine"
+	"example.com/gobot-simulator/src/report"
+	"example.com/gobot-simulator/src/store"
+	"example.com/gobot-simulator/src/strategy"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// sensitivity reports the sensitivity of the completed runs of a results store
+// to their parameters, e.g.
+// sensitivity -store ../results/runs.jsonl -strategy Martingala -side LONG -metric sharpe -fixed
+func sensitivity(args []string) {
+	flags := flag.NewFlagSet("sensitivity", flag.ExitOnError)
+	storePath := flags.String("store", "../results/runs.jsonl", "results store")
+	dataset := flags.String("dataset", "", "only the runs of the dataset")
+	strategyType := flags.String("strategy", "", "only the runs of the strategy type")
+	positionSide := flags.String("side", "", "only the runs of the position side")
+	metric := flags.String("metric", string(common.MetricNetProfit), "metric scoring the runs")
+	fixed := flags.Bool("fixed", false, "fix the parameters not shown at the best run instead of marginalizing them")
+	top := flags.Int("top", 10, "number of runs whose robustness is reported")
+	out := flags.String("out", "../results/sensitivity", "prefix of the HTML report and of the SVG charts")
+	flags.Parse(args)
+
+	resultsStore, err := store.Open(*storePath)
+	if err != nil {
+		log.Panic(err)
+	}
+	defer resultsStore.Close()
+	records, err := resultsStore.Query(store.Query{Dataset: *dataset, StrategyType: strategy.StrategyType(*strategyType)})
+	if err != nil {
+		log.Panic(err)
+	}
+	points := make([]common.SweepPoint, 0, len(records))
+	types := make(map[string]bool)
+	for _, r := range records {
+		if *positionSide != "" && r.PositionSide != engine.PositionSideType(*positionSide) {
+			continue
+		}
+		types[string(r.StrategyType)+" "+string(r.PositionSide)] = true
+		points = append(points, r.SweepPoint())
+	}
+	if len(types) > 1 {
+		log.Warnf("Analyzing runs of %d strategies together, filter them with -strategy and -side", len(types))
+	}
+
+	result := common.NewSensitivityResult(points, common.MetricName(*metric), *fixed, *top)
+	if len(result.Parameters) == 0 {
+		log.Warnf("No parameter varied over the %d runs", len(points))
+		return
+	}
+	fmt.Printf("Sensitivity of %s over %d runs, parameters %v\n", *metric, len(points), result.Parameters)
+	for i, r := range result.Robustness {
+		fmt.Printf("  #%d %s\n", i+1, r.String())
+	}
+
+	reportFile := *out + ".html"
+	if err := report.WriteSensitivityReport(reportFile, fmt.Sprintf("Sensitivity of %s", *metric), result); err != nil {
+		log.Errorf("Error writing report to file %s: %s", reportFile, err)
+	} else {
+		log.Infof("Sensitivity report saved to %s", reportFile)
+	}
+	if err := report.WriteSensitivityCharts(*out, result); err != nil {
+		log.Errorf("Error writing charts %s_*.svg: %s", *out, err)
+	}
+}
@@ -0,0 +1,112 @@
+package simulator
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"example.com/gobot-simulator/src/common"
+	"example.com/gobot-simulator/src/report"
+	"example.com/gobot-simulator/src/strategy"
+	log "github.com/sirupsen/logrus"
+)
+
+// MonteCarloConfig sets the paths of a Monte Carlo test. Every path is drawn
+// from its own seed, Seed plus the index of the path, so that a path can be
+// reproduced alone.
+type MonteCarloConfig struct {
+	Paths        int               `json:"paths"`
+	Method       common.PathMethod `json:"method"`
+	BlockSize    int               `json:"blockSize"`    // returns per block of BLOCK_BOOTSTRAP
+	Length       time.Duration     `json:"length"`       // of the RANDOM_START windows
+	NoisePerc    float64           `json:"noisePerc"`    // standard deviation of the NOISE on every price, in percent
+	RuinLossPerc float64           `json:"ruinLossPerc"` // loss of the initial equity counted as ruin
+	Seed         int64             `json:"seed"`
+}
+
+// RunMonteCarlo runs the strategy on the data and on the paths drawn from it,
+// reporting the distribution of the return and max drawdown and the
+// probability of ruin and liquidation. A path that panics, or on which the
+// strategy does not validate (e.g. its start price does not fit the grid), is
+// counted as failed.
+func (s *Simulator) RunMonteCarlo(wrapper strategy.StrategyWrapper, config MonteCarloConfig) *common.MonteCarloResult {
+	if config.Paths <= 0 {
+		log.Panic("Monte Carlo needs a positive number of paths")
+	}
+	if config.Method != common.PathBlockBootstrap && config.Method != common.PathRandomStart && config.Method != common.PathNoise {
+		log.Panicf("Unknown path method %s", config.Method)
+	}
+	if config.RuinLossPerc <= 0 || config.RuinLossPerc > 100 {
+		log.Panicf("Ruin loss %.2f%% out of (0, 100]", config.RuinLossPerc)
+	}
+	if err := s.validate(wrapper); err != nil {
+		log.Panicf("Invalid strategy %s: %s", wrapper.String(), err)
+	}
+
+	data := s.symbolData
+	defer s.useData(data)
+
+	result := &common.MonteCarloResult{Label: wrapper.String(), Method: config.Method, RuinLossPerc: config.RuinLossPerc}
+	s.start(wrapper)
+	result.Historical = s.simulatorResult.Metrics()
+	fmt.Println("Monte Carlo historical -> " + result.Historical.String())
+
+	for i := 0; i < config.Paths; i++ {
+		path := common.MonteCarloPath{Index: i}
+		pathData, err := monteCarloPath(data, config, rand.New(rand.NewSource(config.Seed+int64(i))))
+		if err != nil {
+			log.Panic(err) // same for every path
+		}
+		s.useData(pathData)
+		if err := s.validate(wrapper); err != nil {
+			path.Error = err.Error()
+			log.Warnf("Monte Carlo path %d/%d invalid: %s", i+1, config.Paths, err)
+		} else if _, err := s.safeStart(wrapper); err != nil {
+			path.Error = err.Error()
+			log.Warnf("Monte Carlo path %d/%d failed: %s", i+1, config.Paths, err)
+		} else {
+			path.Metrics = s.simulatorResult.Metrics()
+			path.MinEquity = minEquity(s.simulatorResult.History())
+			path.Ruined = path.MinEquity <= path.Metrics.InitialEquity*(1-config.RuinLossPerc/100)
+			path.Liquidated = path.MinEquity <= 0
+			fmt.Printf("Monte Carlo path %d/%d -> %s\n", i+1, config.Paths, path.Metrics.String())
+		}
+		result.Paths = append(result.Paths, path)
+	}
+	result.Summarize()
+	fmt.Println("Monte Carlo " + result.String())
+
+	reportFile := s.resultsFolder + "montecarlo.html"
+	title := fmt.Sprintf("Monte Carlo %s, %d %s paths", wrapper.String(), config.Paths, config.Method)
+	if err := report.WriteMonteCarloReport(reportFile, title, result); err != nil {
+		log.Errorf("Error writing report to file %s: %s", reportFile, err)
+	} else {
+		log.Infof("Monte Carlo report saved to %s", reportFile)
+	}
+	return result
+}
+
+// PRIVATE FUNCTIONS
+func monteCarloPath(data *common.SymbolData, config MonteCarloConfig, random *rand.Rand) (*common.SymbolData, error) {
+	switch config.Method {
+	case common.PathBlockBootstrap:
+		return common.BlockBootstrap(data, config.BlockSize, random), nil
+	case common.PathRandomStart:
+		return common.RandomStart(data, config.Length, random)
+	default:
+		return common.InjectNoise(data, config.NoisePerc/100, random), nil
+	}
+}
+
+func minEquity(history []common.SimulatorStatus) float64 {
+	if len(history) == 0 {
+		return 0
+	}
+	min := history[0].TotalEquity()
+	for _, st := range history[1:] {
+		if equity := st.TotalEquity(); equity < min {
+			min = equity
+		}
+	}
+	return min
+}
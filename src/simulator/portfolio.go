@@ -0,0 +1,306 @@
+package simulator
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"example.com/gobot-simulator/src/common"
+	"example.com/gobot-simulator/src/engine"
+	"example.com/gobot-simulator/src/report"
+	"example.com/gobot-simulator/src/risk"
+	"example.com/gobot-simulator/src/strategy"
+	"example.com/gobot-simulator/src/worker"
+	log "github.com/sirupsen/logrus"
+)
+
+type AllocationType string
+
+const (
+	AllocationEqual             AllocationType = "EQUAL"              // same capital for every symbol
+	AllocationFixed             AllocationType = "FIXED"              // weights given by the assignments
+	AllocationInverseVolatility AllocationType = "INVERSE_VOLATILITY" // weights inversely proportional to volatility
+)
+
+// seconds of data used to estimate volatility: an inverse volatility portfolio
+// starts trading after them, so that the weights use no data of the run
+const allocationVolatilityLookback = 24 * 3600
+
+// PortfolioAssignment assigns one strategy per position side to a symbol
+type PortfolioAssignment struct {
+	Symbol     string
+	Strategies []strategy.StrategyWrapper
+	Weight     float64 // used only with AllocationFixed
+}
+
+type Portfolio struct {
+	symbolData    map[string]*common.SymbolData
+	ticks         []common.MultiSymbolTick
+	resultsFolder string
+	exchange      engine.PortfolioExchange
+	workers       map[string]map[engine.PositionSideType]*worker.Worker
+	result        common.PortfolioResult
+	riskManager   *risk.Manager
+}
+
+func NewPortfolio(symbolData map[string]*common.SymbolData, resultsFolder string) *Portfolio {
+	symbols := make([]string, 0, len(symbolData))
+	for symbol := range symbolData {
+		symbols = append(symbols, symbol)
+	}
+	portfolio := &Portfolio{
+		symbolData:    symbolData,
+		ticks:         common.AlignSymbolData(symbolData),
+		resultsFolder: resultsFolder,
+		exchange:      *engine.NewPortfolioExchange(symbols),
+		workers:       make(map[string]map[engine.PositionSideType]*worker.Worker),
+		result:        *common.NewPortfolioResult(),
+	}
+	if len(portfolio.ticks) == 0 {
+		log.Panic("Symbol data of the portfolio do not overlap")
+	}
+
+	portfolio.exchange.NotifyFillCallback = portfolio.handleFill
+	portfolio.exchange.UpdatePortfolioStatusCallback = portfolio.result.Append
+	return portfolio
+}
+
+// PUBLIC METHODS
+// SetRiskManager puts the risk manager between the workers and the exchange,
+// nil to remove it. The account limits apply to the whole portfolio.
+func (p *Portfolio) SetRiskManager(manager *risk.Manager) {
+	p.riskManager = manager
+	if manager != nil {
+		manager.EventCallback = p.result.AppendEvent
+	}
+}
+
+// Run trades the assignments on a shared balance split by the allocation. A
+// symbol with weight 0 (e.g. a constant price with inverse volatility) is
+// excluded from the portfolio.
+func (p *Portfolio) Run(assignments []PortfolioAssignment, allocation AllocationType) {
+	ticks := p.ticks
+	if allocation == AllocationInverseVolatility {
+		ticks = p.ticks[p.warmup():]
+	}
+	weights := p.weights(assignments, allocation)
+	allocations := make(map[string]float64)
+	for symbol, weight := range weights {
+		if weight > 0 {
+			allocations[symbol] = weight * initialBalance
+		} else {
+			log.Warnf("Symbol %s excluded from the portfolio: weight 0", symbol)
+		}
+	}
+
+	p.result.Reset(allocations)
+	p.exchange.Init(initialBalance, ticks[0])
+
+	// one API per symbol, whose balance is the share allocated to the symbol
+	symbols := make([]string, 0, len(allocations))
+	apis := make([]*engine.ExchangeAPI, 0, len(allocations))
+	for _, symbol := range p.exchange.Symbols() {
+		if allocations[symbol] > 0 {
+			symbols = append(symbols, symbol)
+			apis = append(apis, allocatedAPI(p.exchange.GetAPI(symbol), weights[symbol]))
+		}
+	}
+	if p.riskManager != nil {
+		apis = p.riskManager.Wrap(apis...)
+	}
+	symbolAPIs := make(map[string]*engine.ExchangeAPI)
+	for i, symbol := range symbols {
+		symbolAPIs[symbol] = apis[i]
+	}
+
+	// one worker per symbol and position side, trading on its share of the balance
+	p.workers = make(map[string]map[engine.PositionSideType]*worker.Worker)
+	labels := make([]string, 0)
+	flatteners := make([]risk.Flattener, 0)
+	for _, a := range assignments {
+		if allocations[a.Symbol] == 0 {
+			continue
+		}
+		if _, ok := p.workers[a.Symbol]; !ok {
+			p.workers[a.Symbol] = make(map[engine.PositionSideType]*worker.Worker)
+		}
+		for _, s := range a.Strategies {
+			if len(a.Strategies) > 1 && strategy.TradesBothSides(s) {
+				log.Panicf("Strategy %s %s trades both position sides and cannot share the symbol", a.Symbol, s.String())
+			}
+			if _, ok := p.workers[a.Symbol][s.GetPositionSide()]; ok {
+				log.Panicf("More than one strategy for symbol %s and position side %s", a.Symbol, s.GetPositionSide())
+			}
+			s.SetSymbol(a.Symbol)
+			if err := strategy.Validate(s); err != nil {
+				log.Panicf("Invalid strategy %s %s: %s", a.Symbol, s.String(), err)
+			}
+			if err := strategy.ValidateExposure(s, allocations[a.Symbol], ticks[0].Prices[a.Symbol], defaultLeverage); err != nil {
+				log.Panicf("Invalid strategy %s %s: %s", a.Symbol, s.String(), err)
+			}
+			w := worker.NewWorker()
+			w.SetExchangeAPI(symbolAPIs[a.Symbol])
+			if p.riskManager != nil {
+				w.SetEntryFilters(p.riskManager.EntryFilter())
+			}
+			w.SetStrategy(s)
+			w.EventCallback = p.result.AppendEvent
+			symbol := a.Symbol
+			w.IdleCallback = func(positionSide engine.PositionSideType, idle time.Duration) {
+				p.result.AddIdleTime(symbol, idle.Seconds())
+			}
+			p.workers[a.Symbol][s.GetPositionSide()] = w
+			flatteners = append(flatteners, w)
+			labels = append(labels, fmt.Sprintf("%s %s", a.Symbol, s.String()))
+		}
+	}
+	if p.riskManager != nil {
+		p.riskManager.SetFlatteners(flatteners...)
+		p.riskManager.Start()
+	}
+	for _, a := range assignments {
+		for _, s := range a.Strategies {
+			if w, ok := p.workers[a.Symbol][s.GetPositionSide()]; ok {
+				w.StartStrategy()
+			}
+		}
+	}
+
+	for _, tick := range ticks[1:] {
+		p.exchange.Next(tick)
+		if p.riskManager != nil {
+			p.riskManager.Check()
+			if p.riskManager.Done() {
+				break
+			}
+			if p.riskManager.Stopped() {
+				continue // wait for the positions to be closed
+			}
+		}
+		for _, symbol := range p.exchange.Symbols() {
+			symbolTick := common.SymbolDataItem{Time: tick.Time, Price: tick.Prices[symbol]}
+			for _, w := range p.workers[symbol] {
+				w.HandleTick(symbolTick)
+			}
+		}
+	}
+	for _, workers := range p.workers {
+		for _, w := range workers {
+			w.StopStrategy()
+		}
+	}
+
+	fmt.Println(strings.Join(labels, " + ") + " -> " + p.result.Metrics().String())
+	for _, symbol := range p.result.Symbols() {
+		fmt.Printf("  %s (allocated %.2f) -> %s\n", symbol, allocations[symbol], p.result.SymbolMetrics(symbol).String())
+	}
+	p.save(fmt.Sprintf("portfolio %s", allocation))
+}
+
+// PRIVATE METHODS
+func (p *Portfolio) handleFill(fill engine.Fill) {
+	// every worker keeps the fills of its strategy
+	for _, w := range p.workers[fill.Position.Symbol] {
+		w.HandleFill(fill)
+	}
+}
+
+// weights returns the fraction of the balance allocated to every assigned symbol
+func (p *Portfolio) weights(assignments []PortfolioAssignment, allocation AllocationType) map[string]float64 {
+	raw := make(map[string]float64)
+	for _, a := range assignments {
+		if _, ok := p.symbolData[a.Symbol]; !ok {
+			log.Panicf("No data for symbol %s", a.Symbol)
+		}
+		switch allocation {
+		case AllocationEqual:
+			raw[a.Symbol] = 1
+		case AllocationFixed:
+			raw[a.Symbol] = a.Weight
+		case AllocationInverseVolatility:
+			raw[a.Symbol] = 0
+			if vol := p.volatility(a.Symbol); vol > 0 {
+				raw[a.Symbol] = 1 / vol
+			}
+		default:
+			log.Panicf("Allocation type %s not recognized", allocation)
+		}
+	}
+
+	total := 0.0
+	for _, w := range raw {
+		total += w
+	}
+	if total <= 0 {
+		log.Panic("Portfolio weights sum to 0")
+	}
+	weights := make(map[string]float64)
+	for symbol, w := range raw {
+		weights[symbol] = w / total
+	}
+	return weights
+}
+
+// warmup returns the index of the first tick after allocationVolatilityLookback
+// seconds of data
+func (p *Portfolio) warmup() int {
+	start := p.ticks[0].Time.Unix()
+	for i, tick := range p.ticks {
+		if tick.Time.Unix()-start >= allocationVolatilityLookback {
+			if i == len(p.ticks)-1 {
+				break
+			}
+			return i
+		}
+	}
+	log.Panicf("Inverse volatility allocation needs more than %d seconds of data", allocationVolatilityLookback)
+	return 0
+}
+
+// volatility returns the standard deviation of the minute log returns of the
+// symbol over the allocationVolatilityLookback seconds before the portfolio
+// starts trading.
+func (p *Portfolio) volatility(symbol string) float64 {
+	returns := make([]float64, 0)
+	last := p.ticks[0]
+	for _, tick := range p.ticks[:p.warmup()+1] {
+		if tick.Time.Sub(last.Time).Seconds() >= 60 {
+			returns = append(returns, math.Log(tick.Prices[symbol]/last.Prices[symbol]))
+			last = tick
+		}
+	}
+	_, std := common.MeanStd(returns)
+	return std
+}
+
+func (p *Portfolio) save(name string) {
+	resultFile := p.resultsFolder + name + ".csv"
+	if err := p.result.WriteToFile(resultFile); err != nil {
+		log.Errorf("Error writing results to file %s: %s", resultFile, err)
+	} else {
+		log.Infof("Portfolio results saved to %s", resultFile)
+	}
+
+	if len(p.result.Events()) > 0 {
+		eventsFile := p.resultsFolder + name + ".events.csv"
+		if err := p.result.WriteEventsToFile(eventsFile); err != nil {
+			log.Errorf("Error writing events to file %s: %s", eventsFile, err)
+		}
+	}
+
+	reportFile := p.resultsFolder + name + ".html"
+	if err := report.WritePortfolioReport(reportFile, name, &p.result); err != nil {
+		log.Errorf("Error writing report to file %s: %s", reportFile, err)
+	} else {
+		log.Infof("Portfolio report saved to %s", reportFile)
+	}
+}
+
+// allocatedAPI returns a copy of the API whose balance is the share of the wallet
+// allocated to the symbol, so that strategies size their orders on it.
+func allocatedAPI(api *engine.ExchangeAPI, weight float64) *engine.ExchangeAPI {
+	allocated := *api
+	allocated.Balance = func() float64 { return api.Balance() * weight }
+	return &allocated
+}
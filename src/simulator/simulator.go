@@ -1,21 +1,51 @@
 package simulator
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"example.com/gobot-simulator/src/common"
 	"example.com/gobot-simulator/src/engine"
+	"example.com/gobot-simulator/src/filter"
+	"example.com/gobot-simulator/src/report"
+	"example.com/gobot-simulator/src/risk"
+	"example.com/gobot-simulator/src/store"
 	"example.com/gobot-simulator/src/strategy"
 	"example.com/gobot-simulator/src/worker"
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	initialBalance        = 1000
+	defaultLeverage       = 1
+	comparisonTopN        = 10   // runs included in the sweep comparison report
+	robustnessTopN        = 10   // runs of a sweep whose neighbourhood robustness is reported
+	summaryEquityMaxPoint = 1000 // equity points kept for every run of a sweep
+)
+
+// sweepRun is a completed run of a sweep with its parameters
+type sweepRun struct {
+	pars    strategy.StrategyParameters
+	summary common.RunSummary
+}
+
 type Simulator struct {
 	symbolData      *common.SymbolData
 	resultsFolder   string
-	worker          worker.Worker
+	workerLong      worker.Worker
+	workerShort     worker.Worker
 	exchange        engine.Exchange
 	simulatorResult common.SimulatorResult
+	benchmarks      []common.Benchmark
+	leverage        float64
+	entryFilters    map[engine.PositionSideType][]filter.Filter
+	riskManager     *risk.Manager
+	sizing          strategy.Sizing
+	resultsStore    *store.Store
+	dataset         string
 }
 
 func NewSimulator(symbolData *common.SymbolData, resultsFolder string) *Simulator {
@@ -23,72 +53,247 @@ func NewSimulator(symbolData *common.SymbolData, resultsFolder string) *Simulato
 	simulation := &Simulator{
 		symbolData:      symbolData,
 		resultsFolder:   resultsFolder,
-		worker:          *worker.NewWorker(),
+		workerLong:      *worker.NewWorker(),
+		workerShort:     *worker.NewWorker(),
 		exchange:        *exchange,
 		simulatorResult: *common.NewSimulatorResult(),
+		benchmarks:      common.NewBenchmarks(symbolData, initialBalance),
+		leverage:        defaultLeverage,
+		entryFilters:    make(map[engine.PositionSideType][]filter.Filter),
 	}
 
-	// link worker, exchange and simulation through callbacks
-	simulation.worker.SetExchangeAPI(simulation.exchange.GetAPI())
-	simulation.exchange.NotifyPositionUpdateCallback = simulation.worker.HandlePositionUpdate
+	// link workers, exchange and simulation through callbacks
+	simulation.workerLong.EventCallback = simulation.simulatorResult.AppendEvent
+	simulation.workerShort.EventCallback = simulation.simulatorResult.AppendEvent
+	simulation.workerLong.IdleCallback = simulation.addIdleTime
+	simulation.workerShort.IdleCallback = simulation.addIdleTime
+	simulation.exchange.NotifyFillCallback = simulation.handleFill
 	simulation.exchange.UpdateSimulationStatusCallback = simulation.updateResult
 
 	return simulation
 }
 
 // PUBLIC METHODS
+// SetLeverage sets the leverage used to check the margin required by the grids
+func (s *Simulator) SetLeverage(leverage float64) {
+	s.leverage = leverage
+}
+
+// SetEntryFilters sets the conditions checked before every new cycle of the
+// strategy of the position side.
+func (s *Simulator) SetEntryFilters(positionSide engine.PositionSideType, filters ...filter.Filter) {
+	s.entryFilters[positionSide] = filters
+}
+
+// SetSizing sets how the strategies size their orders
+func (s *Simulator) SetSizing(sizing strategy.Sizing) {
+	if err := sizing.Validate(); err != nil {
+		log.Panic(err)
+	}
+	s.sizing = sizing
+}
+
+// SetResultsStore records every run of the sweeps in the store under the
+// dataset name, the runs already completed being skipped.
+func (s *Simulator) SetResultsStore(resultsStore *store.Store, dataset string) {
+	s.resultsStore = resultsStore
+	s.dataset = dataset
+}
+
+// DatasetID identifies the data of the runs: the dataset name given to
+// SetResultsStore and the time range
+func (s *Simulator) DatasetID() string {
+	start := s.symbolData.Data[0].Time.UTC().Format(time.RFC3339)
+	end := s.symbolData.Data[len(s.symbolData.Data)-1].Time.UTC().Format(time.RFC3339)
+	return strings.TrimSpace(fmt.Sprintf("%s %s/%s", s.dataset, start, end))
+}
+
+func (s *Simulator) Leverage() float64 { return s.leverage }
+
+func (s *Simulator) Sizing() strategy.Sizing { return s.sizing }
+
+// SetRiskManager puts the risk manager between the workers and the exchange,
+// nil to remove it.
+func (s *Simulator) SetRiskManager(manager *risk.Manager) {
+	s.riskManager = manager
+	if manager != nil {
+		manager.EventCallback = s.simulatorResult.AppendEvent
+	}
+}
+
+// AnalyzeGrid describes the grid of the strategy at the start of the simulation
+func (s *Simulator) AnalyzeGrid(wrapper strategy.StrategyWrapper) (*strategy.GridAnalysis, error) {
+	price := s.symbolData.Data[0].Price
+	return strategy.AnalyzeGrid(wrapper, s.sizing.Capital(initialBalance, initialBalance, price, wrapper.GetParameters().OS), price, s.leverage)
+}
+
 func (s *Simulator) RunSingleSimulation(strategy strategy.StrategyWrapper) {
 	info := s.start(strategy)
 	fmt.Println(info)
+	s.printBenchmarks()
+	s.saveRun(strategy.String())
+}
 
-	resultFile := s.resultsFolder + strategy.String() + ".csv"
-	if err := s.writeResults(resultFile); err != nil {
-		log.Errorf("Error writing results to file %s: %s", resultFile, err)
-	} else {
-		log.Infof("Simulation results saved to %s", resultFile)
+// RunHedgeSimulation runs a long and a short strategy at the same time on the
+// same account: they share balance and margin, each one manages its own grid and
+// take profit.
+func (s *Simulator) RunHedgeSimulation(long strategy.StrategyWrapper, short strategy.StrategyWrapper) {
+	if long.GetPositionSide() != engine.PositionSideLong || short.GetPositionSide() != engine.PositionSideShort {
+		log.Panic("Hedge simulation requires a long and a short strategy")
+	}
+
+	info := s.start(long, short)
+	fmt.Println(info)
+	fmt.Println("  LONG -> " + s.simulatorResult.LongMetrics().String())
+	fmt.Println("  SHORT -> " + s.simulatorResult.ShortMetrics().String())
+	s.printBenchmarks()
+	s.saveRun(long.String() + " + " + short.String())
+}
+
+// RunSweep runs the strategy for every combination of the parameter ranges
+// applied to base, skipping the invalid ones and the grids not feasible at the
+// simulator leverage. Only parameters used by the strategy type can be varied.
+// A run that panics is reported as failed and the sweep goes on.
+func (s *Simulator) RunSweep(strategyType strategy.StrategyType, positionSide engine.PositionSideType, base strategy.StrategyParameters, ranges map[string][]float64) []common.RunSummary {
+	grid, err := strategy.ParameterGrid(strategyType, base, ranges)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	runs := s.sweep(strategyType, positionSide, grid)
+	summaries := make([]common.RunSummary, 0, len(runs))
+	for _, run := range runs {
+		summaries = append(summaries, run.summary)
+	}
+
+	s.writeComparisonReport("sweep", summaries)
+	s.writeSensitivityReport("sweep.sensitivity", runs)
+	return summaries
+}
+
+// Evaluate runs the strategy without printing or writing any result, as done by
+// the optimizers. It returns an error if the strategy is not valid or the run
+// panics.
+func (s *Simulator) Evaluate(wrapper strategy.StrategyWrapper) (common.RunSummary, error) {
+	if err := s.validate(wrapper); err != nil {
+		return common.RunSummary{}, err
+	}
+	info, err := s.safeStart(wrapper)
+	if err != nil {
+		return common.RunSummary{}, err
 	}
+	log.Debug(info)
+	return s.simulatorResult.Summary(wrapper.String(), summaryEquityMaxPoint), nil
 }
 
-func (s *Simulator) RunMultipleSimulations(GOvec []uint, GSvec []float64, SFvec []float64, OFvec []float64, TSvec []float64) {
-	symbol := ""
+// RunSample runs the strategy for n parameter sets sampled from the ranges
+// applied to base, reproducible with the same seed. Invalid sets and grids not
+// feasible are skipped as in RunSweep.
+func (s *Simulator) RunSample(strategyType strategy.StrategyType, positionSide engine.PositionSideType, base strategy.StrategyParameters, ranges map[string]strategy.ParameterRange, n int, seed int64, method strategy.SamplingMethod) []common.RunSummary {
+	samples, err := strategy.ParameterSample(strategyType, base, ranges, n, seed, method)
+	if err != nil {
+		log.Panic(err)
+	}
 
-	N := len(GOvec) * len(GSvec) * len(SFvec) * len(OFvec) * len(TSvec)
-	n := 0
+	summaries := make([]common.RunSummary, 0, n)
+	for _, run := range s.sweep(strategyType, positionSide, samples) {
+		summaries = append(summaries, run.summary)
+	}
 
-	for _, GOi := range GOvec {
-		for _, GSi := range GSvec {
-			for _, SFi := range SFvec {
-				for _, OFi := range OFvec {
-					for _, TSi := range TSvec {
-						n++
-						fmt.Printf("Starting simulation %d/%d", n, N)
-						pars := strategy.StrategyParameters{GO: GOi, GS: GSi, SF: SFi, OS: 1, OF: OFi, TS: TSi, SL: 0.3}
-						strategy := strategy.NewStrategy(strategy.StrategyTypeAntiMartingala, symbol, engine.PositionSideLong, pars)
-						info := s.start(*strategy)
-						fmt.Println(info)
-					}
-				}
-			}
+	s.writeComparisonReport("sample", summaries)
+	return summaries
+}
+
+// RunSizingComparison runs the strategy with every sizing, skipping the grids
+// not feasible, to separate the return of the strategy from the one due to
+// compounding. The simulator sizing is left unchanged.
+func (s *Simulator) RunSizingComparison(wrapper strategy.StrategyWrapper, sizings ...strategy.Sizing) []common.RunSummary {
+	current := s.sizing
+	defer func() { s.sizing = current }()
+
+	summaries := make([]common.RunSummary, 0, len(sizings))
+	for _, sizing := range sizings {
+		s.SetSizing(sizing)
+		if err := s.validate(wrapper); err != nil {
+			log.Warnf("Skipping sizing %s: %s", sizing.String(), err)
+			continue
 		}
+		info := s.start(wrapper)
+		fmt.Println(info)
+		summaries = append(summaries, s.simulatorResult.Summary(wrapper.String()+" ["+sizing.String()+"]", summaryEquityMaxPoint))
 	}
+
+	s.writeComparisonReport("sizing", summaries)
+	return summaries
 }
 
-func (s *Simulator) start(strategy strategy.StrategyWrapper) string {
-	// Initialize exchange
-	s.exchange.Init(1000, s.symbolData.Data[0])
+func (s *Simulator) start(strategies ...strategy.StrategyWrapper) string {
+	// Initialize result and exchange
+	s.simulatorResult.Reset()
+	s.simulatorResult.SetBenchmarks(s.benchmarks)
+	s.exchange.Init(initialBalance, s.symbolData.Data[0])
+	api := s.exchange.GetAPI()
+	if s.riskManager != nil {
+		api = s.riskManager.WrapOne(api)
+	}
+	for _, positionSide := range []engine.PositionSideType{engine.PositionSideLong, engine.PositionSideShort} {
+		filters := s.entryFilters[positionSide]
+		if s.riskManager != nil {
+			filters = append(append([]filter.Filter{}, filters...), s.riskManager.EntryFilter())
+		}
+		s.getWorker(positionSide).SetExchangeAPI(api)
+		s.getWorker(positionSide).SetEntryFilters(filters...)
+		s.getWorker(positionSide).SetSizing(s.sizing)
+	}
+	if s.riskManager != nil {
+		s.riskManager.SetFlatteners(&s.workerLong, &s.workerShort)
+		s.riskManager.Start()
+	}
 
-	// Start strategy
-	s.worker.SetStrategy(strategy)
-	s.worker.StartStrategy()
+	// Start strategies, one worker per position side
+	s.workerLong.SetStrategy(nil)
+	s.workerShort.SetStrategy(nil)
+	labels := make([]string, 0, len(strategies))
+	for _, wrapper := range strategies {
+		if err := s.validate(wrapper); err != nil {
+			log.Panicf("Invalid strategy %s: %s", wrapper.String(), err)
+		}
+		if len(strategies) > 1 && strategy.TradesBothSides(wrapper) {
+			log.Panicf("Strategy %s trades both position sides and cannot share the account", wrapper.String())
+		}
+		w := s.getWorker(wrapper.GetPositionSide())
+		if w.GetStrategy() != nil {
+			log.Panicf("More than one strategy for position side %s", wrapper.GetPositionSide())
+		}
+		w.SetStrategy(wrapper)
+		labels = append(labels, wrapper.String())
+	}
+	for _, strategy := range strategies {
+		s.getWorker(strategy.GetPositionSide()).StartStrategy()
+	}
 
 	// Cycle over symbol data
 	for _, tick := range s.symbolData.Data[1:] {
 		s.exchange.Next(tick)
-
-		// recreate grid if worker has still no position after some time
-		// s.worker.HandleGridRecreation(tick.Time)
+		if s.riskManager != nil {
+			s.riskManager.Check()
+			if s.riskManager.Done() {
+				break
+			}
+			if s.riskManager.Stopped() {
+				continue // wait for the positions to be closed
+			}
+		}
+		s.workerLong.HandleTick(tick)
+		s.workerShort.HandleTick(tick)
 	}
-	return strategy.String() + " -> " + fmt.Sprint(s.simulatorResult.Performance())
+	s.workerLong.StopStrategy()
+	s.workerShort.StopStrategy()
+	info := strings.Join(labels, " + ")
+	if s.sizing.Mode != "" {
+		info += " [" + s.sizing.String() + "]"
+	}
+	return info + " -> " + s.simulatorResult.Metrics().String()
 }
 
 func (s *Simulator) writeResults(filepath string) error {
@@ -96,6 +301,191 @@ func (s *Simulator) writeResults(filepath string) error {
 }
 
 // PRIVATE METHODS
+// sweep runs the strategy for every parameter set, skipping the invalid ones and
+// the grids not feasible at the simulator leverage.
+func (s *Simulator) sweep(strategyType strategy.StrategyType, positionSide engine.PositionSideType, sets []strategy.StrategyParameters) []sweepRun {
+	N := len(sets)
+	runs := make([]sweepRun, 0, N)
+	for n, pars := range sets {
+		key := s.runKey(strategyType, positionSide, pars)
+		strategy, err := strategy.NewStrategy(strategyType, "", positionSide, pars)
+		if err != nil {
+			log.Errorf("Simulation %d/%d failed: %s", n+1, N, err)
+			s.putRecord(store.Record{RunKey: key, Status: store.StatusFailed, Error: err.Error(), Summary: common.RunSummary{Label: string(strategyType) + " " + pars.String()}})
+			continue
+		}
+		if err := s.validate(*strategy); err != nil {
+			log.Warnf("Skipping simulation %d/%d, %s: %s", n+1, N, (*strategy).String(), err)
+			continue
+		}
+
+		if s.resultsStore != nil {
+			if record, ok := s.resultsStore.Get(key.ID()); ok && record.Status == store.StatusCompleted {
+				log.Infof("Skipping simulation %d/%d, %s: completed in %s", n+1, N, (*strategy).String(), s.resultsStore.Path())
+				runs = append(runs, sweepRun{pars: pars, summary: record.Summary})
+				continue
+			}
+		}
+
+		fmt.Printf("Starting simulation %d/%d", n+1, N)
+		info, err := s.safeStart(*strategy)
+		if err != nil {
+			fmt.Println()
+			log.Errorf("Simulation %d/%d, %s failed: %s", n+1, N, (*strategy).String(), err)
+			s.putRecord(store.Record{RunKey: key, Status: store.StatusFailed, Error: err.Error(), Summary: common.RunSummary{Label: (*strategy).String()}})
+			continue
+		}
+		fmt.Println(info)
+		summary := s.simulatorResult.Summary((*strategy).String(), summaryEquityMaxPoint)
+		s.putRecord(store.Record{RunKey: key, Status: store.StatusCompleted, Summary: summary})
+		runs = append(runs, sweepRun{pars: pars, summary: summary})
+	}
+	return runs
+}
+
+// safeStart runs the strategies, returning the panic of the run as an error
+func (s *Simulator) safeStart(strategies ...strategy.StrategyWrapper) (info string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if entry, ok := r.(*log.Entry); ok {
+				err = errors.New(entry.Message)
+			} else {
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+	return s.start(strategies...), nil
+}
+
+// runKey identifies the run of a sweep, including the entry filters of the
+// position side and the rules of the risk manager
+func (s *Simulator) runKey(strategyType strategy.StrategyType, positionSide engine.PositionSideType, pars strategy.StrategyParameters) store.RunKey {
+	key := store.RunKey{
+		Dataset:      s.dataset,
+		Start:        s.symbolData.Data[0].Time.UTC(),
+		End:          s.symbolData.Data[len(s.symbolData.Data)-1].Time.UTC(),
+		StrategyType: strategyType,
+		PositionSide: positionSide,
+		Parameters:   pars,
+		Leverage:     s.leverage,
+		Sizing:       s.sizing,
+	}
+	if filters := s.entryFilters[positionSide]; len(filters) > 0 {
+		configs := make([]string, len(filters))
+		for i, f := range filters {
+			configs[i] = filter.Config(f)
+		}
+		key.EntryFilters = map[engine.PositionSideType][]string{positionSide: configs}
+	}
+	if s.riskManager != nil {
+		key.RiskRules = s.riskManager.Rules()
+	}
+	return key
+}
+
+func (s *Simulator) putRecord(record store.Record) {
+	if s.resultsStore == nil {
+		return
+	}
+	if err := s.resultsStore.Put(record); err != nil {
+		log.Errorf("Error writing run to store %s: %s", s.resultsStore.Path(), err)
+	}
+}
+
+// validate checks the strategy parameters and that its grid fits the initial capital
+func (s *Simulator) validate(wrapper strategy.StrategyWrapper) error {
+	if err := strategy.Validate(wrapper); err != nil {
+		return err
+	}
+	price := s.symbolData.Data[0].Price
+	return strategy.ValidateExposure(wrapper, s.sizing.Capital(initialBalance, initialBalance, price, wrapper.GetParameters().OS), price, s.leverage)
+}
+
+func (s *Simulator) addIdleTime(positionSide engine.PositionSideType, idle time.Duration) {
+	s.simulatorResult.AddIdleTime(positionSide == engine.PositionSideLong, idle.Seconds())
+}
+
+func (s *Simulator) getWorker(positionSide engine.PositionSideType) *worker.Worker {
+	if positionSide == engine.PositionSideLong {
+		return &s.workerLong
+	} else {
+		return &s.workerShort
+	}
+}
+
+func (s *Simulator) handleFill(fill engine.Fill) {
+	// every worker keeps the fills of its strategy
+	s.workerLong.HandleFill(fill)
+	s.workerShort.HandleFill(fill)
+}
+
+func (s *Simulator) printBenchmarks() {
+	for _, m := range s.simulatorResult.BenchmarkMetrics() {
+		fmt.Println("  " + m.String())
+	}
+}
+
+func (s *Simulator) saveRun(name string) {
+	resultFile := s.resultsFolder + name + ".csv"
+	if err := s.writeResults(resultFile); err != nil {
+		log.Errorf("Error writing results to file %s: %s", resultFile, err)
+	} else {
+		log.Infof("Simulation results saved to %s", resultFile)
+	}
+
+	if len(s.simulatorResult.Events()) > 0 {
+		eventsFile := s.resultsFolder + name + ".events.csv"
+		if err := s.simulatorResult.WriteEventsToFile(eventsFile); err != nil {
+			log.Errorf("Error writing events to file %s: %s", eventsFile, err)
+		}
+	}
+
+	reportFile := s.resultsFolder + name + ".html"
+	if err := report.WriteSingleRunReport(reportFile, name, &s.simulatorResult); err != nil {
+		log.Errorf("Error writing report to file %s: %s", reportFile, err)
+	} else {
+		log.Infof("Simulation report saved to %s", reportFile)
+	}
+}
+
+func (s *Simulator) writeComparisonReport(name string, runs []common.RunSummary) {
+	summaries := append([]common.RunSummary{}, runs...) // keep the order of the caller
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return summaries[i].Metrics.NetProfit > summaries[j].Metrics.NetProfit
+	})
+	if len(summaries) > comparisonTopN {
+		summaries = summaries[:comparisonTopN]
+	}
+
+	reportFile := s.resultsFolder + name + ".html"
+	title := fmt.Sprintf("Top %d runs by net profit", len(summaries))
+	if err := report.WriteComparisonReport(reportFile, title, summaries, s.benchmarks); err != nil {
+		log.Errorf("Error writing report to file %s: %s", reportFile, err)
+	} else {
+		log.Infof("Comparison report saved to %s", reportFile)
+	}
+}
+
+// writeSensitivityReport reports the sensitivity of the net profit to the
+// parameters of the runs, marginalizing the parameters not shown
+func (s *Simulator) writeSensitivityReport(name string, runs []sweepRun) {
+	points := make([]common.SweepPoint, len(runs))
+	for i, run := range runs {
+		points[i] = common.SweepPoint{Label: run.summary.Label, Parameters: strategy.ParameterValues(run.pars), Metrics: run.summary.Metrics}
+	}
+	result := common.NewSensitivityResult(points, common.MetricNetProfit, false, robustnessTopN)
+	if len(result.Parameters) == 0 {
+		return
+	}
+
+	reportFile := s.resultsFolder + name + ".html"
+	if err := report.WriteSensitivityReport(reportFile, "Sensitivity of the net profit", result); err != nil {
+		log.Errorf("Error writing report to file %s: %s", reportFile, err)
+	} else {
+		log.Infof("Sensitivity report saved to %s", reportFile)
+	}
+}
+
 func (s *Simulator) updateResult(status common.SimulatorStatus) {
 	s.simulatorResult.Append(status)
 }
@@ -0,0 +1,97 @@
+package simulator
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/gobot-simulator/src/common"
+	"example.com/gobot-simulator/src/engine"
+	"example.com/gobot-simulator/src/strategy"
+)
+
+// oscillatingData returns a price per minute oscillating by 5% around 100
+// every 6 hours, for 2 days
+func oscillatingData() *common.SymbolData {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := &common.SymbolData{Symbol: "TEST"}
+	for i := 0; i <= 2*24*60; i++ {
+		price := 100 * (1 + 0.05*math.Sin(2*math.Pi*float64(i)/(6*60)))
+		data.Data = append(data.Data, common.SymbolDataItem{Time: start.Add(time.Duration(i) * time.Minute), Price: price})
+	}
+	return data
+}
+
+// recordingStrategy records the position sides of the fills it receives
+type recordingStrategy struct {
+	*strategy.StrategyMartingala
+	fills map[engine.PositionSideType]int
+}
+
+func newRecordingStrategy(t *testing.T, positionSide engine.PositionSideType) *recordingStrategy {
+	pars, err := strategy.DefaultParameters(strategy.StrategyTypeMartingala)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &recordingStrategy{
+		StrategyMartingala: strategy.NewStrategyMartingala("TEST", positionSide, pars),
+		fills:              make(map[engine.PositionSideType]int),
+	}
+}
+
+func (s *recordingStrategy) OnFill(ctx strategy.Context, fill engine.Fill) {
+	s.fills[fill.Position.PositionSide]++
+	s.StrategyMartingala.OnFill(ctx, fill)
+}
+
+func TestHedgeRun(t *testing.T) {
+	sim := NewSimulator(oscillatingData(), "")
+	long := newRecordingStrategy(t, engine.PositionSideLong)
+	short := newRecordingStrategy(t, engine.PositionSideShort)
+	if _, err := sim.safeStart(long, short); err != nil {
+		t.Fatal(err)
+	}
+
+	if long.fills[engine.PositionSideLong] == 0 || long.fills[engine.PositionSideShort] != 0 {
+		t.Errorf("long strategy got fills %v, want only long ones", long.fills)
+	}
+	if short.fills[engine.PositionSideShort] == 0 || short.fills[engine.PositionSideLong] != 0 {
+		t.Errorf("short strategy got fills %v, want only short ones", short.fills)
+	}
+	result := &sim.simulatorResult
+	if !result.IsHedged() {
+		t.Errorf("run not hedged")
+	}
+	longMetrics, shortMetrics := result.LongMetrics(), result.ShortMetrics()
+	if longMetrics.Cycles == 0 || shortMetrics.Cycles == 0 {
+		t.Errorf("got %d long and %d short cycles, want both sides closing cycles", longMetrics.Cycles, shortMetrics.Cycles)
+	}
+	if fills := result.Metrics().Fills; fills != longMetrics.Fills+shortMetrics.Fills {
+		t.Errorf("got %d fills, want the %d long plus the %d short ones", fills, longMetrics.Fills, shortMetrics.Fills)
+	}
+}
+
+func TestHedgeRejected(t *testing.T) {
+	pars, err := strategy.DefaultParameters(strategy.StrategyTypeNeutralGrid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	neutral := strategy.NewStrategyNeutralGrid("TEST", engine.PositionSideShort, pars)
+	tests := []struct {
+		name       string
+		strategies []strategy.StrategyWrapper
+		err        string
+	}{
+		{"two strategies on a side", []strategy.StrategyWrapper{newRecordingStrategy(t, engine.PositionSideLong), newRecordingStrategy(t, engine.PositionSideLong)}, "More than one strategy"},
+		{"two sided strategy sharing the account", []strategy.StrategyWrapper{newRecordingStrategy(t, engine.PositionSideLong), neutral}, "cannot share the account"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := NewSimulator(oscillatingData(), "")
+			if _, err := sim.safeStart(tt.strategies...); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package simulator
+
+import (
+	"fmt"
+	"os"
+
+	"example.com/gobot-simulator/src/scenario"
+	"example.com/gobot-simulator/src/store"
+	"example.com/gobot-simulator/src/strategy"
+	log "github.com/sirupsen/logrus"
+)
+
+// RunStress runs the strategy on every scenario, saving every run as
+// RunSingleSimulation does, and prints the pass/fail matrix of the scenarios
+// against the risk thresholds on the metrics.
+func (s *Simulator) RunStress(wrapper strategy.StrategyWrapper, library *scenario.Library, scenarios []scenario.Scenario, thresholds []store.Condition) []scenario.StressResult {
+	if err := scenario.ValidateThresholds(thresholds); err != nil {
+		log.Panic(err)
+	}
+
+	data := s.symbolData
+	defer s.useData(data)
+
+	results := make([]scenario.StressResult, 0, len(scenarios))
+	for n, sc := range scenarios {
+		result, err := s.stress(wrapper, library, sc, thresholds)
+		if err != nil {
+			log.Errorf("Scenario %d/%d %s failed: %s", n+1, len(scenarios), sc.Name, err)
+			result = scenario.NewFailedStressResult(sc, err)
+		}
+		results = append(results, result)
+	}
+
+	fmt.Printf("Stress test %s\n", wrapper.String())
+	scenario.WriteMatrix(os.Stdout, results, thresholds)
+	return results
+}
+
+// PRIVATE METHODS
+func (s *Simulator) stress(wrapper strategy.StrategyWrapper, library *scenario.Library, sc scenario.Scenario, thresholds []store.Condition) (scenario.StressResult, error) {
+	scenarioData, err := library.Data(sc)
+	if err != nil {
+		return scenario.StressResult{}, err
+	}
+	s.useData(scenarioData)
+	if err := s.validate(wrapper); err != nil {
+		return scenario.StressResult{}, err
+	}
+	info, err := s.safeStart(wrapper)
+	if err != nil {
+		return scenario.StressResult{}, err
+	}
+	fmt.Printf("Scenario %s: %s\n", sc.Name, info)
+	s.saveRun("stress." + sc.Name)
+	return scenario.NewStressResult(sc, s.simulatorResult.Metrics(), thresholds), nil
+}
@@ -0,0 +1,149 @@
+package simulator
+
+import (
+	"fmt"
+	"time"
+
+	"example.com/gobot-simulator/src/common"
+	"example.com/gobot-simulator/src/engine"
+	"example.com/gobot-simulator/src/report"
+	"example.com/gobot-simulator/src/strategy"
+	log "github.com/sirupsen/logrus"
+)
+
+// WalkForwardConfig sets the windows of a walk-forward optimization. Rolling
+// windows keep the in-sample length, anchored ones always start at the
+// beginning of the data. Windows move forward by the out-of-sample length.
+type WalkForwardConfig struct {
+	InSample    time.Duration     `json:"inSample"`
+	OutOfSample time.Duration     `json:"outOfSample"`
+	Anchored    bool              `json:"anchored"`
+	Metric      common.MetricName `json:"metric"` // used to choose the in-sample run
+}
+
+// RunWalkForward sweeps the parameter ranges in-sample, runs the best
+// parameters by the config metric out-of-sample and stitches the out-of-sample
+// runs into one report, with the efficiency ratio of every window.
+func (s *Simulator) RunWalkForward(strategyType strategy.StrategyType, positionSide engine.PositionSideType, base strategy.StrategyParameters, ranges map[string][]float64, config WalkForwardConfig) *common.WalkForwardResult {
+	grid, err := strategy.ParameterGrid(strategyType, base, ranges)
+	if err != nil {
+		log.Panic(err)
+	}
+	if config.InSample <= 0 || config.OutOfSample <= 0 {
+		log.Panic("Walk-forward windows must have a positive length")
+	}
+	common.Metrics{}.Score(config.Metric) // panics on unknown metrics before running
+
+	data := s.symbolData
+	defer s.useData(data)
+
+	result := &common.WalkForwardResult{Metric: config.Metric}
+	stitched := make([]common.SimulatorStatus, 0)
+	inSampleReturn := 0.0
+	for _, w := range walkForwardWindows(data, config) {
+		inSample, outSample := data.Slice(w.InSampleStart, w.InSampleEnd), data.Slice(w.OutSampleStart, w.OutSampleEnd)
+		if len(inSample.Data) < 2 || len(outSample.Data) < 2 {
+			log.Warnf("Skipping window %s: not enough data", w.InSampleStart.UTC().Format(time.RFC3339))
+			continue
+		}
+
+		s.useData(inSample)
+		runs := s.sweep(strategyType, positionSide, grid)
+		if len(runs) == 0 {
+			log.Warnf("Skipping window %s: no valid in-sample run", w.InSampleStart.UTC().Format(time.RFC3339))
+			continue
+		}
+		best := runs[0]
+		for _, run := range runs[1:] {
+			if run.summary.Metrics.Score(config.Metric) > best.summary.Metrics.Score(config.Metric) {
+				best = run
+			}
+		}
+
+		s.useData(outSample)
+		strat, err := strategy.NewStrategy(strategyType, "", positionSide, best.pars)
+		if err != nil {
+			log.Panic(err)
+		}
+		s.start(*strat)
+		stitched = common.StitchOutSample(stitched, s.simulatorResult.History())
+
+		w.Label = best.summary.Label
+		w.Runs = len(runs)
+		w.InSample = best.summary.Metrics
+		w.OutSample = s.simulatorResult.Metrics()
+		w.Efficiency = common.WalkForwardEfficiency(w.InSample, seconds(w.InSampleStart, w.InSampleEnd), w.OutSample, seconds(w.OutSampleStart, w.OutSampleEnd))
+		inSampleReturn += common.AnnualizedReturnPerc(w.InSample.ReturnPerc, seconds(w.InSampleStart, w.InSampleEnd))
+		result.Windows = append(result.Windows, w)
+		fmt.Println("Walk-forward " + w.String())
+	}
+	if len(result.Windows) == 0 {
+		log.Warn("Walk-forward without any window")
+		return result
+	}
+
+	result.Metrics = common.ComputeMetrics(stitched)
+	first, last := stitched[0], stitched[len(stitched)-1]
+	if inSampleReturn > 0 {
+		outSampleReturn := common.AnnualizedReturnPerc(result.Metrics.ReturnPerc, last.Timestamp-first.Timestamp)
+		result.Efficiency = outSampleReturn / (inSampleReturn / float64(len(result.Windows)))
+	}
+	for _, i := range common.DownsampleIndexes(len(stitched), summaryEquityMaxPoint) {
+		result.Timestamps = append(result.Timestamps, stitched[i].Timestamp)
+		result.Equity = append(result.Equity, stitched[i].TotalEquity())
+	}
+	fmt.Printf("Walk-forward %d windows -> %s, efficiency %.2f\n", len(result.Windows), result.Metrics.String(), result.Efficiency)
+
+	outSample := data.Slice(time.Unix(first.Timestamp, 0), time.Unix(last.Timestamp+1, 0))
+	reportFile := s.resultsFolder + "walkforward.html"
+	title := fmt.Sprintf("Walk-forward %s %s by %s", strategyType, positionSide, config.Metric)
+	if err := report.WriteWalkForwardReport(reportFile, title, result, common.NewBenchmarks(outSample, initialBalance)); err != nil {
+		log.Errorf("Error writing report to file %s: %s", reportFile, err)
+	} else {
+		log.Infof("Walk-forward report saved to %s", reportFile)
+	}
+	return result
+}
+
+// PRIVATE METHODS
+// useData sets the data of the following runs
+func (s *Simulator) useData(data *common.SymbolData) {
+	s.symbolData = data
+	s.benchmarks = common.NewBenchmarks(data, initialBalance)
+}
+
+// PRIVATE FUNCTIONS
+// walkForwardWindows splits the data into windows, the last out-of-sample one
+// being cut at the end of the data.
+func walkForwardWindows(data *common.SymbolData, config WalkForwardConfig) []common.WalkForwardWindow {
+	windows := make([]common.WalkForwardWindow, 0)
+	if len(data.Data) < 2 {
+		return windows
+	}
+	start := data.Data[0].Time
+	end := data.Data[len(data.Data)-1].Time.Add(time.Second)
+	for k := 0; ; k++ {
+		shift := time.Duration(k) * config.OutOfSample
+		w := common.WalkForwardWindow{
+			InSampleStart: start.Add(shift),
+			InSampleEnd:   start.Add(shift + config.InSample),
+		}
+		if config.Anchored {
+			w.InSampleStart = start
+		}
+		w.OutSampleStart = w.InSampleEnd
+		w.OutSampleEnd = w.InSampleEnd.Add(config.OutOfSample)
+		if !w.OutSampleStart.Before(end) {
+			break
+		}
+		if w.OutSampleEnd.After(end) {
+			w.OutSampleEnd = end
+		}
+		windows = append(windows, w)
+	}
+	return windows
+}
+
+func seconds(from time.Time, to time.Time) int64 {
+	return int64(to.Sub(from) / time.Second)
+}
@@ -0,0 +1,172 @@
+package store
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/gobot-simulator/src/common"
+	"example.com/gobot-simulator/src/strategy"
+)
+
+var operators = []string{"<=", ">=", "!=", "<", ">", "="} // longest first for parsing
+
+// Condition compares a metric or a parameter of the records with a value
+type Condition struct {
+	Field    string  `json:"field"`
+	Operator string  `json:"operator"`
+	Value    float64 `json:"value"`
+}
+
+// ParseCondition parses conditions like "maxDrawdownPerc<30" or "GO>=5"
+func ParseCondition(s string) (Condition, error) {
+	for _, op := range operators {
+		if i := strings.Index(s, op); i > 0 {
+			value, err := strconv.ParseFloat(strings.TrimSpace(s[i+len(op):]), 64)
+			if err != nil {
+				return Condition{}, fmt.Errorf("invalid value in condition %s: %w", s, err)
+			}
+			return Condition{Field: strings.TrimSpace(s[:i]), Operator: op, Value: value}, nil
+		}
+	}
+	return Condition{}, fmt.Errorf("invalid condition %s, expected <field><op><value> with op in %v", s, operators)
+}
+
+// Match returns true if the value satisfies the condition
+func (c Condition) Match(value float64) bool {
+	switch c.Operator {
+	case "<":
+		return value < c.Value
+	case "<=":
+		return value <= c.Value
+	case ">":
+		return value > c.Value
+	case ">=":
+		return value >= c.Value
+	case "=":
+		return value == c.Value
+	default:
+		return value != c.Value
+	}
+}
+
+func (c Condition) String() string {
+	return fmt.Sprintf("%s%s%g", c.Field, c.Operator, c.Value)
+}
+
+// Query selects and orders records. Empty fields do not filter, only completed
+// runs are returned unless Status is set.
+type Query struct {
+	Dataset      string                `json:"dataset"`
+	StrategyType strategy.StrategyType `json:"strategyType"`
+	Status       Status                `json:"status"`
+	Where        []Condition           `json:"where"`
+	OrderBy      string                `json:"orderBy"` // metric or parameter, descending unless Ascending
+	Ascending    bool                  `json:"ascending"`
+	Limit        int                   `json:"limit"` // 0 for all
+}
+
+// Query returns the records matching the query
+func (s *Store) Query(q Query) ([]Record, error) {
+	for _, c := range q.Where {
+		if !isField(c.Field) {
+			return nil, fmt.Errorf("unknown field %s in condition %s", c.Field, c.String())
+		}
+	}
+	if q.OrderBy != "" && !isField(q.OrderBy) {
+		return nil, fmt.Errorf("unknown field %s to order by", q.OrderBy)
+	}
+	status := q.Status
+	if status == "" {
+		status = StatusCompleted
+	}
+
+	selected := make([]Record, 0)
+	for _, r := range s.Records() {
+		if r.Status != status || (q.Dataset != "" && r.Dataset != q.Dataset) || (q.StrategyType != "" && r.StrategyType != q.StrategyType) {
+			continue
+		}
+		match := true
+		for _, c := range q.Where {
+			value, _ := r.Field(c.Field)
+			match = match && c.Match(value)
+		}
+		if match {
+			selected = append(selected, r)
+		}
+	}
+
+	if q.OrderBy != "" {
+		sort.SliceStable(selected, func(i, j int) bool {
+			a, _ := selected[i].Field(q.OrderBy)
+			b, _ := selected[j].Field(q.OrderBy)
+			if q.Ascending {
+				return a < b
+			}
+			return a > b
+		})
+	}
+	if q.Limit > 0 && len(selected) > q.Limit {
+		selected = selected[:q.Limit]
+	}
+	return selected, nil
+}
+
+// Field returns a metric or a parameter of the record by JSON name
+func (r Record) Field(name string) (float64, bool) {
+	if value, ok := r.Summary.Metrics.Value(name); ok {
+		return value, true
+	}
+	value, err := strategy.GetParameter(r.Parameters, name)
+	return value, err == nil
+}
+
+// SweepPoint returns the run as analyzed by common.NewSensitivityResult
+func (r Record) SweepPoint() common.SweepPoint {
+	return common.SweepPoint{Label: r.Summary.Label, Parameters: strategy.ParameterValues(r.Parameters), Metrics: r.Summary.Metrics}
+}
+
+// WriteCSV writes the records with all their parameters and metrics
+func WriteCSV(filepath string, records []Record) error {
+	file, err := os.OpenFile(filepath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	fields := append(strategy.ParameterNames(), common.MetricNames()...)
+	w := csv.NewWriter(file)
+	header := []string{"id", "dataset", "start", "end", "strategyType", "positionSide", "leverage", "sizing", "status", "error", "label"}
+	header = append(header, fields...)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, r := range records {
+		row := []string{r.ID, r.Dataset, r.Start.Format(time.RFC3339), r.End.Format(time.RFC3339),
+			string(r.StrategyType), string(r.PositionSide), strconv.FormatFloat(r.Leverage, 'f', -1, 64), r.Sizing.String(),
+			string(r.Status), r.Error, r.Summary.Label}
+		for _, name := range fields {
+			value, _ := r.Field(name)
+			row = append(row, strconv.FormatFloat(value, 'f', -1, 64))
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// PRIVATE FUNCTIONS
+func isField(name string) bool {
+	for _, n := range append(strategy.ParameterNames(), common.MetricNames()...) {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
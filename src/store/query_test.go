@@ -0,0 +1,142 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+
+	"example.com/gobot-simulator/src/common"
+	"example.com/gobot-simulator/src/strategy"
+)
+
+func TestParseCondition(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Condition
+		err   bool
+	}{
+		{input: "maxDrawdownPerc<30", want: Condition{Field: "maxDrawdownPerc", Operator: "<", Value: 30}},
+		{input: "GO>=5", want: Condition{Field: "GO", Operator: ">=", Value: 5}},
+		{input: "sharpe <= 1.5", want: Condition{Field: "sharpe", Operator: "<=", Value: 1.5}},
+		{input: "returnPerc>-2", want: Condition{Field: "returnPerc", Operator: ">", Value: -2}},
+		{input: "GS=0.3", want: Condition{Field: "GS", Operator: "=", Value: 0.3}},
+		{input: "GO!=3", want: Condition{Field: "GO", Operator: "!=", Value: 3}},
+		{input: "GO", err: true},
+		{input: "<5", err: true},
+		{input: "GO>=", err: true},
+		{input: "GO>=five", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseCondition(tt.input)
+			if tt.err {
+				if err == nil {
+					t.Errorf("expected an error, got %s", got.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConditionMatch(t *testing.T) {
+	tests := []struct {
+		operator string
+		matches  []bool // for values 1, 2 and 3 against 2
+	}{
+		{"<", []bool{true, false, false}},
+		{"<=", []bool{true, true, false}},
+		{">", []bool{false, false, true}},
+		{">=", []bool{false, true, true}},
+		{"=", []bool{false, true, false}},
+		{"!=", []bool{true, false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.operator, func(t *testing.T) {
+			c := Condition{Field: "GO", Operator: tt.operator, Value: 2}
+			for i, want := range tt.matches {
+				if got := c.Match(float64(i + 1)); got != want {
+					t.Errorf("%g %s: got %t, want %t", float64(i+1), c.String(), got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestQuery(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "runs.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	records := []struct {
+		label        string
+		dataset      string
+		strategyType strategy.StrategyType
+		gridOrders   uint
+		returnPerc   float64
+		status       Status
+	}{
+		{"a", "DOGE", strategy.StrategyTypeMartingala, 3, 5, StatusCompleted},
+		{"b", "DOGE", strategy.StrategyTypeMartingala, 5, 8, StatusCompleted},
+		{"c", "DOGE", strategy.StrategyTypeAntiMartingala, 5, 2, StatusCompleted},
+		{"d", "LTC", strategy.StrategyTypeMartingala, 7, 12, StatusCompleted},
+		{"e", "DOGE", strategy.StrategyTypeMartingala, 9, 0, StatusFailed},
+	}
+	for _, r := range records {
+		key := RunKey{Dataset: r.dataset, StrategyType: r.strategyType, Parameters: strategy.StrategyParameters{GO: r.gridOrders}}
+		summary := common.RunSummary{Label: r.label, Metrics: common.Metrics{ReturnPerc: r.returnPerc}}
+		if err := s.Put(Record{RunKey: key, Status: r.status, Summary: summary}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []string
+		err   bool
+	}{
+		{name: "completed runs by insertion", query: Query{}, want: []string{"a", "b", "c", "d"}},
+		{name: "failed runs", query: Query{Status: StatusFailed}, want: []string{"e"}},
+		{name: "dataset and strategy type", query: Query{Dataset: "DOGE", StrategyType: strategy.StrategyTypeMartingala}, want: []string{"a", "b"}},
+		{name: "parameter condition", query: Query{Where: []Condition{{Field: "GO", Operator: ">=", Value: 5}}}, want: []string{"b", "c", "d"}},
+		{name: "conditions are combined", query: Query{Where: []Condition{{Field: "GO", Operator: ">=", Value: 5}, {Field: "returnPerc", Operator: "<", Value: 10}}}, want: []string{"b", "c"}},
+		{name: "descending order", query: Query{OrderBy: "returnPerc"}, want: []string{"d", "b", "a", "c"}},
+		{name: "ascending order with limit", query: Query{OrderBy: "returnPerc", Ascending: true, Limit: 2}, want: []string{"c", "a"}},
+		{name: "unknown condition field", query: Query{Where: []Condition{{Field: "XX", Operator: "<", Value: 1}}}, err: true},
+		{name: "unknown order field", query: Query{OrderBy: "XX"}, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Query(tt.query)
+			if tt.err {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			labels := make([]string, len(got))
+			for i, r := range got {
+				labels[i] = r.Summary.Label
+			}
+			if len(labels) != len(tt.want) {
+				t.Fatalf("got %v, want %v", labels, tt.want)
+			}
+			for i := range labels {
+				if labels[i] != tt.want[i] {
+					t.Errorf("got %v, want %v", labels, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package store
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"example.com/gobot-simulator/src/common"
+	"example.com/gobot-simulator/src/engine"
+	"example.com/gobot-simulator/src/risk"
+	"example.com/gobot-simulator/src/strategy"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type Status string
+
+const (
+	StatusCompleted Status = "COMPLETED"
+	StatusFailed    Status = "FAILED" // the run panicked, see the error
+)
+
+// RunKey identifies a run: the same key always gives the same result
+type RunKey struct {
+	Dataset      string                      `json:"dataset"`
+	Start        time.Time                   `json:"start"`
+	End          time.Time                   `json:"end"`
+	StrategyType strategy.StrategyType       `json:"strategyType"`
+	PositionSide engine.PositionSideType     `json:"positionSide"`
+	Parameters   strategy.StrategyParameters `json:"parameters"`
+	Leverage     float64                     `json:"leverage"`
+	Sizing       strategy.Sizing             `json:"sizing"`
+
+	// empty without entry filters and risk manager, leaving the ID unchanged
+	EntryFilters map[engine.PositionSideType][]string `json:"entryFilters,omitempty"` // see filter.Config
+	RiskRules    []risk.Rule                          `json:"riskRules,omitempty"`
+}
+
+// ID returns a short hash of the key
+func (k RunKey) ID() string {
+	data, err := json.Marshal(k)
+	if err != nil {
+		log.Panic(err)
+	}
+	sum := sha1.Sum(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+type Record struct {
+	ID string `json:"id"`
+	RunKey
+	Status    Status            `json:"status"`
+	Error     string            `json:"error,omitempty"`
+	Summary   common.RunSummary `json:"summary"`
+	CreatedAt time.Time         `json:"createdAt"`
+}
+
+// Store keeps the records of the runs in a JSONL file, every record being
+// appended as soon as it is put so that nothing completed is lost if the
+// process dies. The last record of an ID wins. Safe for concurrent use.
+type Store struct {
+	path    string
+	file    *os.File
+	records map[string]Record
+	order   []string // IDs by first insertion
+	mu      sync.Mutex
+}
+
+// Open loads the records of the file, created if it does not exist. Lines
+// that cannot be decoded, e.g. cut by an interruption, are skipped.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, records: make(map[string]Record)}
+	file, err := os.Open(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil {
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			var record Record
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				log.Warnf("Skipping record of %s: %s", path, err)
+				continue
+			}
+			s.add(record)
+		}
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	s.file, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// PUBLIC METHODS
+func (s *Store) Get(id string) (Record, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[id]
+	return record, ok
+}
+
+// Put appends the record to the file, setting its ID and creation time
+func (s *Store) Put(record Record) error {
+	record.ID = record.RunKey.ID()
+	record.CreatedAt = time.Now().UTC()
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	s.add(record)
+	return nil
+}
+
+// Records returns the records by first insertion
+func (s *Store) Records() []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records := make([]Record, 0, len(s.order))
+	for _, id := range s.order {
+		records = append(records, s.records[id])
+	}
+	return records
+}
+
+func (s *Store) Path() string { return s.path }
+
+func (s *Store) Close() error {
+	return s.file.Close()
+}
+
+// PRIVATE METHODS
+func (s *Store) add(record Record) {
+	if _, ok := s.records[record.ID]; !ok {
+		s.order = append(s.order, record.ID)
+	}
+	s.records[record.ID] = record
+}
@@ -0,0 +1,201 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"example.com/gobot-simulator/src/common"
+	"example.com/gobot-simulator/src/engine"
+	"example.com/gobot-simulator/src/indicator"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	VolatilityATR    = 0 // average true range, in % of the price
+	VolatilityStdDev = 1 // standard deviation of the log returns, in %
+
+	volatilityIndicator = "volatility"
+)
+
+// StrategyAdaptiveMartingala is the Martingala with grid step and take profit
+// scaled by a rolling volatility estimate: GS and TS are multiples of the
+// volatility, clamped to [VL, VH]%. The first grid is created once the
+// volatility window is complete, every grid keeps the steps of its creation.
+// AdaptiveParameters are the parameters of the AdaptiveMartingala, GS and TS
+// being multiples of the volatility
+type AdaptiveParameters struct {
+	MartingalaParameters `yaml:",inline"`
+	VW                   uint    `json:"VW" yaml:"VW"` // volatility window, number of 1 minute bars
+	VM                   uint    `json:"VM" yaml:"VM"` // volatility estimate, 0 ATR, 1 standard deviation of the returns
+	VL                   float64 `json:"VL" yaml:"VL"` // minimum grid step and take profit, %
+	VH                   float64 `json:"VH" yaml:"VH"` // maximum grid step and take profit, %
+}
+
+type StrategyAdaptiveMartingala struct {
+	Type         StrategyType            `json:"type" yaml:"type"`
+	Symbol       string                  `json:"symbol" yaml:"symbol"`
+	PositionSide engine.PositionSideType `json:"positionSide" yaml:"positionSide"`
+	Status       string                  `json:"status" yaml:"status"`
+	Parameters   AdaptiveParameters      `json:"parameters" yaml:"parameters"`
+
+	gridCycle  GridCycle
+	volatility indicator.Indicator // on bars of 1 minute
+	lastPrice  float64
+	waiting    bool                 // for the volatility window to be complete
+	cyclePars  MartingalaParameters // parameters of the current grid, GS and TS in %
+}
+
+func init() {
+	Register(Registration{
+		Type: StrategyTypeAdaptive,
+		New: func(symbol string, positionSide engine.PositionSideType, pars StrategyParameters) StrategyWrapper {
+			return NewStrategyAdaptiveMartingala(symbol, positionSide, pars)
+		},
+		Set: AdaptiveParameters{},
+		Parameters: append([]ParameterSpec{
+			intParameter("GO", 1, 100, 5),
+			describe(floatParameter("GS", 0.01, 100, 1), "grid step, multiple of the volatility"),
+			floatParameter("SF", 0.1, 10, 1.5),
+			floatParameter("OS", 0.01, 100, 1),
+			floatParameter("OF", 1, 10, 2),
+			describe(floatParameter("TS", 0.01, 100, 1), "take profit step, multiple of the volatility"),
+			intParameter("VW", 2, 7*24*60, 60),
+			intParameter("VM", 0, 1, VolatilityATR),
+			floatParameter("VL", 0.01, 50, 0.1),
+			floatParameter("VH", 0.01, 50, 2),
+		}, reanchorParameters()...),
+	})
+}
+
+func (s *StrategyAdaptiveMartingala) BuyGridOrders(balance float64, startPrice float64) []*engine.Order {
+	pars := s.cycleParameters()
+	return martingalaBuyOrders(s.Symbol, pars, balance, startPrice)
+}
+
+func (s *StrategyAdaptiveMartingala) SellGridOrders(balance float64, startPrice float64) []*engine.Order {
+	pars := s.cycleParameters()
+	return martingalaSellOrders(s.Symbol, pars, balance, startPrice)
+}
+
+func (s *StrategyAdaptiveMartingala) TakeProfitOrder(position engine.Position, currentGrid int64) *engine.Order {
+	return martingalaTakeProfit(s.Symbol, s.PositionSide, s.cyclePars, position)
+}
+
+func (s *StrategyAdaptiveMartingala) String() string {
+	return string(s.GetType()) + " " + string(s.GetPositionSide()) + " " + FormatParameters(s)
+}
+
+// EVENTS
+func (s *StrategyAdaptiveMartingala) OnStart(ctx Context) {
+	if s.Parameters.VM == VolatilityStdDev {
+		s.volatility = ctx.Indicators().Add(volatilityIndicator, time.Minute, indicator.NewReturnsStdDev(int(s.Parameters.VW)))
+	} else {
+		s.volatility = ctx.Indicators().Add(volatilityIndicator, time.Minute, indicator.NewATR(int(s.Parameters.VW)))
+	}
+	s.waiting = true
+}
+
+func (s *StrategyAdaptiveMartingala) OnTick(ctx Context, tick common.SymbolDataItem) {
+	s.lastPrice = tick.Price
+	if s.waiting {
+		if s.volatility.Ready() {
+			s.waiting = false
+			startGridCycle(ctx, s)
+		}
+		return
+	}
+	handleGridTick(ctx, s, tick)
+}
+
+func (s *StrategyAdaptiveMartingala) OnFill(ctx Context, fill engine.Fill) {
+	handleGridFill(ctx, s, fill)
+}
+
+func (s *StrategyAdaptiveMartingala) OnCancel(ctx Context, order engine.Order) {}
+
+func (s *StrategyAdaptiveMartingala) OnTimer(ctx Context, name string) {
+	handleGridTimer(ctx, s, name)
+}
+
+// GETTERS
+func (s *StrategyAdaptiveMartingala) GetType() StrategyType { return s.Type }
+
+func (s *StrategyAdaptiveMartingala) GetSymbol() string { return s.Symbol }
+
+func (s *StrategyAdaptiveMartingala) GetPositionSide() engine.PositionSideType {
+	return s.PositionSide
+}
+
+func (s *StrategyAdaptiveMartingala) GetStatus() string { return s.Status }
+
+func (s *StrategyAdaptiveMartingala) GetParameters() StrategyParameters {
+	return toStrategyParameters(s.Parameters)
+}
+
+func (s *StrategyAdaptiveMartingala) GetGridCycle() *GridCycle { return &s.gridCycle }
+
+func (s *StrategyAdaptiveMartingala) EntryAtMarket() bool { return true }
+
+// SETTERS
+func (s *StrategyAdaptiveMartingala) SetSymbol(symbol string) { s.Symbol = symbol }
+
+func (s *StrategyAdaptiveMartingala) SetPositionSide(positionSide engine.PositionSideType) {
+	s.PositionSide = positionSide
+}
+
+func (s *StrategyAdaptiveMartingala) SetStatus(status string) { s.Status = status }
+
+func (s *StrategyAdaptiveMartingala) SetParameters(pars StrategyParameters) {
+	s.Parameters = NewAdaptiveParameters(pars)
+}
+
+func NewStrategyAdaptiveMartingala(symbol string, positionSide engine.PositionSideType, pars StrategyParameters) *StrategyAdaptiveMartingala {
+	return &StrategyAdaptiveMartingala{
+		Type:         StrategyTypeAdaptive,
+		Symbol:       symbol,
+		PositionSide: positionSide,
+		Status:       "",
+		Parameters:   NewAdaptiveParameters(pars),
+	}
+}
+
+// NewAdaptiveParameters takes the parameters of the set from pars
+func NewAdaptiveParameters(pars StrategyParameters) AdaptiveParameters {
+	set := AdaptiveParameters{}
+	copyParameters(&set, pars)
+	return set
+}
+
+// PRIVATE METHODS
+// startCycle keeps the steps of the grid being created for its take profit
+func (s *StrategyAdaptiveMartingala) startCycle() {
+	s.cyclePars = s.cycleParameters()
+}
+
+func (s *StrategyAdaptiveMartingala) validate() error {
+	if s.Parameters.VL > s.Parameters.VH {
+		return fmt.Errorf("minimum step VL %.2f greater than maximum step VH %.2f", s.Parameters.VL, s.Parameters.VH)
+	}
+	return nil
+}
+
+// cycleParameters converts GS and TS to % of the current volatility. Without
+// volatility (e.g. in the pre-run analysis) the widest step VH is used.
+func (s *StrategyAdaptiveMartingala) cycleParameters() MartingalaParameters {
+	pars := s.Parameters.MartingalaParameters
+	if s.volatility == nil || !s.volatility.Ready() {
+		pars.GS = s.Parameters.VH
+		pars.TS = s.Parameters.VH
+		return pars
+	}
+	volatility := s.volatility.Value()
+	if s.Parameters.VM != VolatilityStdDev {
+		volatility = volatility / s.lastPrice * 100
+	}
+	pars.GS = math.Min(math.Max(s.Parameters.GS*volatility, s.Parameters.VL), s.Parameters.VH)
+	pars.TS = math.Min(math.Max(s.Parameters.TS*volatility, s.Parameters.VL), s.Parameters.VH)
+	log.Debugf("Strategy: volatility %.4f%%, grid step %.4f%%, take profit %.4f%%", volatility, pars.GS, pars.TS)
+	return pars
+}
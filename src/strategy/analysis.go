@@ -0,0 +1,149 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"example.com/gobot-simulator/src/engine"
+)
+
+// GridLevel is the state of the position once a grid order is filled, level 0
+// being the market entry.
+type GridLevel struct {
+	Grid               int64   `json:"grid"`
+	Price              float64 `json:"price"`
+	Size               float64 `json:"size"`
+	Notional           float64 `json:"notional"`
+	CumulativeSize     float64 `json:"cumulativeSize"`
+	CumulativeNotional float64 `json:"cumulativeNotional"`
+	AverageEntry       float64 `json:"averageEntry"`
+	TakeProfit         float64 `json:"takeProfit"`
+	RequiredMargin     float64 `json:"requiredMargin"`
+	LiquidationPrice   float64 `json:"liquidationPrice"` // 0 if the position cannot be liquidated
+}
+
+// GridAnalysis describes the capital needed by a grid strategy and how far the
+// price can move against it, before running a simulation.
+type GridAnalysis struct {
+	Strategy            string      `json:"strategy"`
+	Balance             float64     `json:"balance"`
+	StartPrice          float64     `json:"startPrice"`
+	Leverage            float64     `json:"leverage"`
+	Levels              []GridLevel `json:"levels"`
+	TotalNotional       float64     `json:"totalNotional"`
+	RequiredMargin      float64     `json:"requiredMargin"`
+	ExhaustMovePerc     float64     `json:"exhaustMovePerc"`     // price move from the start price filling every level
+	LiquidationPrice    float64     `json:"liquidationPrice"`    // with every level filled
+	LiquidationMovePerc float64     `json:"liquidationMovePerc"` // adverse price move from the start price, 0 if not reachable
+	Feasible            bool        `json:"feasible"`
+	Reason              string      `json:"reason"`
+}
+
+// AnalyzeGrid computes the grid levels of a grid strategy and checks that the
+// whole grid fits the balance at the given leverage. The liquidation price is
+// computed with the whole balance as collateral and no maintenance margin.
+func AnalyzeGrid(strategy StrategyWrapper, balance float64, startPrice float64, leverage float64) (*GridAnalysis, error) {
+	gridStrategy, ok := strategy.(GridStrategy)
+	if !ok {
+		return nil, fmt.Errorf("strategy %s has no grid", strategy.GetType())
+	}
+	if balance <= 0 || startPrice <= 0 || leverage <= 0 {
+		return nil, fmt.Errorf("balance, start price and leverage must be positive")
+	}
+
+	positionSide := gridStrategy.GetPositionSide()
+	var orders []*engine.Order
+	if gridStrategy.EntryAtMarket() {
+		size := (balance / startPrice) * (gridStrategy.GetParameters().OS / 100)
+		orders = append(orders, &engine.Order{Price: startPrice, Amount: size})
+	}
+	if positionSide == engine.PositionSideLong {
+		orders = append(orders, gridStrategy.BuyGridOrders(balance, startPrice)...)
+	} else {
+		orders = append(orders, gridStrategy.SellGridOrders(balance, startPrice)...)
+	}
+
+	analysis := &GridAnalysis{
+		Strategy:   strategy.String(),
+		Balance:    balance,
+		StartPrice: startPrice,
+		Leverage:   leverage,
+		Levels:     make([]GridLevel, 0, len(orders)),
+		Feasible:   true,
+	}
+	size := 0.0
+	notional := 0.0
+	for _, order := range orders {
+		price := order.Price
+
+		// grid reached before the previous position is liquidated
+		if n := len(analysis.Levels); n > 0 && analysis.Feasible {
+			liquidation := analysis.Levels[n-1].LiquidationPrice
+			if liquidation != 0 && ((positionSide == engine.PositionSideLong && price <= liquidation) ||
+				(positionSide == engine.PositionSideShort && price >= liquidation)) {
+				analysis.Feasible = false
+				analysis.Reason = fmt.Sprintf("liquidated at %.6f before grid %d at %.6f", liquidation, order.GridNumber, price)
+			}
+		}
+
+		size += order.Amount
+		notional += order.Amount * price
+		level := GridLevel{
+			Grid:               order.GridNumber,
+			Price:              price,
+			Size:               order.Amount,
+			Notional:           order.Amount * price,
+			CumulativeSize:     size,
+			CumulativeNotional: notional,
+			AverageEntry:       notional / size,
+			RequiredMargin:     notional / leverage,
+		}
+		level.LiquidationPrice = liquidationPrice(positionSide, level.AverageEntry, size, balance)
+		position := engine.Position{PositionSide: positionSide, EntryPrice: level.AverageEntry, Size: size, MarkPrice: price}
+		if tp := gridStrategy.TakeProfitOrder(position, order.GridNumber); tp != nil {
+			level.TakeProfit = tp.Price
+		}
+		analysis.Levels = append(analysis.Levels, level)
+	}
+	if len(analysis.Levels) == 0 {
+		return analysis, nil
+	}
+
+	last := analysis.Levels[len(analysis.Levels)-1]
+	analysis.TotalNotional = last.CumulativeNotional
+	analysis.RequiredMargin = last.RequiredMargin
+	analysis.ExhaustMovePerc = math.Abs(last.Price/startPrice-1) * 100
+	analysis.LiquidationPrice = last.LiquidationPrice
+	if last.LiquidationPrice != 0 {
+		analysis.LiquidationMovePerc = math.Abs(last.LiquidationPrice/startPrice-1) * 100
+	}
+	if analysis.RequiredMargin > balance {
+		analysis.Feasible = false
+		analysis.Reason = fmt.Sprintf("required margin %.2f exceeds the balance %.2f", analysis.RequiredMargin, balance)
+	}
+	return analysis, nil
+}
+
+func (a *GridAnalysis) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s, balance %.2f, start price %.6f, leverage %.1f\n", a.Strategy, a.Balance, a.StartPrice, a.Leverage)
+	fmt.Fprintf(&sb, "%5s %14s %14s %14s %14s %14s %14s %14s\n", "grid", "price", "size", "notional", "avg entry", "take profit", "margin", "liquidation")
+	for _, l := range a.Levels {
+		fmt.Fprintf(&sb, "%5d %14.6f %14.6f %14.2f %14.6f %14.6f %14.2f %14.6f\n", l.Grid, l.Price, l.Size, l.CumulativeNotional, l.AverageEntry, l.TakeProfit, l.RequiredMargin, l.LiquidationPrice)
+	}
+	fmt.Fprintf(&sb, "total notional %.2f, required margin %.2f, grid exhausted after %.2f%%, liquidation at %.6f (%.2f%%)", a.TotalNotional, a.RequiredMargin, a.ExhaustMovePerc, a.LiquidationPrice, a.LiquidationMovePerc)
+	if !a.Feasible {
+		fmt.Fprintf(&sb, "\nNOT FEASIBLE: %s", a.Reason)
+	}
+	return sb.String()
+}
+
+// PRIVATE FUNCTIONS
+// liquidationPrice is the price at which the loss of the position equals the balance
+func liquidationPrice(positionSide engine.PositionSideType, averageEntry float64, size float64, balance float64) float64 {
+	if positionSide == engine.PositionSideLong {
+		return math.Max(averageEntry-balance/size, 0)
+	}
+	return averageEntry + balance/size
+}
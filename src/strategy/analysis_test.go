@@ -0,0 +1,140 @@
+package strategy
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"example.com/gobot-simulator/src/engine"
+)
+
+func TestAnalyzeGrid(t *testing.T) {
+	long := StrategyParameters{GO: 2, GS: 10, SF: 1, OS: 10, OF: 2, TS: 10}
+	short := StrategyParameters{GO: 2, GS: 10, SF: 10, OS: 100, OF: 1, TS: 10}
+	tests := []struct {
+		name        string
+		strategy    StrategyWrapper
+		leverage    float64
+		prices      []float64
+		takeProfits []float64
+		notional    float64
+		feasible    bool
+		reason      string
+	}{
+		{
+			name:        "long grid fits the balance",
+			strategy:    NewStrategyMartingala("DOGE", engine.PositionSideLong, long),
+			leverage:    1,
+			prices:      []float64{100, 81, 72},
+			takeProfits: []float64{110, 99.55, 89.375},
+			notional:    325,
+			feasible:    true,
+		},
+		{
+			name:        "margin above the balance",
+			strategy:    NewStrategyMartingala("DOGE", engine.PositionSideLong, long),
+			leverage:    0.25,
+			prices:      []float64{100, 81, 72},
+			takeProfits: []float64{110, 99.55, 89.375},
+			notional:    325,
+			feasible:    false,
+			reason:      "required margin 1300.00 exceeds the balance 1000.00",
+		},
+		{
+			name:        "short liquidated before the last grid",
+			strategy:    NewStrategyMartingala("DOGE", engine.PositionSideShort, short),
+			leverage:    10,
+			prices:      []float64{100, 121, 231},
+			takeProfits: []float64{90, 99.45, 135.6},
+			notional:    1000 + 1210 + 2310,
+			feasible:    false,
+			reason:      "liquidated at 160.500000 before grid 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis, err := AnalyzeGrid(tt.strategy, 1000, 100, tt.leverage)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(analysis.Levels) != len(tt.prices) {
+				t.Fatalf("got %d levels, want %d", len(analysis.Levels), len(tt.prices))
+			}
+			for i, level := range analysis.Levels {
+				if level.Grid != int64(i) || math.Abs(level.Price-tt.prices[i]) > 1e-9 || math.Abs(level.TakeProfit-tt.takeProfits[i]) > 1e-9 {
+					t.Errorf("level %d: got grid %d, price %g, take profit %g, want price %g, take profit %g",
+						i, level.Grid, level.Price, level.TakeProfit, tt.prices[i], tt.takeProfits[i])
+				}
+			}
+			if math.Abs(analysis.TotalNotional-tt.notional) > 1e-9 {
+				t.Errorf("got total notional %g, want %g", analysis.TotalNotional, tt.notional)
+			}
+			if analysis.Feasible != tt.feasible || !strings.HasPrefix(analysis.Reason, tt.reason) {
+				t.Errorf("got feasible %t (%s), want %t (%s)", analysis.Feasible, analysis.Reason, tt.feasible, tt.reason)
+			}
+		})
+	}
+}
+
+func TestAnalyzeGridErrors(t *testing.T) {
+	pars, _ := DefaultParameters(StrategyTypeMartingala)
+	gridPars, _ := DefaultParameters(StrategyTypeNeutralGrid)
+	tests := []struct {
+		name     string
+		strategy StrategyWrapper
+		balance  float64
+		price    float64
+		leverage float64
+	}{
+		{"strategy without grid", NewStrategyNeutralGrid("DOGE", engine.PositionSideLong, gridPars), 1000, 100, 1},
+		{"zero balance", NewStrategyMartingala("DOGE", engine.PositionSideLong, pars), 0, 100, 1},
+		{"zero price", NewStrategyMartingala("DOGE", engine.PositionSideLong, pars), 1000, 0, 1},
+		{"zero leverage", NewStrategyMartingala("DOGE", engine.PositionSideLong, pars), 1000, 100, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := AnalyzeGrid(tt.strategy, tt.balance, tt.price, tt.leverage); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+// recordingStrategy records the positions given to TakeProfitOrder
+type recordingStrategy struct {
+	*StrategyMartingala
+	positions []engine.Position
+}
+
+func (s *recordingStrategy) TakeProfitOrder(position engine.Position, currentGrid int64) *engine.Order {
+	s.positions = append(s.positions, position)
+	return s.StrategyMartingala.TakeProfitOrder(position, currentGrid)
+}
+
+func TestAnalyzeGridPositions(t *testing.T) {
+	pars := StrategyParameters{GO: 2, GS: 10, SF: 1, OS: 10, OF: 2, TS: 10}
+	tests := []struct {
+		name         string
+		positionSide engine.PositionSideType
+	}{
+		{"long", engine.PositionSideLong},
+		{"short with the absolute size", engine.PositionSideShort},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &recordingStrategy{StrategyMartingala: NewStrategyMartingala("DOGE", tt.positionSide, pars)}
+			analysis, err := AnalyzeGrid(s, 1000, 100, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(s.positions) != len(analysis.Levels) {
+				t.Fatalf("got %d take profits, want %d", len(s.positions), len(analysis.Levels))
+			}
+			for i, position := range s.positions {
+				if position.PositionSide != tt.positionSide || position.Size != analysis.Levels[i].CumulativeSize || position.Size <= 0 {
+					t.Errorf("level %d: got %s size %g, want %s size %g", i, position.PositionSide, position.Size, tt.positionSide, analysis.Levels[i].CumulativeSize)
+				}
+			}
+		})
+	}
+}
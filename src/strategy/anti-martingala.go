@@ -4,15 +4,49 @@ import (
 	"math"
 	"time"
 
+	"example.com/gobot-simulator/src/common"
 	"example.com/gobot-simulator/src/engine"
 )
 
+// AntiMartingalaParameters are the parameters of the AntiMartingala
+type AntiMartingalaParameters struct {
+	GO                 uint    `json:"GO" yaml:"GO"`
+	GS                 float64 `json:"GS" yaml:"GS"`
+	SF                 float64 `json:"SF" yaml:"SF"`
+	OS                 float64 `json:"OS" yaml:"OS"`
+	OF                 float64 `json:"OF" yaml:"OF"`
+	SL                 float64 `json:"SL" yaml:"SL"`
+	ST                 float64 `json:"ST" yaml:"ST"` // stop loss threshold
+	ReanchorParameters `yaml:",inline"`
+}
+
 type StrategyAntiMartingala struct {
-	Type         StrategyType            `json:"type"`
-	Symbol       string                  `json:"symbol"`
-	PositionSide engine.PositionSideType `json:"position_side"`
-	Status       string                  `json:"status"`
-	Parameters   StrategyParameters      `json:"parameters"`
+	Type         StrategyType             `json:"type" yaml:"type"`
+	Symbol       string                   `json:"symbol" yaml:"symbol"`
+	PositionSide engine.PositionSideType  `json:"positionSide" yaml:"positionSide"`
+	Status       string                   `json:"status" yaml:"status"`
+	Parameters   AntiMartingalaParameters `json:"parameters" yaml:"parameters"`
+
+	gridCycle GridCycle
+}
+
+func init() {
+	Register(Registration{
+		Type: StrategyTypeAntiMartingala,
+		New: func(symbol string, positionSide engine.PositionSideType, pars StrategyParameters) StrategyWrapper {
+			return NewStrategyAntiMartingala(symbol, positionSide, pars)
+		},
+		Set: AntiMartingalaParameters{},
+		Parameters: append([]ParameterSpec{
+			intParameter("GO", 1, 100, 5),
+			floatParameter("GS", 0.01, 50, 0.3),
+			floatParameter("SF", 0.1, 10, 1.5),
+			floatParameter("OS", 0.01, 100, 1),
+			floatParameter("OF", 0.1, 10, 1),
+			floatParameter("SL", 0.01, 100, 0.3),
+			floatParameter("ST", 0, 100, 0.1),
+		}, reanchorParameters()...),
+	})
 }
 
 const (
@@ -68,7 +102,7 @@ func (s *StrategyAntiMartingala) SellGridOrders(balance float64, startPrice floa
 func (s *StrategyAntiMartingala) TakeProfitOrder(position engine.Position, currentGrid int64) *engine.Order {
 	markPrice := position.MarkPrice
 	entryPrice := position.EntryPrice
-	stopLossThreshold := 0.1
+	stopLossThreshold := s.Parameters.ST
 
 	if s.PositionSide == engine.PositionSideLong {
 		isStopLoss := entryPrice*(1+stopLossThreshold/100) >= markPrice
@@ -104,9 +138,22 @@ func (s *StrategyAntiMartingala) TakeProfitOrder(position engine.Position, curre
 }
 
 func (s *StrategyAntiMartingala) String() string {
-	return string(s.GetType()) + " " + string(s.GetPositionSide()) + " " + s.Parameters.String()
+	return string(s.GetType()) + " " + string(s.GetPositionSide()) + " " + FormatParameters(s)
 }
 
+// EVENTS
+func (s *StrategyAntiMartingala) OnStart(ctx Context) { startGridCycle(ctx, s) }
+
+func (s *StrategyAntiMartingala) OnTick(ctx Context, tick common.SymbolDataItem) {
+	handleGridTick(ctx, s, tick)
+}
+
+func (s *StrategyAntiMartingala) OnFill(ctx Context, fill engine.Fill) { handleGridFill(ctx, s, fill) }
+
+func (s *StrategyAntiMartingala) OnCancel(ctx Context, order engine.Order) {}
+
+func (s *StrategyAntiMartingala) OnTimer(ctx Context, name string) { handleGridTimer(ctx, s, name) }
+
 // GETTERS
 func (s *StrategyAntiMartingala) GetType() StrategyType { return s.Type }
 
@@ -116,7 +163,13 @@ func (s *StrategyAntiMartingala) GetPositionSide() engine.PositionSideType { ret
 
 func (s *StrategyAntiMartingala) GetStatus() string { return s.Status }
 
-func (s *StrategyAntiMartingala) GetParameters() StrategyParameters { return s.Parameters }
+func (s *StrategyAntiMartingala) GetParameters() StrategyParameters {
+	return toStrategyParameters(s.Parameters)
+}
+
+func (s *StrategyAntiMartingala) GetGridCycle() *GridCycle { return &s.gridCycle }
+
+func (s *StrategyAntiMartingala) EntryAtMarket() bool { return true }
 
 // SETTERS
 func (s *StrategyAntiMartingala) SetSymbol(value string) { s.Symbol = value }
@@ -127,7 +180,9 @@ func (s *StrategyAntiMartingala) SetPositionSide(value engine.PositionSideType)
 
 func (s *StrategyAntiMartingala) SetStatus(value string) { s.Status = value }
 
-func (s *StrategyAntiMartingala) SetParameters(value StrategyParameters) { s.Parameters = value }
+func (s *StrategyAntiMartingala) SetParameters(value StrategyParameters) {
+	s.Parameters = NewAntiMartingalaParameters(value)
+}
 
 func NewStrategyAntiMartingala(symbol string, positionSide engine.PositionSideType, pars StrategyParameters) *StrategyAntiMartingala {
 	return &StrategyAntiMartingala{
@@ -135,6 +190,13 @@ func NewStrategyAntiMartingala(symbol string, positionSide engine.PositionSideTy
 		Symbol:       symbol,
 		PositionSide: positionSide,
 		Status:       "",
-		Parameters:   pars,
+		Parameters:   NewAntiMartingalaParameters(pars),
 	}
 }
+
+// NewAntiMartingalaParameters takes the parameters of the set from pars
+func NewAntiMartingalaParameters(pars StrategyParameters) AntiMartingalaParameters {
+	set := AntiMartingalaParameters{}
+	copyParameters(&set, pars)
+	return set
+}
@@ -0,0 +1,40 @@
+package strategy
+
+import (
+	"time"
+
+	"example.com/gobot-simulator/src/common"
+	"example.com/gobot-simulator/src/engine"
+	"example.com/gobot-simulator/src/indicator"
+)
+
+// Context is the view of the account given to a strategy when it handles an
+// event, together with the order management functions.
+type Context interface {
+	Symbol() string
+	PositionSide() engine.PositionSideType // of the strategy
+	Time() time.Time
+	MarkPrice() float64
+	Balance() float64 // wallet balance of the account
+	Capital() float64 // capital the orders are sized from, see Sizing
+	Position(positionSide engine.PositionSideType) engine.Position
+	GridReached(positionSide engine.PositionSideType) int64
+	OpenOrders(positionSide engine.PositionSideType) []engine.Order
+	PlaceOrder(order engine.Order)
+	CancelOrder(order engine.Order) bool
+	SetTimer(name string, at time.Time) // OnTimer is called with name at the first tick after at
+	CancelTimer(name string)
+	LogEvent(eventType string, message string) // recorded in the simulation results
+	Indicators() *indicator.Manager            // updated with every tick before OnTick
+	StartCycle() bool                          // false if the entry conditions do not pass: stay flat and ask again later
+}
+
+// EventHandler is implemented by every strategy: the worker only forwards the
+// events and lets the strategy manage its orders through the context.
+type EventHandler interface {
+	OnStart(ctx Context)
+	OnTick(ctx Context, tick common.SymbolDataItem)
+	OnFill(ctx Context, fill engine.Fill)
+	OnCancel(ctx Context, order engine.Order) // called for every order successfully cancelled
+	OnTimer(ctx Context, name string)
+}
@@ -0,0 +1,188 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"example.com/gobot-simulator/src/common"
+	"example.com/gobot-simulator/src/engine"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// GridStrategy is a strategy of the martingala family, defined by its grid and
+// take profit orders.
+type GridStrategy interface {
+	StrategyWrapper
+	BuyGridOrders(balance float64, startPrice float64) []*engine.Order
+	SellGridOrders(balance float64, startPrice float64) []*engine.Order
+	TakeProfitOrder(position engine.Position, currentGrid int64) *engine.Order
+	EntryAtMarket() bool // false if the position is opened by the grid orders only
+	GetGridCycle() *GridCycle
+}
+
+const reanchorTimer = "reanchor"
+
+// ReanchorParameters re-anchor the grid while the position is flat (0 disables
+// the rule), shared by the strategies using the grid lifecycle
+type ReanchorParameters struct {
+	RT float64 `json:"RT,omitempty" yaml:"RT,omitempty"` // recreate the grid after RT minutes without position
+	RD float64 `json:"RD,omitempty" yaml:"RD,omitempty"` // recreate the grid when the price drifts RD% from the grid anchor
+	RE float64 `json:"RE,omitempty" yaml:"RE,omitempty"` // recreate the grid every RE minutes (clock aligned)
+}
+
+// GridCycle is the state of the current grid, used to re-anchor it
+type GridCycle struct {
+	AnchorPrice float64
+	CreatedAt   time.Time
+	Idle        bool // waiting for the entry conditions
+}
+
+// cycleStarter is implemented by the grid strategies keeping a state for the
+// duration of a cycle, set when its grid is placed
+type cycleStarter interface {
+	startCycle()
+}
+
+// startGridCycle starts a new grid if the entry conditions pass, otherwise the
+// strategy stays flat and handleGridTick tries again at every tick.
+func startGridCycle(ctx Context, s GridStrategy) {
+	cycle := s.GetGridCycle()
+	if !ctx.StartCycle() {
+		cancelOrders(ctx, s.GetPositionSide(), false)
+		cycle.Idle = true
+		return
+	}
+	cycle.Idle = false
+	placeGridCycle(ctx, s)
+}
+
+// placeGridCycle places the grid orders and, if the strategy enters at market,
+// opens the position.
+func placeGridCycle(ctx Context, s GridStrategy) {
+	log.Debug("Strategy: start grid cycle")
+	cycle := s.GetGridCycle()
+	cycle.AnchorPrice = ctx.MarkPrice()
+	cycle.CreatedAt = ctx.Time()
+	if s.GetParameters().RE > 0 {
+		period := time.Duration(s.GetParameters().RE * float64(time.Minute))
+		ctx.SetTimer(reanchorTimer, ctx.Time().Truncate(period).Add(period))
+	}
+
+	symbol := s.GetSymbol()
+	balance := ctx.Capital()
+	markPrice := ctx.MarkPrice()
+	s0 := (balance / markPrice) * (s.GetParameters().OS / 100)
+	if math.IsNaN(s0) {
+		log.Panic("Order size is NaN")
+	}
+
+	positionSide := s.GetPositionSide()
+	if starter, ok := s.(cycleStarter); ok {
+		starter.startCycle()
+	}
+	createGrid(ctx, s, positionSide, balance, markPrice)
+	if !s.EntryAtMarket() {
+		return
+	}
+
+	var order engine.Order
+	if positionSide == engine.PositionSideLong {
+		order = *engine.NewOrderMarket(symbol, engine.SideBuy, engine.PositionSideLong, s0)
+	} else {
+		order = *engine.NewOrderMarket(symbol, engine.SideSell, engine.PositionSideShort, s0)
+	}
+	ctx.PlaceOrder(order)
+}
+
+// handleGridFill restarts the cycle when the position is closed, otherwise
+// moves the take profit according to the new position.
+func handleGridFill(ctx Context, s GridStrategy, fill engine.Fill) {
+	if fill.Position.Size == 0 {
+		startGridCycle(ctx, s)
+	} else {
+		setTakeProfit(ctx, s, fill.Position)
+	}
+}
+
+// handleGridTick starts the waiting cycle once the entry conditions pass, or
+// re-anchors the grid if the position is still flat and one of the re-anchoring
+// rules of the parameters applies.
+func handleGridTick(ctx Context, s GridStrategy, tick common.SymbolDataItem) {
+	if cycle := s.GetGridCycle(); cycle.Idle {
+		if ctx.StartCycle() {
+			cycle.Idle = false
+			placeGridCycle(ctx, s)
+		}
+		return
+	}
+
+	pars := s.GetParameters()
+	if pars.RT == 0 && pars.RD == 0 {
+		return
+	}
+	if ctx.Position(s.GetPositionSide()).Size != 0 {
+		return
+	}
+
+	cycle := s.GetGridCycle()
+	if pars.RT > 0 && tick.Time.Sub(cycle.CreatedAt).Minutes() >= pars.RT {
+		reanchorGrid(ctx, s, fmt.Sprintf("no position after %.0f minutes", pars.RT))
+	} else if drift := math.Abs(tick.Price/cycle.AnchorPrice-1) * 100; pars.RD > 0 && drift >= pars.RD {
+		reanchorGrid(ctx, s, fmt.Sprintf("price drifted %.2f%% from %.6f", drift, cycle.AnchorPrice))
+	}
+}
+
+// handleGridTimer re-anchors the grid on schedule if the position is still flat
+func handleGridTimer(ctx Context, s GridStrategy, name string) {
+	if name != reanchorTimer || s.GetGridCycle().Idle {
+		return
+	}
+	if ctx.Position(s.GetPositionSide()).Size != 0 {
+		// check again at the next scheduled time
+		period := time.Duration(s.GetParameters().RE * float64(time.Minute))
+		ctx.SetTimer(reanchorTimer, ctx.Time().Truncate(period).Add(period))
+		return
+	}
+	reanchorGrid(ctx, s, "scheduled")
+}
+
+func reanchorGrid(ctx Context, s GridStrategy, reason string) {
+	log.Debugf("Strategy: re-anchor grid, %s", reason)
+	ctx.LogEvent(common.EventGridReanchor, fmt.Sprintf("%s grid re-anchored at %.6f: %s", s.GetPositionSide(), ctx.MarkPrice(), reason))
+	placeGridCycle(ctx, s)
+}
+
+func createGrid(ctx Context, s GridStrategy, positionSide engine.PositionSideType, balance float64, startPrice float64) {
+	cancelOrders(ctx, positionSide, false)
+
+	var orders []*engine.Order
+	if positionSide == engine.PositionSideLong {
+		orders = s.BuyGridOrders(balance, startPrice)
+	} else {
+		orders = s.SellGridOrders(balance, startPrice)
+	}
+
+	for _, order := range orders {
+		ctx.PlaceOrder(*order)
+	}
+}
+
+func setTakeProfit(ctx Context, s GridStrategy, position engine.Position) {
+	cancelOrders(ctx, position.PositionSide, true)
+	gridReached := ctx.GridReached(position.PositionSide)
+	order := s.TakeProfitOrder(position, gridReached)
+	log.Debugf("Strategy: set take profit order %s", order.String())
+	ctx.PlaceOrder(*order)
+}
+
+// cancelOrders cancels the open orders of the position side, only the take
+// profit ones if onlyTP is set.
+func cancelOrders(ctx Context, positionSide engine.PositionSideType, onlyTP bool) {
+	for _, order := range ctx.OpenOrders(positionSide) {
+		if !onlyTP || order.IsTP {
+			ctx.CancelOrder(order)
+		}
+	}
+}
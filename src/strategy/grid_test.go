@@ -0,0 +1,116 @@
+package strategy
+
+import (
+	"testing"
+	"time"
+
+	"example.com/gobot-simulator/src/common"
+	"example.com/gobot-simulator/src/engine"
+)
+
+var gridStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// gridContext implements the part of Context the grid lifecycle uses, the
+// orders being kept without ever filling
+type gridContext struct {
+	Context
+	now      time.Time
+	price    float64
+	position engine.Position
+	placed   []engine.Order
+	timers   map[string]time.Time
+	events   []common.SimulatorEvent
+}
+
+func newGridContext() *gridContext {
+	return &gridContext{now: gridStart, price: 100, timers: make(map[string]time.Time)}
+}
+
+func (c *gridContext) Time() time.Time    { return c.now }
+func (c *gridContext) MarkPrice() float64 { return c.price }
+func (c *gridContext) Capital() float64   { return 1000 }
+func (c *gridContext) Position(positionSide engine.PositionSideType) engine.Position {
+	return c.position
+}
+func (c *gridContext) OpenOrders(positionSide engine.PositionSideType) []engine.Order { return nil }
+func (c *gridContext) PlaceOrder(order engine.Order)                                  { c.placed = append(c.placed, order) }
+func (c *gridContext) SetTimer(name string, at time.Time)                             { c.timers[name] = at }
+func (c *gridContext) StartCycle() bool                                               { return true }
+func (c *gridContext) LogEvent(eventType string, message string) {
+	c.events = append(c.events, common.SimulatorEvent{Type: eventType, Message: message})
+}
+
+// stopEntryStrategy opens its position with the grid orders only
+type stopEntryStrategy struct {
+	*StrategyAntiMartingala
+}
+
+func (s stopEntryStrategy) EntryAtMarket() bool { return false }
+
+func TestGridReanchor(t *testing.T) {
+	tests := []struct {
+		name     string
+		pars     ReanchorParameters
+		minutes  int
+		price    func(minute int) float64
+		position float64
+		want     int
+		anchor   float64
+	}{
+		{"disabled", ReanchorParameters{}, 180, flatPrice, 0, 0, 100},
+		{"after RT minutes flat", ReanchorParameters{RT: 30}, 90, flatPrice, 0, 3, 100},
+		{"price drifting RD% from the anchor", ReanchorParameters{RD: 1}, 30, risingPrice, 0, 1, 101},
+		{"every RE minutes", ReanchorParameters{RE: 60}, 180, flatPrice, 0, 3, 100},
+		{"RT with an open position", ReanchorParameters{RT: 30}, 90, flatPrice, 1, 0, 100},
+		{"RD with an open position", ReanchorParameters{RD: 1}, 30, risingPrice, 1, 0, 100},
+		{"RE with an open position", ReanchorParameters{RE: 60}, 180, flatPrice, 1, 0, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pars, _ := DefaultParameters(StrategyTypeAntiMartingala)
+			pars.RT, pars.RD, pars.RE = tt.pars.RT, tt.pars.RD, tt.pars.RE
+			s := stopEntryStrategy{NewStrategyAntiMartingala("TEST", engine.PositionSideLong, pars)}
+			ctx := newGridContext()
+			startGridCycle(ctx, s)
+			ctx.position = engine.Position{PositionSide: engine.PositionSideLong, Size: tt.position}
+
+			for minute := 1; minute <= tt.minutes; minute++ {
+				ctx.now = gridStart.Add(time.Duration(minute) * time.Minute)
+				ctx.price = tt.price(minute)
+				handleGridTick(ctx, s, common.SymbolDataItem{Time: ctx.now, Price: ctx.price})
+				if at, ok := ctx.timers[reanchorTimer]; ok && !ctx.now.Before(at) {
+					delete(ctx.timers, reanchorTimer)
+					handleGridTimer(ctx, s, reanchorTimer)
+				}
+			}
+			if len(ctx.events) != tt.want {
+				t.Fatalf("got %d re-anchors %v, want %d", len(ctx.events), ctx.events, tt.want)
+			}
+			for _, event := range ctx.events {
+				if event.Type != common.EventGridReanchor {
+					t.Errorf("got event %s, want %s", event.Type, common.EventGridReanchor)
+				}
+			}
+			if grids := len(ctx.placed) / int(pars.GO); grids != tt.want+1 || ctx.placed[0].Type != engine.OrderTypeStop {
+				t.Errorf("got %d stop grids placed, want %d", grids, tt.want+1)
+			}
+			if anchor := s.GetGridCycle().AnchorPrice; anchor != tt.anchor {
+				t.Errorf("got grid anchored at %g, want %g", anchor, tt.anchor)
+			}
+		})
+	}
+}
+
+func flatPrice(minute int) float64 { return 100 }
+
+// risingPrice drifts 1% from 100 after 10 minutes, then 0.5% more
+func risingPrice(minute int) float64 {
+	switch {
+	case minute < 10:
+		return 100
+	case minute < 20:
+		return 101
+	default:
+		return 101.5
+	}
+}
@@ -3,15 +3,36 @@ package strategy
 import (
 	"math"
 
+	"example.com/gobot-simulator/src/common"
 	"example.com/gobot-simulator/src/engine"
 )
 
 type StrategyLogMartingala struct {
-	Type         StrategyType            `json:"type"`
-	Symbol       string                  `json:"symbol"`
-	PositionSide engine.PositionSideType `json:"positionSide"`
-	Status       string                  `json:"status"`
-	Parameters   StrategyParameters      `json:"parameters"`
+	Type         StrategyType            `json:"type" yaml:"type"`
+	Symbol       string                  `json:"symbol" yaml:"symbol"`
+	PositionSide engine.PositionSideType `json:"positionSide" yaml:"positionSide"`
+	Status       string                  `json:"status" yaml:"status"`
+	Parameters   MartingalaParameters    `json:"parameters" yaml:"parameters"`
+
+	gridCycle GridCycle
+}
+
+func init() {
+	Register(Registration{
+		Type: StrategyTypeLogMartingala,
+		New: func(symbol string, positionSide engine.PositionSideType, pars StrategyParameters) StrategyWrapper {
+			return NewStrategyLogMartingala(symbol, positionSide, pars)
+		},
+		Set: MartingalaParameters{},
+		Parameters: append([]ParameterSpec{
+			intParameter("GO", 1, 100, 5),
+			floatParameter("GS", 0.01, 50, 0.3),
+			floatParameter("SF", 0.1, 10, 1.5),
+			floatParameter("OS", 0.01, 100, 1),
+			floatParameter("OF", 1, 10, 2),
+			floatParameter("TS", 0.01, 100, 0.3),
+		}, reanchorParameters()...),
+	})
 }
 
 func (s *StrategyLogMartingala) BuyGridOrders(balance float64, startPrice float64) []*engine.Order {
@@ -75,9 +96,22 @@ func (s *StrategyLogMartingala) TakeProfitOrder(position engine.Position, curren
 }
 
 func (s *StrategyLogMartingala) String() string {
-	return string(s.GetType()) + " " + string(s.GetPositionSide()) + " " + s.Parameters.String()
+	return string(s.GetType()) + " " + string(s.GetPositionSide()) + " " + FormatParameters(s)
 }
 
+// EVENTS
+func (s *StrategyLogMartingala) OnStart(ctx Context) { startGridCycle(ctx, s) }
+
+func (s *StrategyLogMartingala) OnTick(ctx Context, tick common.SymbolDataItem) {
+	handleGridTick(ctx, s, tick)
+}
+
+func (s *StrategyLogMartingala) OnFill(ctx Context, fill engine.Fill) { handleGridFill(ctx, s, fill) }
+
+func (s *StrategyLogMartingala) OnCancel(ctx Context, order engine.Order) {}
+
+func (s *StrategyLogMartingala) OnTimer(ctx Context, name string) { handleGridTimer(ctx, s, name) }
+
 // GETTERS
 func (s *StrategyLogMartingala) GetType() StrategyType { return s.Type }
 
@@ -87,7 +121,13 @@ func (s *StrategyLogMartingala) GetPositionSide() engine.PositionSideType { retu
 
 func (s *StrategyLogMartingala) GetStatus() string { return s.Status }
 
-func (s *StrategyLogMartingala) GetParameters() StrategyParameters { return s.Parameters }
+func (s *StrategyLogMartingala) GetParameters() StrategyParameters {
+	return toStrategyParameters(s.Parameters)
+}
+
+func (s *StrategyLogMartingala) GetGridCycle() *GridCycle { return &s.gridCycle }
+
+func (s *StrategyLogMartingala) EntryAtMarket() bool { return true }
 
 // SETTERS
 func (s *StrategyLogMartingala) SetSymbol(value string) { s.Symbol = value }
@@ -98,7 +138,9 @@ func (s *StrategyLogMartingala) SetPositionSide(value engine.PositionSideType) {
 
 func (s *StrategyLogMartingala) SetStatus(value string) { s.Status = value }
 
-func (s *StrategyLogMartingala) SetParameters(value StrategyParameters) { s.Parameters = value }
+func (s *StrategyLogMartingala) SetParameters(value StrategyParameters) {
+	s.Parameters = NewMartingalaParameters(value)
+}
 
 func NewStrategyLogMartingala(symbol string, positionSide engine.PositionSideType, pars StrategyParameters) *StrategyLogMartingala {
 	return &StrategyLogMartingala{
@@ -106,6 +148,6 @@ func NewStrategyLogMartingala(symbol string, positionSide engine.PositionSideTyp
 		Symbol:       symbol,
 		PositionSide: positionSide,
 		Status:       "",
-		Parameters:   pars,
+		Parameters:   NewMartingalaParameters(pars),
 	}
 }
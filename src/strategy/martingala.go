@@ -3,37 +3,149 @@ package strategy
 import (
 	"math"
 
+	"example.com/gobot-simulator/src/common"
 	"example.com/gobot-simulator/src/engine"
 )
 
+// MartingalaParameters are the parameters of the Martingala and LogMartingala
+type MartingalaParameters struct {
+	GO                 uint    `json:"GO" yaml:"GO"`
+	GS                 float64 `json:"GS" yaml:"GS"`
+	SF                 float64 `json:"SF" yaml:"SF"`
+	OS                 float64 `json:"OS" yaml:"OS"`
+	OF                 float64 `json:"OF" yaml:"OF"`
+	TS                 float64 `json:"TS" yaml:"TS"`
+	ReanchorParameters `yaml:",inline"`
+}
+
 type StrategyMartingala struct {
-	Type         StrategyType            `json:"type"`
-	Symbol       string                  `json:"symbol"`
-	PositionSide engine.PositionSideType `json:"positionSide"`
-	Status       string                  `json:"status"`
-	Parameters   StrategyParameters      `json:"parameters"`
+	Type         StrategyType            `json:"type" yaml:"type"`
+	Symbol       string                  `json:"symbol" yaml:"symbol"`
+	PositionSide engine.PositionSideType `json:"positionSide" yaml:"positionSide"`
+	Status       string                  `json:"status" yaml:"status"`
+	Parameters   MartingalaParameters    `json:"parameters" yaml:"parameters"`
+
+	gridCycle GridCycle
+}
+
+func init() {
+	Register(Registration{
+		Type: StrategyTypeMartingala,
+		New: func(symbol string, positionSide engine.PositionSideType, pars StrategyParameters) StrategyWrapper {
+			return NewStrategyMartingala(symbol, positionSide, pars)
+		},
+		Set: MartingalaParameters{},
+		Parameters: append([]ParameterSpec{
+			intParameter("GO", 1, 100, 5),
+			floatParameter("GS", 0.01, 50, 0.3),
+			floatParameter("SF", 0.1, 10, 1.5),
+			floatParameter("OS", 0.01, 100, 1),
+			floatParameter("OF", 1, 10, 2),
+			floatParameter("TS", 0.01, 100, 0.3),
+		}, reanchorParameters()...),
+	})
 }
 
 func (s *StrategyMartingala) BuyGridOrders(balance float64, startPrice float64) []*engine.Order {
+	return martingalaBuyOrders(s.Symbol, s.Parameters, balance, startPrice)
+}
+
+func (s *StrategyMartingala) SellGridOrders(balance float64, startPrice float64) []*engine.Order {
+	return martingalaSellOrders(s.Symbol, s.Parameters, balance, startPrice)
+}
+
+func (s *StrategyMartingala) TakeProfitOrder(position engine.Position, currentGrid int64) *engine.Order {
+	return martingalaTakeProfit(s.Symbol, s.PositionSide, s.Parameters, position)
+}
+
+func (s *StrategyMartingala) String() string {
+	return string(s.GetType()) + " " + string(s.GetPositionSide()) + " " + FormatParameters(s)
+}
+
+// EVENTS
+func (s *StrategyMartingala) OnStart(ctx Context) { startGridCycle(ctx, s) }
+
+func (s *StrategyMartingala) OnTick(ctx Context, tick common.SymbolDataItem) {
+	handleGridTick(ctx, s, tick)
+}
+
+func (s *StrategyMartingala) OnFill(ctx Context, fill engine.Fill) { handleGridFill(ctx, s, fill) }
+
+func (s *StrategyMartingala) OnCancel(ctx Context, order engine.Order) {}
+
+func (s *StrategyMartingala) OnTimer(ctx Context, name string) { handleGridTimer(ctx, s, name) }
+
+// GETTERS
+func (s *StrategyMartingala) GetType() StrategyType { return s.Type }
+
+func (s *StrategyMartingala) GetSymbol() string { return s.Symbol }
+
+func (s *StrategyMartingala) GetPositionSide() engine.PositionSideType { return s.PositionSide }
+
+func (s *StrategyMartingala) GetStatus() string { return s.Status }
+
+func (s *StrategyMartingala) GetParameters() StrategyParameters {
+	return toStrategyParameters(s.Parameters)
+}
+
+func (s *StrategyMartingala) GetGridCycle() *GridCycle { return &s.gridCycle }
+
+func (s *StrategyMartingala) EntryAtMarket() bool { return true }
+
+// SETTERS
+func (s *StrategyMartingala) SetSymbol(symbol string) { s.Symbol = symbol }
+
+func (s *StrategyMartingala) SetPositionSide(positionSide engine.PositionSideType) {
+	s.PositionSide = positionSide
+}
+
+func (s *StrategyMartingala) SetStatus(status string) { s.Status = status }
+
+func (s *StrategyMartingala) SetParameters(pars StrategyParameters) {
+	s.Parameters = NewMartingalaParameters(pars)
+}
+
+func NewStrategyMartingala(symbol string, positionSide engine.PositionSideType, pars StrategyParameters) *StrategyMartingala {
+	strat := &StrategyMartingala{
+		Type:         StrategyTypeMartingala,
+		Symbol:       symbol,
+		PositionSide: positionSide,
+		Status:       "",
+		Parameters:   NewMartingalaParameters(pars),
+	}
+	return strat
+}
+
+// NewMartingalaParameters takes the parameters of the set from pars
+func NewMartingalaParameters(pars StrategyParameters) MartingalaParameters {
+	set := MartingalaParameters{}
+	copyParameters(&set, pars)
+	return set
+}
+
+// GRID GENERATION
+// martingalaBuyOrders returns the long grid of the Martingala, also used by the
+// variants changing only GS and TS.
+func martingalaBuyOrders(symbol string, pars MartingalaParameters, balance float64, startPrice float64) []*engine.Order {
 	orders := []*engine.Order{}
 
 	// start price and size
-	p0 := startPrice * (1 - s.Parameters.GS/100)
-	s0 := (balance / startPrice) * (s.Parameters.OS / 100)
+	p0 := startPrice * (1 - pars.GS/100)
+	s0 := (balance / startPrice) * (pars.OS / 100)
 
 	// first grid
-	p_1 := p0 * (1 - s.Parameters.GS/100)
+	p_1 := p0 * (1 - pars.GS/100)
 	s_1 := s0
 	p_2 := p0
-	order := engine.NewOrderLimit(s.Symbol, engine.SideBuy, engine.PositionSideLong, s_1, p_1)
+	order := engine.NewOrderLimit(symbol, engine.SideBuy, engine.PositionSideLong, s_1, p_1)
 	order.GridNumber = 1
 	orders = append(orders, order)
 
 	// other grids
-	for i := 2; i < int(s.Parameters.GO)+1; i++ {
-		p_i := p_1 - (p_2-p_1)*s.Parameters.SF
-		s_i := s_1 * s.Parameters.OF
-		order := engine.NewOrderLimit(s.Symbol, engine.SideBuy, engine.PositionSideLong, s_i, p_i)
+	for i := 2; i < int(pars.GO)+1; i++ {
+		p_i := p_1 - (p_2-p_1)*pars.SF
+		s_i := s_1 * pars.OF
+		order := engine.NewOrderLimit(symbol, engine.SideBuy, engine.PositionSideLong, s_i, p_i)
 		order.GridNumber = int64(i)
 		orders = append(orders, order)
 		p_2 = p_1
@@ -44,26 +156,26 @@ func (s *StrategyMartingala) BuyGridOrders(balance float64, startPrice float64)
 	return orders
 }
 
-func (s *StrategyMartingala) SellGridOrders(balance float64, startPrice float64) []*engine.Order {
+func martingalaSellOrders(symbol string, pars MartingalaParameters, balance float64, startPrice float64) []*engine.Order {
 	orders := []*engine.Order{}
 
 	// start price and size
-	p0 := startPrice * (1 + s.Parameters.GS/100)
-	s0 := (balance / startPrice) * (s.Parameters.OS / 100)
+	p0 := startPrice * (1 + pars.GS/100)
+	s0 := (balance / startPrice) * (pars.OS / 100)
 
 	// first grid
-	p_1 := p0 * (1 + s.Parameters.GS/100)
+	p_1 := p0 * (1 + pars.GS/100)
 	s_1 := s0
 	p_2 := p0
-	order := engine.NewOrderLimit(s.Symbol, engine.SideSell, engine.PositionSideShort, s_1, p_1)
+	order := engine.NewOrderLimit(symbol, engine.SideSell, engine.PositionSideShort, s_1, p_1)
 	order.GridNumber = 1
 	orders = append(orders, order)
 
 	// other grids
-	for i := 2; i < int(s.Parameters.GO)+1; i++ {
-		p_i := p_1 + (p_1-p_2)*s.Parameters.SF
-		s_i := s_1 * s.Parameters.OF
-		order := engine.NewOrderLimit(s.Symbol, engine.SideSell, engine.PositionSideShort, s_i, p_i)
+	for i := 2; i < int(pars.GO)+1; i++ {
+		p_i := p_1 + (p_1-p_2)*pars.SF
+		s_i := s_1 * pars.OF
+		order := engine.NewOrderLimit(symbol, engine.SideSell, engine.PositionSideShort, s_i, p_i)
 		order.GridNumber = int64(i)
 		orders = append(orders, order)
 		p_2 = p_1
@@ -74,56 +186,19 @@ func (s *StrategyMartingala) SellGridOrders(balance float64, startPrice float64)
 	return orders
 }
 
-func (s *StrategyMartingala) TakeProfitOrder(position engine.Position, currentGrid int64) *engine.Order {
-	switch s.PositionSide {
+func martingalaTakeProfit(symbol string, positionSide engine.PositionSideType, pars MartingalaParameters, position engine.Position) *engine.Order {
+	switch positionSide {
 	case engine.PositionSideLong:
-		takeProfitPrice := position.EntryPrice * (1 + s.Parameters.TS/100)
-		order := engine.NewOrderLimit(s.Symbol, engine.SideSell, engine.PositionSideLong, position.Size, takeProfitPrice)
+		takeProfitPrice := position.EntryPrice * (1 + pars.TS/100)
+		order := engine.NewOrderLimit(symbol, engine.SideSell, engine.PositionSideLong, position.Size, takeProfitPrice)
 		order.IsTP = true
 		return order
 	case engine.PositionSideShort:
-		takeProfitPrice := position.EntryPrice * (1 - s.Parameters.TS/100)
-		order := engine.NewOrderLimit(s.Symbol, engine.SideBuy, engine.PositionSideShort, math.Abs(position.Size), takeProfitPrice)
+		takeProfitPrice := position.EntryPrice * (1 - pars.TS/100)
+		order := engine.NewOrderLimit(symbol, engine.SideBuy, engine.PositionSideShort, math.Abs(position.Size), takeProfitPrice)
 		order.IsTP = true
 		return order
 	default:
 		return nil
 	}
 }
-
-func (s *StrategyMartingala) String() string {
-	return string(s.GetType()) + " " + string(s.GetPositionSide()) + " " + s.Parameters.String()
-}
-
-// GETTERS
-func (s *StrategyMartingala) GetType() StrategyType { return s.Type }
-
-func (s *StrategyMartingala) GetSymbol() string { return s.Symbol }
-
-func (s *StrategyMartingala) GetPositionSide() engine.PositionSideType { return s.PositionSide }
-
-func (s *StrategyMartingala) GetStatus() string { return s.Status }
-
-func (s *StrategyMartingala) GetParameters() StrategyParameters { return s.Parameters }
-
-// SETTERS
-func (s *StrategyMartingala) SetSymbol(symbol string) { s.Symbol = symbol }
-
-func (s *StrategyMartingala) SetPositionSide(positionSide engine.PositionSideType) {
-	s.PositionSide = positionSide
-}
-
-func (s *StrategyMartingala) SetStatus(status string) { s.Status = status }
-
-func (s *StrategyMartingala) SetParameters(pars StrategyParameters) { s.Parameters = pars }
-
-func NewStrategyMartingala(symbol string, positionSide engine.PositionSideType, pars StrategyParameters) *StrategyMartingala {
-	strat := &StrategyMartingala{
-		Type:         StrategyTypeMartingala,
-		Symbol:       symbol,
-		PositionSide: positionSide,
-		Status:       "",
-		Parameters:   pars,
-	}
-	return strat
-}
@@ -0,0 +1,238 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"example.com/gobot-simulator/src/common"
+	"example.com/gobot-simulator/src/engine"
+)
+
+// StrategyNeutralGrid is a neutral grid bot: GN levels between the bounds, with
+// an order at every level but the one closest to the price. The grid starts
+// without inventory: it buys (opening a long) at the levels below the price and
+// sells (opening a short) at the levels above. Every fill is paired with the
+// opposite order one level away, so every crossing of two levels realizes one
+// level of profit on the long or on the short position.
+//
+// The grid trades both position sides of the account, its position side only
+// names the worker driving it.
+// NeutralGridParameters are the parameters of the NeutralGrid
+type NeutralGridParameters struct {
+	GN uint    `json:"GN" yaml:"GN"` // number of grid levels
+	GU float64 `json:"GU" yaml:"GU"` // upper bound, % above the start price
+	GL float64 `json:"GL" yaml:"GL"` // lower bound, % below the start price
+	GM uint    `json:"GM" yaml:"GM"` // levels spacing, 0 arithmetic, 1 geometric
+	OS float64 `json:"OS" yaml:"OS"` // order size, % of the capital
+	GT uint    `json:"GT" yaml:"GT"` // 1 to trail the range when the price leaves it
+}
+
+type StrategyNeutralGrid struct {
+	Type         StrategyType            `json:"type" yaml:"type"`
+	Symbol       string                  `json:"symbol" yaml:"symbol"`
+	PositionSide engine.PositionSideType `json:"positionSide" yaml:"positionSide"`
+	Status       string                  `json:"status" yaml:"status"`
+	Parameters   NeutralGridParameters   `json:"parameters" yaml:"parameters"`
+
+	levels   []float64
+	size     float64 // amount of every order
+	idle     bool    // waiting for the entry conditions
+	creating bool    // cancelling the orders of the previous grid
+}
+
+func init() {
+	Register(Registration{
+		Type: StrategyTypeNeutralGrid,
+		New: func(symbol string, positionSide engine.PositionSideType, pars StrategyParameters) StrategyWrapper {
+			return NewStrategyNeutralGrid(symbol, positionSide, pars)
+		},
+		Set: NeutralGridParameters{},
+		Parameters: []ParameterSpec{
+			intParameter("GN", 2, 500, 10),
+			floatParameter("GU", 0.01, 1000, 5),
+			floatParameter("GL", 0.01, 99, 5),
+			intParameter("GM", 0, 1, 0),
+			floatParameter("OS", 0.01, 100, 5),
+			intParameter("GT", 0, 1, 0),
+		},
+	})
+}
+
+// GridLevels returns the price of the levels, ascending, for the given start price
+func (s *StrategyNeutralGrid) GridLevels(startPrice float64) []float64 {
+	n := int(s.Parameters.GN)
+	lower := startPrice * (1 - s.Parameters.GL/100)
+	upper := startPrice * (1 + s.Parameters.GU/100)
+	levels := make([]float64, n)
+	for i := 0; i < n; i++ {
+		x := float64(i) / float64(n-1)
+		if s.Parameters.GM == 1 {
+			levels[i] = lower * math.Pow(upper/lower, x)
+		} else {
+			levels[i] = lower + (upper-lower)*x
+		}
+		levels[i] = common.RoundFloatWithPrecision(levels[i], 6)
+	}
+	return levels
+}
+
+// TradesBothSides is true: the grid holds a long and a short position
+func (s *StrategyNeutralGrid) TradesBothSides() bool { return true }
+
+func (s *StrategyNeutralGrid) String() string {
+	return string(s.GetType()) + " " + FormatParameters(s)
+}
+
+// EVENTS
+func (s *StrategyNeutralGrid) OnStart(ctx Context) {
+	s.levels = nil
+	s.idle = !ctx.StartCycle()
+	if !s.idle {
+		s.createGrid(ctx)
+	}
+}
+
+func (s *StrategyNeutralGrid) OnTick(ctx Context, tick common.SymbolDataItem) {
+	if s.idle {
+		if ctx.StartCycle() {
+			s.idle = false
+			s.createGrid(ctx)
+		}
+		return
+	}
+	if s.Parameters.GT != 1 || len(s.levels) == 0 {
+		return
+	}
+	lower, upper := s.levels[0], s.levels[len(s.levels)-1]
+	if tick.Price > upper || tick.Price < lower {
+		ctx.LogEvent(common.EventGridTrail, fmt.Sprintf("neutral grid [%.6f, %.6f] moved to %.6f", lower, upper, tick.Price))
+		s.createGrid(ctx)
+	}
+}
+
+func (s *StrategyNeutralGrid) OnFill(ctx Context, fill engine.Fill) {
+	if fill.Order.Type == engine.OrderTypeMarket {
+		return // position closed by createGrid
+	}
+
+	// pair the fill with the opposite order one level away: a buy (long open
+	// or short close) with a sell one level above, a sell with a buy one level below
+	level := int(fill.Order.GridNumber) - 1
+	side := engine.SideBuy
+	if fill.Order.Side == engine.SideBuy {
+		side = engine.SideSell
+		level++
+	} else {
+		level--
+	}
+	if level >= 0 && level < len(s.levels) {
+		s.placeLevel(ctx, level, side, fill.Order.PositionSide)
+	}
+}
+
+// OnCancel drops the grid when one of its orders is cancelled from outside,
+// e.g. by the risk manager: a new one is created at the next cycle.
+func (s *StrategyNeutralGrid) OnCancel(ctx Context, order engine.Order) {
+	if !s.creating {
+		s.levels = nil
+		s.idle = true
+	}
+}
+
+func (s *StrategyNeutralGrid) OnTimer(ctx Context, name string) {}
+
+// GETTERS
+func (s *StrategyNeutralGrid) GetType() StrategyType { return s.Type }
+
+func (s *StrategyNeutralGrid) GetSymbol() string { return s.Symbol }
+
+func (s *StrategyNeutralGrid) GetPositionSide() engine.PositionSideType { return s.PositionSide }
+
+func (s *StrategyNeutralGrid) GetStatus() string { return s.Status }
+
+func (s *StrategyNeutralGrid) GetParameters() StrategyParameters {
+	return toStrategyParameters(s.Parameters)
+}
+
+// SETTERS
+func (s *StrategyNeutralGrid) SetSymbol(symbol string) { s.Symbol = symbol }
+
+func (s *StrategyNeutralGrid) SetPositionSide(positionSide engine.PositionSideType) {
+	s.PositionSide = positionSide
+}
+
+func (s *StrategyNeutralGrid) SetStatus(status string) { s.Status = status }
+
+func (s *StrategyNeutralGrid) SetParameters(pars StrategyParameters) {
+	s.Parameters = NewNeutralGridParameters(pars)
+}
+
+func NewStrategyNeutralGrid(symbol string, positionSide engine.PositionSideType, pars StrategyParameters) *StrategyNeutralGrid {
+	return &StrategyNeutralGrid{
+		Type:         StrategyTypeNeutralGrid,
+		Symbol:       symbol,
+		PositionSide: positionSide,
+		Status:       "",
+		Parameters:   NewNeutralGridParameters(pars),
+	}
+}
+
+// NewNeutralGridParameters takes the parameters of the set from pars
+func NewNeutralGridParameters(pars StrategyParameters) NeutralGridParameters {
+	set := NeutralGridParameters{}
+	copyParameters(&set, pars)
+	return set
+}
+
+// PRIVATE METHODS
+func (s *StrategyNeutralGrid) validate() error {
+	if exposure := float64(s.Parameters.GN-1) * s.Parameters.OS; exposure > 100 {
+		return fmt.Errorf("grid orders need %.2f%% of the balance", exposure)
+	}
+	return nil
+}
+
+// createGrid closes the positions of the previous grid and places the new one
+// around the mark price, without inventory.
+func (s *StrategyNeutralGrid) createGrid(ctx Context) {
+	s.creating = true
+	defer func() { s.creating = false }()
+	for _, positionSide := range []engine.PositionSideType{engine.PositionSideLong, engine.PositionSideShort} {
+		cancelOrders(ctx, positionSide, false)
+		if size := ctx.Position(positionSide).Size; size > 0 {
+			ctx.PlaceOrder(*engine.NewOrderMarket(s.Symbol, closeSide(positionSide), positionSide, size))
+		}
+	}
+	price := ctx.MarkPrice()
+	s.levels = s.GridLevels(price)
+	s.size = common.RoundFloatWithPrecision((ctx.Capital()/price)*(s.Parameters.OS/100), 6)
+
+	// open a long below the level closest to the price, a short above it
+	nearest := sort.SearchFloat64s(s.levels, price) // first level >= price
+	if nearest > 0 && (nearest == len(s.levels) || price-s.levels[nearest-1] < s.levels[nearest]-price) {
+		nearest--
+	}
+	for i := range s.levels {
+		if i < nearest {
+			s.placeLevel(ctx, i, engine.SideBuy, engine.PositionSideLong)
+		} else if i > nearest {
+			s.placeLevel(ctx, i, engine.SideSell, engine.PositionSideShort)
+		}
+	}
+}
+
+func (s *StrategyNeutralGrid) placeLevel(ctx Context, level int, side engine.SideType, positionSide engine.PositionSideType) {
+	order := engine.NewOrderLimit(s.Symbol, side, positionSide, s.size, s.levels[level])
+	order.GridNumber = int64(level + 1)
+	order.IsTP = side == closeSide(positionSide)
+	ctx.PlaceOrder(*order)
+}
+
+// PRIVATE FUNCTIONS
+func closeSide(positionSide engine.PositionSideType) engine.SideType {
+	if positionSide == engine.PositionSideLong {
+		return engine.SideSell
+	}
+	return engine.SideBuy
+}
@@ -0,0 +1,353 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type ParameterType string
+
+const (
+	ParameterTypeInt   ParameterType = "int"
+	ParameterTypeFloat ParameterType = "float"
+)
+
+// ParameterSpec describes a parameter used by a strategy, by the name of its
+// field in the parameter set of the strategy and in StrategyParameters.
+type ParameterSpec struct {
+	Name        string        `json:"name"`
+	Type        ParameterType `json:"type"`
+	Min         float64       `json:"min"`
+	Max         float64       `json:"max"`
+	Default     float64       `json:"default"`
+	Optional    bool          `json:"optional"` // 0 disables the feature, omitted from descriptions when 0
+	Description string        `json:"description"`
+}
+
+// ParameterSet holds parameter values by name
+type ParameterSet map[string]float64
+
+func (ps ParameterSet) String() string {
+	names := make([]string, 0, len(ps))
+	for name := range ps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s %g", name, ps[name]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+var parameterDescriptions = map[string]string{
+	"GO": "number of grid orders",
+	"GS": "grid step, % distance of the first grid order",
+	"SF": "step factor, multiplier of the distance between consecutive grid orders",
+	"OS": "order size, % of the balance used by the first order",
+	"OF": "order factor, multiplier of the size between consecutive grid orders",
+	"TS": "take profit step, % from the entry price",
+	"SL": "stop loss, % from the entry price",
+	"ST": "stop loss threshold, % above the entry price under which the stop loss is used instead of the take profit",
+	"RT": "re-anchor the grid after RT minutes without position",
+	"RD": "re-anchor the grid when the price drifts RD% from the anchor",
+	"RE": "re-anchor the grid every RE minutes",
+	"GN": "number of grid levels",
+	"GU": "upper bound of the grid, % above the start price",
+	"GL": "lower bound of the grid, % below the start price",
+	"GM": "levels spacing, 0 arithmetic, 1 geometric",
+	"GT": "1 to trail the range when the price leaves it",
+	"VW": "volatility window, number of 1 minute bars",
+	"VM": "volatility estimate, 0 ATR, 1 standard deviation of the returns",
+	"VL": "minimum grid step and take profit, %",
+	"VH": "maximum grid step and take profit, %",
+}
+
+func intParameter(name string, min float64, max float64, def float64) ParameterSpec {
+	return ParameterSpec{Name: name, Type: ParameterTypeInt, Min: min, Max: max, Default: def, Description: parameterDescriptions[name]}
+}
+
+func floatParameter(name string, min float64, max float64, def float64) ParameterSpec {
+	return ParameterSpec{Name: name, Type: ParameterTypeFloat, Min: min, Max: max, Default: def, Description: parameterDescriptions[name]}
+}
+
+func optionalParameter(name string, max float64) ParameterSpec {
+	return ParameterSpec{Name: name, Type: ParameterTypeFloat, Min: 0, Max: max, Default: 0, Optional: true, Description: parameterDescriptions[name]}
+}
+
+func describe(spec ParameterSpec, description string) ParameterSpec {
+	spec.Description = description
+	return spec
+}
+
+// reanchorParameters are shared by the strategies using the grid lifecycle
+func reanchorParameters() []ParameterSpec {
+	return []ParameterSpec{optionalParameter("RT", 7*24*60), optionalParameter("RD", 100), optionalParameter("RE", 7*24*60)}
+}
+
+// ParameterNames returns the names of all the fields of StrategyParameters
+func ParameterNames() []string {
+	t := reflect.TypeOf(StrategyParameters{})
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		names = append(names, strings.Split(t.Field(i).Tag.Get("json"), ",")[0])
+	}
+	return names
+}
+
+// ParameterValues returns all the parameters by JSON name
+func ParameterValues(pars StrategyParameters) map[string]float64 {
+	values := make(map[string]float64)
+	for _, name := range ParameterNames() {
+		values[name], _ = GetParameter(pars, name) // names of the fields
+	}
+	return values
+}
+
+// GetParameter returns the value of a field of StrategyParameters by name
+func GetParameter(pars StrategyParameters, name string) (float64, error) {
+	field, err := parameterField(reflect.ValueOf(&pars).Elem(), name)
+	if err != nil {
+		return 0, err
+	}
+	return fieldValue(field), nil
+}
+
+// SetParameter sets a field of StrategyParameters by name
+func SetParameter(pars *StrategyParameters, name string, value float64) error {
+	field, err := parameterField(reflect.ValueOf(pars).Elem(), name)
+	if err != nil {
+		return err
+	}
+	return setFieldValue(field, name, value)
+}
+
+// ParametersOf returns the values of the parameters used by the strategy
+func ParametersOf(strategy StrategyWrapper) ParameterSet {
+	set := make(ParameterSet)
+	registration, err := Lookup(strategy.GetType())
+	if err != nil {
+		return set
+	}
+	for _, spec := range registration.Parameters {
+		set[spec.Name], _ = GetParameter(strategy.GetParameters(), spec.Name)
+	}
+	return set
+}
+
+// DefaultParameters returns the default parameters of a strategy type
+func DefaultParameters(strategyType StrategyType) (StrategyParameters, error) {
+	pars := StrategyParameters{}
+	registration, err := Lookup(strategyType)
+	if err != nil {
+		return pars, err
+	}
+	for _, spec := range registration.Parameters {
+		if err := SetParameter(&pars, spec.Name, spec.Default); err != nil {
+			return pars, err
+		}
+	}
+	return pars, nil
+}
+
+// FormatParameters describes the parameters used by the strategy
+func FormatParameters(strategy StrategyWrapper) string {
+	registration, err := Lookup(strategy.GetType())
+	if err != nil {
+		return strategy.GetParameters().String()
+	}
+	parts := make([]string, 0, len(registration.Parameters))
+	for _, spec := range registration.Parameters {
+		value, _ := GetParameter(strategy.GetParameters(), spec.Name)
+		if spec.Optional && value == 0 {
+			continue
+		}
+		if spec.Type == ParameterTypeInt {
+			parts = append(parts, fmt.Sprintf("%s %d", spec.Name, int64(value)))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s %.2f", spec.Name, value))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// validator is implemented by the strategies with constraints between parameters
+type validator interface {
+	validate() error
+}
+
+// Validate checks the parameters used by the strategy against its schema
+func Validate(strategy StrategyWrapper) error {
+	registration, err := Lookup(strategy.GetType())
+	if err != nil {
+		return err
+	}
+	for _, spec := range registration.Parameters {
+		value, err := GetParameter(strategy.GetParameters(), spec.Name)
+		if err != nil {
+			return err
+		}
+		if err := spec.check(value); err != nil {
+			return err
+		}
+	}
+	if v, ok := strategy.(validator); ok {
+		return v.validate()
+	}
+	return nil
+}
+
+// ValidateExposure checks that the market entry plus every grid order of a grid
+// strategy fit the balance at the given leverage, without being liquidated
+// before the last grid.
+func ValidateExposure(strategy StrategyWrapper, balance float64, price float64, leverage float64) error {
+	if _, ok := strategy.(GridStrategy); !ok {
+		return nil
+	}
+	analysis, err := AnalyzeGrid(strategy, balance, price, leverage)
+	if err != nil {
+		return err
+	}
+	if !analysis.Feasible {
+		return fmt.Errorf("grid not feasible: %s", analysis.Reason)
+	}
+	return nil
+}
+
+// ParameterGrid returns the Cartesian product of the ranges applied to base. Only
+// parameters used by the strategy type can be varied.
+func ParameterGrid(strategyType StrategyType, base StrategyParameters, ranges map[string][]float64) ([]StrategyParameters, error) {
+	registration, err := Lookup(strategyType)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(ranges))
+	for name := range ranges {
+		if _, ok := registration.spec(name); !ok {
+			return nil, fmt.Errorf("parameter %s is not used by strategy %s", name, strategyType)
+		}
+		if len(ranges[name]) == 0 {
+			return nil, fmt.Errorf("no value for parameter %s", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	grid := []StrategyParameters{base}
+	for _, name := range names {
+		next := make([]StrategyParameters, 0, len(grid)*len(ranges[name]))
+		for _, pars := range grid {
+			for _, value := range ranges[name] {
+				p := pars
+				if err := SetParameter(&p, name, value); err != nil {
+					return nil, err
+				}
+				next = append(next, p)
+			}
+		}
+		grid = next
+	}
+	return grid, nil
+}
+
+// PRIVATE FUNCTIONS
+func (spec ParameterSpec) check(value float64) error {
+	if spec.Optional && value == 0 {
+		return nil
+	}
+	if spec.Type == ParameterTypeInt && value != math.Trunc(value) {
+		return fmt.Errorf("parameter %s must be an integer, got %g", spec.Name, value)
+	}
+	if value < spec.Min || value > spec.Max {
+		return fmt.Errorf("parameter %s = %g out of bounds [%g, %g]: %s", spec.Name, value, spec.Min, spec.Max, spec.Description)
+	}
+	return nil
+}
+
+func (r Registration) spec(name string) (ParameterSpec, bool) {
+	for _, spec := range r.Parameters {
+		if spec.Name == name {
+			return spec, true
+		}
+	}
+	return ParameterSpec{}, false
+}
+
+func parameterField(pars reflect.Value, name string) (reflect.Value, error) {
+	field, ok := parameterFields(pars)[name]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("unknown parameter %s", name)
+	}
+	return field, nil
+}
+
+// parameterFields returns the fields of a parameter set by JSON name, including
+// the fields of the embedded sets
+func parameterFields(pars reflect.Value) map[string]reflect.Value {
+	fields := make(map[string]reflect.Value)
+	t := pars.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Anonymous {
+			for name, field := range parameterFields(pars.Field(i)) {
+				fields[name] = field
+			}
+			continue
+		}
+		fields[strings.Split(t.Field(i).Tag.Get("json"), ",")[0]] = pars.Field(i)
+	}
+	return fields
+}
+
+func fieldValue(field reflect.Value) float64 {
+	switch field.Kind() {
+	case reflect.Uint, reflect.Uint64, reflect.Uint32:
+		return float64(field.Uint())
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		return float64(field.Int())
+	default:
+		return field.Float()
+	}
+}
+
+func setFieldValue(field reflect.Value, name string, value float64) error {
+	switch field.Kind() {
+	case reflect.Uint, reflect.Uint64, reflect.Uint32:
+		if value < 0 || value != math.Trunc(value) {
+			return fmt.Errorf("parameter %s must be a non negative integer, got %g", name, value)
+		}
+		field.SetUint(uint64(value))
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		if value != math.Trunc(value) {
+			return fmt.Errorf("parameter %s must be an integer, got %g", name, value)
+		}
+		field.SetInt(int64(value))
+	default:
+		field.SetFloat(value)
+	}
+	return nil
+}
+
+// copyParameters copies the fields of the parameter set from to the fields of
+// the same name of the parameter set pointed by to, leaving the others unchanged
+func copyParameters(to interface{}, from interface{}) {
+	fromFields := parameterFields(reflect.Indirect(reflect.ValueOf(from)))
+	for name, field := range parameterFields(reflect.ValueOf(to).Elem()) {
+		if value, ok := fromFields[name]; ok {
+			if err := setFieldValue(field, name, fieldValue(value)); err != nil {
+				log.Panic(err)
+			}
+		}
+	}
+}
+
+// toStrategyParameters converts the parameter set of a strategy
+func toStrategyParameters(set interface{}) StrategyParameters {
+	pars := StrategyParameters{}
+	copyParameters(&pars, set)
+	return pars
+}
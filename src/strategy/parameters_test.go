@@ -0,0 +1,134 @@
+package strategy
+
+import (
+	"testing"
+
+	"example.com/gobot-simulator/src/engine"
+)
+
+func TestParameterGrid(t *testing.T) {
+	base, _ := DefaultParameters(StrategyTypeMartingala)
+	tests := []struct {
+		name         string
+		strategyType StrategyType
+		ranges       map[string][]float64
+		want         []ParameterSet // values of the varied parameters, in order
+		err          bool
+	}{
+		{
+			name:         "no range is the base",
+			strategyType: StrategyTypeMartingala,
+			ranges:       map[string][]float64{},
+			want:         []ParameterSet{{}},
+		},
+		{
+			name:         "product sorted by parameter name",
+			strategyType: StrategyTypeMartingala,
+			ranges:       map[string][]float64{"TS": {0.2, 0.4}, "GO": {3, 4, 5}},
+			want: []ParameterSet{
+				{"GO": 3, "TS": 0.2}, {"GO": 3, "TS": 0.4},
+				{"GO": 4, "TS": 0.2}, {"GO": 4, "TS": 0.4},
+				{"GO": 5, "TS": 0.2}, {"GO": 5, "TS": 0.4},
+			},
+		},
+		{name: "unknown strategy type", strategyType: "Unknown", ranges: map[string][]float64{"GO": {3}}, err: true},
+		{name: "parameter not used by the strategy", strategyType: StrategyTypeMartingala, ranges: map[string][]float64{"GN": {3}}, err: true},
+		{name: "no value", strategyType: StrategyTypeMartingala, ranges: map[string][]float64{"GO": {}}, err: true},
+		{name: "integer parameter with a fraction", strategyType: StrategyTypeMartingala, ranges: map[string][]float64{"GO": {2.5}}, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grid, err := ParameterGrid(tt.strategyType, base, tt.ranges)
+			if tt.err {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(grid) != len(tt.want) {
+				t.Fatalf("got %d parameter sets, want %d", len(grid), len(tt.want))
+			}
+			for i, pars := range grid {
+				values := ParameterValues(pars)
+				for name, value := range ParameterValues(base) {
+					want, varied := tt.want[i][name]
+					if !varied {
+						want = value
+					}
+					if values[name] != want {
+						t.Errorf("set %d: %s %g, want %g", i, name, values[name], want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name         string
+		strategyType StrategyType
+		set          ParameterSet
+		err          bool
+	}{
+		{name: "defaults", strategyType: StrategyTypeAntiMartingala},
+		{name: "ST 0 disables the threshold", strategyType: StrategyTypeAntiMartingala, set: ParameterSet{"ST": 0}},
+		{name: "out of bounds", strategyType: StrategyTypeMartingala, set: ParameterSet{"GS": 200}, err: true},
+		{name: "optional parameter disabled", strategyType: StrategyTypeMartingala, set: ParameterSet{"RT": 0}},
+		{name: "constraint between parameters", strategyType: StrategyTypeAdaptive, set: ParameterSet{"VL": 3, "VH": 2}, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pars, _ := DefaultParameters(tt.strategyType)
+			for name, value := range tt.set {
+				if err := SetParameter(&pars, name, value); err != nil {
+					t.Fatal(err)
+				}
+			}
+			registration, _ := Lookup(tt.strategyType)
+			err := Validate(registration.New("DOGE", engine.PositionSideLong, pars))
+			if (err != nil) != tt.err {
+				t.Errorf("got error %v, want error %t", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestParameterSets(t *testing.T) {
+	all := StrategyParameters{GO: 3, GS: 0.5, SF: 1, OS: 2, OF: 1.5, TS: 0.4, SL: 0.3, ST: 0.2, RT: 30, GN: 5, GU: 1, GL: 1, GT: 1, VW: 10, VM: 1, VL: 0.1, VH: 1}
+	tests := []struct {
+		name         string
+		strategyType StrategyType
+		want         StrategyParameters // parameters owned by the strategy
+	}{
+		{"Martingala", StrategyTypeMartingala, StrategyParameters{GO: 3, GS: 0.5, SF: 1, OS: 2, OF: 1.5, TS: 0.4, RT: 30}},
+		{"LogMartingala", StrategyTypeLogMartingala, StrategyParameters{GO: 3, GS: 0.5, SF: 1, OS: 2, OF: 1.5, TS: 0.4, RT: 30}},
+		{"AntiMartingala", StrategyTypeAntiMartingala, StrategyParameters{GO: 3, GS: 0.5, SF: 1, OS: 2, OF: 1.5, SL: 0.3, ST: 0.2, RT: 30}},
+		{"AdaptiveMartingala", StrategyTypeAdaptive, StrategyParameters{GO: 3, GS: 0.5, SF: 1, OS: 2, OF: 1.5, TS: 0.4, RT: 30, VW: 10, VM: 1, VL: 0.1, VH: 1}},
+		{"NeutralGrid", StrategyTypeNeutralGrid, StrategyParameters{OS: 2, GN: 5, GU: 1, GL: 1, GT: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy, err := NewStrategy(tt.strategyType, "DOGE", engine.PositionSideLong, all)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := (*strategy).GetParameters(); got != tt.want {
+				t.Errorf("got %s, want %s", got.String(), tt.want.String())
+			}
+		})
+	}
+}
+
+func TestDefaultParameters(t *testing.T) {
+	pars, err := DefaultParameters(StrategyTypeAntiMartingala)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pars.ST != 0.1 {
+		t.Errorf("got ST %g, want 0.1", pars.ST)
+	}
+}
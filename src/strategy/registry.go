@@ -0,0 +1,258 @@
+package strategy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"example.com/gobot-simulator/src/engine"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+type Constructor func(symbol string, positionSide engine.PositionSideType, pars StrategyParameters) StrategyWrapper
+
+// Registration is what a strategy type provides to be created by name
+type Registration struct {
+	Type       StrategyType
+	New        Constructor
+	Set        interface{} // zero value of the parameter set owned by the strategy, e.g. MartingalaParameters{}
+	Parameters []ParameterSpec
+}
+
+var registry = make(map[StrategyType]Registration)
+
+// Register makes a strategy type available to NewStrategy and to the loaders.
+// It is meant to be called from an init function, also by external packages.
+func Register(registration Registration) {
+	if registration.New == nil {
+		log.Panicf("Strategy type %s registered without constructor", registration.Type)
+	}
+	if _, ok := registry[registration.Type]; ok {
+		log.Panicf("Strategy type %s already registered", registration.Type)
+	}
+	if registration.Set == nil {
+		log.Panicf("Strategy type %s registered without parameter set", registration.Type)
+	}
+	fields := parameterFields(reflect.ValueOf(registration.Set))
+	for _, spec := range registration.Parameters {
+		if _, ok := fields[spec.Name]; !ok {
+			log.Panicf("Parameter %s of strategy type %s not in its parameter set", spec.Name, registration.Type)
+		}
+	}
+	registry[registration.Type] = registration
+}
+
+func Lookup(strategyType StrategyType) (Registration, error) {
+	registration, ok := registry[strategyType]
+	if !ok {
+		return Registration{}, fmt.Errorf("unknown strategy type %q, registered types are: %s", strategyType, strings.Join(typeNames(), ", "))
+	}
+	return registration, nil
+}
+
+func RegisteredTypes() []StrategyType {
+	types := make([]StrategyType, 0, len(registry))
+	for _, name := range typeNames() {
+		types = append(types, StrategyType(name))
+	}
+	return types
+}
+
+// UnmarshalStrategyJSON creates a strategy from its JSON representation, the
+// concrete type being selected by the "type" field. Missing parameters take the
+// default value of the strategy type, unknown fields are an error.
+func UnmarshalStrategyJSON(data []byte) (StrategyWrapper, error) {
+	var header struct {
+		Type StrategyType `json:"type"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+	strategy, err := newDefaultStrategy(header.Type)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(strategy); err != nil {
+		return nil, fmt.Errorf("strategy %s: %s", header.Type, err)
+	}
+	return strategy, nil
+}
+
+func MarshalStrategyJSON(strategy StrategyWrapper) ([]byte, error) {
+	return json.MarshalIndent(strategy, "", "  ")
+}
+
+// UnmarshalStrategyYAML creates a strategy from YAML, using the same fields as
+// the JSON representation.
+func UnmarshalStrategyYAML(data []byte) (StrategyWrapper, error) {
+	var header struct {
+		Type StrategyType `yaml:"type"`
+	}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+	strategy, err := newDefaultStrategy(header.Type)
+	if err != nil {
+		return nil, err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(strategy); err != nil {
+		return nil, fmt.Errorf("strategy %s: %s", header.Type, err)
+	}
+	return strategy, nil
+}
+
+func MarshalStrategyYAML(strategy StrategyWrapper) ([]byte, error) {
+	value, err := toGeneric(strategy)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(value)
+}
+
+// LoadStrategies reads a JSON or YAML file (by extension) containing either one
+// strategy or a list of strategies.
+func LoadStrategies(path string) ([]StrategyWrapper, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var items [][]byte
+	var unmarshal func([]byte) (StrategyWrapper, error)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		items, err = splitJSON(data)
+		unmarshal = UnmarshalStrategyJSON
+	case ".yaml", ".yml":
+		items, err = splitYAML(data)
+		unmarshal = UnmarshalStrategyYAML
+	default:
+		return nil, fmt.Errorf("unsupported strategy file extension %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	strategies := make([]StrategyWrapper, 0, len(items))
+	for i, item := range items {
+		strategy, err := unmarshal(item)
+		if err != nil {
+			return nil, fmt.Errorf("%s: strategy %d: %s", path, i, err)
+		}
+		strategies = append(strategies, strategy)
+	}
+	return strategies, nil
+}
+
+// SaveStrategies writes the strategies as a JSON or YAML list (by extension)
+func SaveStrategies(path string, strategies []StrategyWrapper) error {
+	items := make([]interface{}, 0, len(strategies))
+	for _, strategy := range strategies {
+		item, err := toGeneric(strategy)
+		if err != nil {
+			return err
+		}
+		items = append(items, item)
+	}
+
+	var data []byte
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		data, err = json.MarshalIndent(items, "", "  ")
+	case ".yaml", ".yml":
+		data, err = yaml.Marshal(items)
+	default:
+		return fmt.Errorf("unsupported strategy file extension %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// PRIVATE FUNCTIONS
+func typeNames() []string {
+	names := make([]string, 0, len(registry))
+	for strategyType := range registry {
+		names = append(names, string(strategyType))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newDefaultStrategy creates a strategy of the type with the default parameters
+func newDefaultStrategy(strategyType StrategyType) (StrategyWrapper, error) {
+	registration, err := Lookup(strategyType)
+	if err != nil {
+		return nil, err
+	}
+	defaults, err := DefaultParameters(strategyType)
+	if err != nil {
+		return nil, err
+	}
+	return registration.New("", "", defaults), nil
+}
+
+// splitJSON returns the items of a JSON list, or the value if it is not a list
+func splitJSON(data []byte) ([][]byte, error) {
+	var value json.RawMessage
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	var list []json.RawMessage
+	if err := json.Unmarshal(value, &list); err != nil {
+		return [][]byte{value}, nil
+	}
+	items := make([][]byte, len(list))
+	for i, item := range list {
+		items[i] = item
+	}
+	return items, nil
+}
+
+// splitYAML returns the items of a YAML list, or the document if it is not a list
+func splitYAML(data []byte) ([][]byte, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) == 0 {
+		return nil, nil
+	}
+	nodes := []*yaml.Node{document.Content[0]}
+	if document.Content[0].Kind == yaml.SequenceNode {
+		nodes = document.Content[0].Content
+	}
+	items := make([][]byte, len(nodes))
+	for i, node := range nodes {
+		item, err := yaml.Marshal(node)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+// toGeneric converts a strategy to maps and slices through its JSON tags
+func toGeneric(strategy StrategyWrapper) (interface{}, error) {
+	data, err := json.Marshal(strategy)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	err = json.Unmarshal(data, &value)
+	return value, err
+}
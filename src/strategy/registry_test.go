@@ -0,0 +1,159 @@
+package strategy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"example.com/gobot-simulator/src/engine"
+)
+
+func TestStrategyRoundTrip(t *testing.T) {
+	formats := []struct {
+		name      string
+		marshal   func(StrategyWrapper) ([]byte, error)
+		unmarshal func([]byte) (StrategyWrapper, error)
+	}{
+		{"JSON", MarshalStrategyJSON, UnmarshalStrategyJSON},
+		{"YAML", MarshalStrategyYAML, UnmarshalStrategyYAML},
+	}
+	antiMartingala, _ := DefaultParameters(StrategyTypeAntiMartingala)
+	antiMartingala.ST = 0
+	withST := antiMartingala
+	withST.ST = 0.5
+	reanchor, _ := DefaultParameters(StrategyTypeMartingala)
+	reanchor.RT, reanchor.RD, reanchor.RE = 30, 0.5, 60
+
+	tests := []struct {
+		name     string
+		strategy StrategyWrapper
+	}{
+		{"AntiMartingala ST 0", NewStrategyAntiMartingala("DOGE", engine.PositionSideLong, antiMartingala)},
+		{"AntiMartingala ST 0.5", NewStrategyAntiMartingala("DOGE", engine.PositionSideShort, withST)},
+		{"Martingala re-anchoring", NewStrategyMartingala("LTC", engine.PositionSideLong, reanchor)},
+	}
+	for _, strategyType := range RegisteredTypes() {
+		registration, _ := Lookup(strategyType)
+		pars, _ := DefaultParameters(strategyType)
+		tests = append(tests, struct {
+			name     string
+			strategy StrategyWrapper
+		}{string(strategyType) + " defaults", registration.New("DOGE", engine.PositionSideShort, pars)})
+	}
+
+	for _, format := range formats {
+		for _, tt := range tests {
+			t.Run(format.name+" "+tt.name, func(t *testing.T) {
+				data, err := format.marshal(tt.strategy)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := format.unmarshal(data)
+				if err != nil {
+					t.Fatalf("%s: %s", data, err)
+				}
+				if got.GetType() != tt.strategy.GetType() || got.GetSymbol() != tt.strategy.GetSymbol() ||
+					got.GetPositionSide() != tt.strategy.GetPositionSide() || got.GetParameters() != tt.strategy.GetParameters() {
+					t.Errorf("got %s %s %s, want %s %s %s", got.GetSymbol(), got.String(), got.GetParameters().String(),
+						tt.strategy.GetSymbol(), tt.strategy.String(), tt.strategy.GetParameters().String())
+				}
+			})
+		}
+	}
+}
+
+func TestUnmarshalStrategyDefaults(t *testing.T) {
+	defaults, _ := DefaultParameters(StrategyTypeAntiMartingala)
+	tests := []struct {
+		name string
+		data string
+		set  ParameterSet // expected values other than the defaults
+		err  bool
+	}{
+		{name: "missing parameters take the defaults", data: `{"type": "AntiMartingala", "symbol": "DOGE", "positionSide": "LONG"}`},
+		{name: "given parameters", data: `{"type": "AntiMartingala", "parameters": {"GO": 3, "ST": 0.2}}`, set: ParameterSet{"GO": 3, "ST": 0.2}},
+		{name: "explicit ST 0", data: `{"type": "AntiMartingala", "parameters": {"ST": 0}}`, set: ParameterSet{"ST": 0}},
+		{name: "unknown type", data: `{"type": "Unknown"}`, err: true},
+		{name: "invalid JSON", data: `{"type": `, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy, err := UnmarshalStrategyJSON([]byte(tt.data))
+			if tt.err {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := defaults
+			for name, value := range tt.set {
+				if err := SetParameter(&want, name, value); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if strategy.GetParameters() != want {
+				t.Errorf("got %s, want %s", strategy.GetParameters().String(), want.String())
+			}
+		})
+	}
+}
+
+func TestSaveLoadStrategies(t *testing.T) {
+	pars, _ := DefaultParameters(StrategyTypeAntiMartingala)
+	strategies := []StrategyWrapper{
+		NewStrategyAntiMartingala("DOGE", engine.PositionSideLong, pars),
+		NewStrategyMartingala("LTC", engine.PositionSideShort, StrategyParameters{GO: 3, GS: 0.5, SF: 1, OS: 2, OF: 1.5, TS: 0.4}),
+	}
+	for _, name := range []string{"strategies.json", "strategies.yaml"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := SaveStrategies(path, strategies); err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := LoadStrategies(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(loaded) != len(strategies) {
+				t.Fatalf("got %d strategies, want %d", len(loaded), len(strategies))
+			}
+			for i := range loaded {
+				if loaded[i].String() != strategies[i].String() || loaded[i].GetParameters() != strategies[i].GetParameters() {
+					t.Errorf("got %s, want %s", loaded[i].String(), strategies[i].String())
+				}
+			}
+		})
+	}
+}
+
+func TestUnknownFields(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data string
+		key  string // reported in the error
+	}{
+		{"JSON unknown field", "s.json", `{"type": "Martingala", "side": "LONG"}`, "side"},
+		{"JSON unknown parameter", "s.json", `[{"type": "Martingala", "parameters": {"GO": 3, "XX": 1}}]`, "XX"},
+		{"JSON parameter of another strategy", "s.json", `{"type": "Martingala", "parameters": {"ST": 0.2}}`, "ST"},
+		{"YAML unknown field", "s.yaml", "type: Martingala\nside: LONG\n", "side"},
+		{"YAML unknown parameter", "s.yaml", "- type: Martingala\n  parameters:\n    GO: 3\n    XX: 1\n", "XX"},
+		{"YAML parameter of another strategy", "s.yml", "type: NeutralGrid\nparameters:\n  GO: 3\n", "GO"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadStrategies(path)
+			if err == nil || !strings.Contains(err.Error(), tt.key) {
+				t.Errorf("got error %v, want an error reporting %s", err, tt.key)
+			}
+		})
+	}
+}
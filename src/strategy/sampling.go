@@ -0,0 +1,157 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"example.com/gobot-simulator/src/common"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type SamplingMethod string
+
+const (
+	SamplingRandom         SamplingMethod = "RANDOM"          // independent uniform draws
+	SamplingLatinHypercube SamplingMethod = "LATIN_HYPERCUBE" // one draw per stratum of every parameter
+)
+
+const sampledPrecision = 4 // decimals kept for the float parameters
+
+// ParameterRange is the interval a parameter is sampled from, the bounds of its
+// spec if both are zero.
+type ParameterRange struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// ParameterSpace maps the points of the unit hypercube, one dimension per
+// parameter sorted by name, to parameter sets applied to a base. Integer
+// parameters take every integer of their range with the same probability.
+type ParameterSpace struct {
+	base   StrategyParameters
+	names  []string
+	specs  []ParameterSpec
+	bounds []ParameterRange
+}
+
+// NewParameterSpace checks the ranges against the parameters used by the
+// strategy type.
+func NewParameterSpace(strategyType StrategyType, base StrategyParameters, ranges map[string]ParameterRange) (*ParameterSpace, error) {
+	registration, err := Lookup(strategyType)
+	if err != nil {
+		return nil, err
+	}
+	space := &ParameterSpace{base: base}
+	for name := range ranges {
+		space.names = append(space.names, name)
+	}
+	sort.Strings(space.names) // deterministic draws
+	for _, name := range space.names {
+		spec, ok := registration.spec(name)
+		if !ok {
+			return nil, fmt.Errorf("parameter %s is not used by strategy %s", name, strategyType)
+		}
+		r := ranges[name]
+		if r.Min == 0 && r.Max == 0 {
+			r = ParameterRange{Min: spec.Min, Max: spec.Max}
+		} else if r.Min > r.Max || r.Min < spec.Min || r.Max > spec.Max {
+			return nil, fmt.Errorf("range [%g, %g] of parameter %s out of bounds [%g, %g]", r.Min, r.Max, name, spec.Min, spec.Max)
+		}
+		space.specs = append(space.specs, spec)
+		space.bounds = append(space.bounds, r)
+	}
+	return space, nil
+}
+
+func (s *ParameterSpace) Names() []string { return s.names }
+
+func (s *ParameterSpace) Dimensions() int { return len(s.names) }
+
+// Parameters returns the parameter set of a point, coordinates are clamped to
+// [0, 1].
+func (s *ParameterSpace) Parameters(point []float64) StrategyParameters {
+	pars := s.base
+	for i, name := range s.names {
+		value := s.value(i, math.Min(math.Max(point[i], 0), 1))
+		if err := SetParameter(&pars, name, value); err != nil {
+			log.Panic(err) // names checked by NewParameterSpace
+		}
+	}
+	return pars
+}
+
+// Point returns the point of a parameter set, false if the set is not in the
+// space: other parameters differ from the base or values are out of the ranges.
+func (s *ParameterSpace) Point(pars StrategyParameters) ([]float64, bool) {
+	point := make([]float64, len(s.names))
+	for i, name := range s.names {
+		value, err := GetParameter(pars, name)
+		if err != nil {
+			return nil, false
+		}
+		r := s.bounds[i]
+		switch {
+		case value < r.Min || value > r.Max:
+			return nil, false
+		case s.specs[i].Type == ParameterTypeInt:
+			point[i] = (value - r.Min + 0.5) / (r.Max - r.Min + 1)
+		case r.Max > r.Min:
+			point[i] = (value - r.Min) / (r.Max - r.Min)
+		}
+	}
+	return point, s.Parameters(point) == pars
+}
+
+// ParameterSample returns n parameter sets applying to base values drawn from
+// the ranges, reproducible with the same seed. Only parameters used by the
+// strategy type can be sampled.
+func ParameterSample(strategyType StrategyType, base StrategyParameters, ranges map[string]ParameterRange, n int, seed int64, method SamplingMethod) ([]StrategyParameters, error) {
+	if method != SamplingRandom && method != SamplingLatinHypercube {
+		return nil, fmt.Errorf("unknown sampling method %s", method)
+	}
+	if n <= 0 {
+		return nil, fmt.Errorf("the number of samples must be positive, got %d", n)
+	}
+	space, err := NewParameterSpace(strategyType, base, ranges)
+	if err != nil {
+		return nil, err
+	}
+
+	random := rand.New(rand.NewSource(seed))
+	points := make([][]float64, n)
+	for i := range points {
+		points[i] = make([]float64, space.Dimensions())
+	}
+	for d := 0; d < space.Dimensions(); d++ {
+		strata := make([]int, n)
+		if method == SamplingLatinHypercube {
+			strata = random.Perm(n)
+		}
+		for i := range points {
+			u := random.Float64()
+			if method == SamplingLatinHypercube {
+				u = (float64(strata[i]) + u) / float64(n)
+			}
+			points[i][d] = u
+		}
+	}
+
+	samples := make([]StrategyParameters, n)
+	for i, point := range points {
+		samples[i] = space.Parameters(point)
+	}
+	return samples, nil
+}
+
+// PRIVATE METHODS
+// value maps u in [0, 1] to the range of the parameter of dimension i
+func (s *ParameterSpace) value(i int, u float64) float64 {
+	r := s.bounds[i]
+	if s.specs[i].Type == ParameterTypeInt {
+		return math.Min(math.Floor(r.Min+u*(r.Max-r.Min+1)), r.Max)
+	}
+	return common.RoundFloatWithPrecision(r.Min+u*(r.Max-r.Min), sampledPrecision)
+}
@@ -0,0 +1,114 @@
+package strategy
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParameterSample(t *testing.T) {
+	base, _ := DefaultParameters(StrategyTypeMartingala)
+	ranges := map[string]ParameterRange{"GO": {Min: 2, Max: 6}, "GS": {Min: 0.1, Max: 0.5}}
+	tests := []struct {
+		name   string
+		ranges map[string]ParameterRange
+		n      int
+		method SamplingMethod
+		err    bool
+	}{
+		{name: "random", ranges: ranges, n: 50, method: SamplingRandom},
+		{name: "latin hypercube", ranges: ranges, n: 20, method: SamplingLatinHypercube},
+		{name: "spec bounds if the range is zero", ranges: map[string]ParameterRange{"TS": {}}, n: 10, method: SamplingRandom},
+		{name: "unknown method", ranges: ranges, n: 10, method: "GRID", err: true},
+		{name: "no sample", ranges: ranges, n: 0, method: SamplingRandom, err: true},
+		{name: "range out of the spec", ranges: map[string]ParameterRange{"GS": {Min: 0, Max: 500}}, n: 10, method: SamplingRandom, err: true},
+		{name: "inverted range", ranges: map[string]ParameterRange{"GS": {Min: 0.5, Max: 0.1}}, n: 10, method: SamplingRandom, err: true},
+		{name: "parameter not used by the strategy", ranges: map[string]ParameterRange{"VW": {Min: 2, Max: 10}}, n: 10, method: SamplingRandom, err: true},
+	}
+	registration, _ := Lookup(StrategyTypeMartingala)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samples, err := ParameterSample(StrategyTypeMartingala, base, tt.ranges, tt.n, 1, tt.method)
+			if tt.err {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(samples) != tt.n {
+				t.Fatalf("got %d samples, want %d", len(samples), tt.n)
+			}
+			again, _ := ParameterSample(StrategyTypeMartingala, base, tt.ranges, tt.n, 1, tt.method)
+			for i, pars := range samples {
+				if pars != again[i] {
+					t.Fatalf("sample %d differs with the same seed", i)
+				}
+				for name, r := range tt.ranges {
+					spec, _ := registration.spec(name)
+					if r.Min == 0 && r.Max == 0 {
+						r = ParameterRange{Min: spec.Min, Max: spec.Max}
+					}
+					value, _ := GetParameter(pars, name)
+					if value < r.Min || value > r.Max || (spec.Type == ParameterTypeInt && value != math.Trunc(value)) {
+						t.Errorf("sample %d: %s %g out of [%g, %g]", i, name, value, r.Min, r.Max)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestParameterSampleLatinHypercube(t *testing.T) {
+	base, _ := DefaultParameters(StrategyTypeMartingala)
+	n := 10
+	samples, err := ParameterSample(StrategyTypeMartingala, base, map[string]ParameterRange{"GS": {Min: 1, Max: 11}, "TS": {Min: 1, Max: 11}}, n, 7, SamplingLatinHypercube)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"GS", "TS"} {
+		strata := make(map[int]bool)
+		for _, pars := range samples {
+			value, _ := GetParameter(pars, name)
+			strata[int(math.Min(value-1, 9.9999))] = true
+		}
+		if len(strata) != n {
+			t.Errorf("%s: %d strata of %d sampled", name, len(strata), n)
+		}
+	}
+}
+
+func TestParameterSpacePoint(t *testing.T) {
+	base, _ := DefaultParameters(StrategyTypeMartingala)
+	space, err := NewParameterSpace(StrategyTypeMartingala, base, map[string]ParameterRange{"GO": {Min: 2, Max: 6}, "GS": {Min: 0.1, Max: 0.5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inside := base
+	inside.GO, inside.GS = 4, 0.25
+	outside := inside
+	outside.GS = 0.7
+	otherBase := inside
+	otherBase.TS = base.TS + 1
+	tests := []struct {
+		name string
+		pars StrategyParameters
+		ok   bool
+	}{
+		{"in the space", inside, true},
+		{"out of the range", outside, false},
+		{"other parameter differs from the base", otherBase, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			point, ok := space.Point(tt.pars)
+			if ok != tt.ok {
+				t.Fatalf("got %t, want %t", ok, tt.ok)
+			}
+			if ok && space.Parameters(point) != tt.pars {
+				t.Errorf("point %v maps to %v, want %v", point, space.Parameters(point), tt.pars)
+			}
+		})
+	}
+}
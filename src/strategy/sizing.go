@@ -0,0 +1,82 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+)
+
+type SizingMode string
+
+const (
+	SizingBalance       SizingMode = "BALANCE"        // the wallet balance
+	SizingCompounding   SizingMode = "COMPOUNDING"    // the current equity
+	SizingFixedNotional SizingMode = "FIXED_NOTIONAL" // first order of Amount in quote
+	SizingFixedQuantity SizingMode = "FIXED_QUANTITY" // first order of Amount in base
+	SizingCapped        SizingMode = "CAPPED"         // the current equity up to Amount in quote
+)
+
+// Sizing sets the capital the strategies size their orders from, OS being a
+// percentage of it. The fixed modes set the first order instead, OS being
+// ignored. The reserve, in quote, is never allocated: the balance and
+// compounding modes size from the balance or equity above it, the fixed ones
+// stop when the equity falls below it. The zero value sizes from the whole
+// balance, as the strategies did before sizing modes existed.
+type Sizing struct {
+	Mode    SizingMode `json:"mode"`
+	Amount  float64    `json:"amount"`
+	Reserve float64    `json:"reserve"`
+}
+
+// Capital returns the capital available to the strategy given the account
+// balance, equity, the mark price and the order size OS of the strategy. For
+// the fixed modes it is the capital of which OS% is the first order.
+func (s Sizing) Capital(balance float64, equity float64, price float64, orderSize float64) float64 {
+	if s.Mode == "" || s.Mode == SizingBalance {
+		return math.Max(balance-s.Reserve, 0)
+	}
+	available := math.Max(equity-s.Reserve, 0)
+	if available == 0 || orderSize <= 0 {
+		return 0
+	}
+	switch s.Mode {
+	case SizingFixedNotional:
+		return s.Amount * 100 / orderSize
+	case SizingFixedQuantity:
+		return s.Amount * price * 100 / orderSize
+	case SizingCapped:
+		return math.Min(s.Amount, available)
+	default:
+		return available
+	}
+}
+
+func (s Sizing) Validate() error {
+	switch s.Mode {
+	case "", SizingBalance, SizingCompounding:
+	case SizingFixedNotional, SizingFixedQuantity, SizingCapped:
+		if s.Amount <= 0 {
+			return fmt.Errorf("sizing %s requires a positive amount", s.Mode)
+		}
+	default:
+		return fmt.Errorf("unknown sizing mode %s", s.Mode)
+	}
+	if s.Reserve < 0 {
+		return fmt.Errorf("sizing reserve %.2f is negative", s.Reserve)
+	}
+	return nil
+}
+
+func (s Sizing) String() string {
+	mode := s.Mode
+	if mode == "" {
+		mode = SizingBalance
+	}
+	str := string(mode)
+	if mode != SizingBalance && mode != SizingCompounding {
+		str += fmt.Sprintf(" %.2f", s.Amount)
+	}
+	if s.Reserve > 0 {
+		str += fmt.Sprintf(", reserve %.2f", s.Reserve)
+	}
+	return str
+}
@@ -0,0 +1,49 @@
+package strategy
+
+import (
+	"math"
+	"testing"
+
+	"example.com/gobot-simulator/src/common"
+	"example.com/gobot-simulator/src/engine"
+)
+
+func TestSizingCapital(t *testing.T) {
+	tests := []struct {
+		name    string
+		sizing  Sizing
+		balance float64
+		equity  float64
+		want    float64
+	}{
+		{"zero value is the balance", Sizing{}, 1000, 1200, 1000},
+		{"balance above the reserve", Sizing{Mode: SizingBalance, Reserve: 100}, 1000, 1200, 900},
+		{"compounding", Sizing{Mode: SizingCompounding}, 1000, 1200, 1200},
+		{"capped", Sizing{Mode: SizingCapped, Amount: 500}, 1000, 1200, 500},
+		{"fixed notional ignores OS", Sizing{Mode: SizingFixedNotional, Amount: 50}, 1000, 1200, 2500},
+		{"fixed quantity at the mark price", Sizing{Mode: SizingFixedQuantity, Amount: 2}, 1000, 1200, 10000},
+		{"fixed below the reserve", Sizing{Mode: SizingFixedNotional, Amount: 50, Reserve: 1500}, 1000, 1200, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// price 100, OS 2%
+			if got := tt.sizing.Capital(tt.balance, tt.equity, 100, 2); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("got %g, want %g", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSizingFixedQuantityOrder(t *testing.T) {
+	sizing := Sizing{Mode: SizingFixedQuantity, Amount: 3}
+	for _, os := range []float64{0.5, 1, 7} {
+		pars, _ := DefaultParameters(StrategyTypeMartingala)
+		pars.OS = os
+		s := NewStrategyMartingala("DOGE", engine.PositionSideLong, pars)
+		price := 0.123456
+		orders := s.BuyGridOrders(sizing.Capital(1000, 1000, price, os), price)
+		if got := common.RoundFloatWithPrecision(orders[0].Amount, 6); got != sizing.Amount {
+			t.Errorf("OS %g: got first order of %g, want %g", os, got, sizing.Amount)
+		}
+	}
+}
@@ -12,8 +12,13 @@ const (
 	StrategyTypeMartingala     StrategyType = "Martingala"
 	StrategyTypeLogMartingala  StrategyType = "LogMartingala"
 	StrategyTypeAntiMartingala StrategyType = "AntiMartingala"
+	StrategyTypeNeutralGrid    StrategyType = "NeutralGrid"
+	StrategyTypeAdaptive       StrategyType = "AdaptiveMartingala"
 )
 
+// StrategyParameters holds the parameters of every strategy type, as used by
+// the sweeps, the optimizers and the results store. Every strategy owns the
+// parameter set of its type and converts it from and to StrategyParameters.
 type StrategyParameters struct {
 	GO uint    `json:"GO"`
 	GS float64 `json:"GS"`
@@ -22,10 +27,42 @@ type StrategyParameters struct {
 	OF float64 `json:"OF"`
 	TS float64 `json:"TS"`
 	SL float64 `json:"SL"`
+	ST float64 `json:"ST"` // stop loss threshold of AntiMartingala
+
+	// grid re-anchoring while the position is flat (0 disables the rule)
+	RT float64 `json:"RT,omitempty"` // recreate the grid after RT minutes without position
+	RD float64 `json:"RD,omitempty"` // recreate the grid when the price drifts RD% from the grid anchor
+	RE float64 `json:"RE,omitempty"` // recreate the grid every RE minutes (clock aligned)
+
+	// neutral grid
+	GN uint    `json:"GN,omitempty"` // number of grid levels
+	GU float64 `json:"GU,omitempty"` // upper bound, % above the start price
+	GL float64 `json:"GL,omitempty"` // lower bound, % below the start price
+	GM uint    `json:"GM,omitempty"` // levels spacing, 0 arithmetic, 1 geometric
+	GT uint    `json:"GT,omitempty"` // 1 to trail the range when the price leaves it
+
+	// volatility adaptive grid, GS and TS are multiples of the volatility
+	VW uint    `json:"VW,omitempty"` // volatility window, number of 1 minute bars
+	VM uint    `json:"VM,omitempty"` // volatility estimate, 0 ATR, 1 standard deviation of the returns
+	VL float64 `json:"VL,omitempty"` // minimum grid step and take profit, %
+	VH float64 `json:"VH,omitempty"` // maximum grid step and take profit, %
 }
 
 func (sp StrategyParameters) String() string {
-	return fmt.Sprintf("GO %d, GS %.2f, SF %.2f, OS %.2f, OF %.2f, TS %.2f, SL %.2f", sp.GO, sp.GS, sp.SF, sp.OS, sp.OF, sp.TS, sp.SL)
+	str := fmt.Sprintf("GO %d, GS %.2f, SF %.2f, OS %.2f, OF %.2f, TS %.2f, SL %.2f", sp.GO, sp.GS, sp.SF, sp.OS, sp.OF, sp.TS, sp.SL)
+	if sp.ST != 0 {
+		str += fmt.Sprintf(", ST %.2f", sp.ST)
+	}
+	if sp.RT != 0 || sp.RD != 0 || sp.RE != 0 {
+		str += fmt.Sprintf(", RT %.2f, RD %.2f, RE %.2f", sp.RT, sp.RD, sp.RE)
+	}
+	if sp.VW != 0 {
+		str += fmt.Sprintf(", VW %d, VM %d, VL %.2f, VH %.2f", sp.VW, sp.VM, sp.VL, sp.VH)
+	}
+	if sp.GN != 0 {
+		str += fmt.Sprintf(", GN %d, GU %.2f, GL %.2f, GM %d, GT %d", sp.GN, sp.GU, sp.GL, sp.GM, sp.GT)
+	}
+	return str
 }
 
 type StrategyWrapper interface {
@@ -38,25 +75,33 @@ type StrategyWrapper interface {
 	SetPositionSide(positionSide engine.PositionSideType)
 	SetStatus(status string)
 	SetParameters(pars StrategyParameters)
-	BuyGridOrders(balance float64, startPrice float64) []*engine.Order
-	SellGridOrders(balance float64, startPrice float64) []*engine.Order
-	TakeProfitOrder(position engine.Position, currentGrid int64) *engine.Order
+	EventHandler
 	String() string
 }
 
+// twoSided is implemented by the strategies trading both position sides of the
+// account, as the neutral grid
+type twoSided interface {
+	TradesBothSides() bool
+}
+
+// TradesBothSides returns true if the strategy receives the fills of both
+// position sides and cannot share the account with another strategy
+func TradesBothSides(strategy StrategyWrapper) bool {
+	s, ok := strategy.(twoSided)
+	return ok && s.TradesBothSides()
+}
+
 type Strategy struct {
 	Type StrategyType `json:"type"`
 }
 
-func NewStrategy(strategyType StrategyType, symbol string, positionSide engine.PositionSideType, pars StrategyParameters) *StrategyWrapper {
-	var strategy StrategyWrapper
-	switch strategyType {
-	case StrategyTypeMartingala:
-		strategy = NewStrategyMartingala(symbol, positionSide, pars)
-	case StrategyTypeLogMartingala:
-		strategy = NewStrategyLogMartingala(symbol, positionSide, pars)
-	case StrategyTypeAntiMartingala:
-		strategy = NewStrategyAntiMartingala(symbol, positionSide, pars)
+// NewStrategy creates a strategy of any registered type
+func NewStrategy(strategyType StrategyType, symbol string, positionSide engine.PositionSideType, pars StrategyParameters) (*StrategyWrapper, error) {
+	registration, err := Lookup(strategyType)
+	if err != nil {
+		return nil, err
 	}
-	return &strategy
+	strategy := registration.New(symbol, positionSide, pars)
+	return &strategy, nil
 }
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strconv"
+	"strings"
+
+	"example.com/gobot-simulator/src/engine"
+	"example.com/gobot-simulator/src/scenario"
+	"example.com/gobot-simulator/src/simulator"
+	"example.com/gobot-simulator/src/store"
+	"example.com/gobot-simulator/src/strategy"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// stress runs a strategy on the scenarios of the library and exits with an
+// error if any of them fails the thresholds, e.g.
+// stress -strategy Martingala -side LONG -pars GO=5,GS=0.3 -tags crash -thresholds "maxDrawdownPerc<30,returnPerc>-10"
+func stress(args []string) {
+	flags := flag.NewFlagSet("stress", flag.ExitOnError)
+	libraryPath := flags.String("library", "../scenarios.json", "scenario library")
+	tags := flags.String("tags", "", "comma separated tags, only the scenarios having any of them")
+	strategyType := flags.String("strategy", string(strategy.StrategyTypeMartingala), "strategy type")
+	positionSide := flags.String("side", string(engine.PositionSideLong), "position side")
+	pars := flags.String("pars", "", "comma separated parameters overriding the defaults of the strategy, e.g. GO=5,GS=0.3")
+	thresholds := flags.String("thresholds", "maxDrawdownPerc<50", "comma separated conditions on the metrics that every scenario must satisfy")
+	leverage := flags.Float64("leverage", 1, "leverage of the account")
+	resultsFolder := flags.String("results", "../results/", "folder of the run results")
+	flags.Parse(args)
+
+	parameters, err := strategy.DefaultParameters(strategy.StrategyType(*strategyType))
+	if err != nil {
+		log.Panic(err)
+	}
+	for _, p := range splitList(*pars) {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 {
+			log.Panicf("Invalid parameter %s, expected NAME=value", p)
+		}
+		value, err := strconv.ParseFloat(kv[1], 64)
+		if err != nil {
+			log.Panicf("Invalid value of parameter %s: %s", p, err)
+		}
+		if err := strategy.SetParameter(&parameters, kv[0], value); err != nil {
+			log.Panic(err)
+		}
+	}
+	wrapper, err := strategy.NewStrategy(strategy.StrategyType(*strategyType), "", engine.PositionSideType(*positionSide), parameters)
+	if err != nil {
+		log.Panic(err)
+	}
+	conditions := make([]store.Condition, 0)
+	for _, s := range splitList(*thresholds) {
+		condition, err := store.ParseCondition(s)
+		if err != nil {
+			log.Panic(err)
+		}
+		conditions = append(conditions, condition)
+	}
+
+	library, err := scenario.OpenLibrary(*libraryPath)
+	if err != nil {
+		log.Panic(err)
+	}
+	selected := library.Select(splitList(*tags)...)
+	if len(selected) == 0 {
+		log.Panicf("No scenario in %s", library.Path())
+	}
+	data, err := library.Data(selected[0])
+	if err != nil {
+		log.Panic(err)
+	}
+	sim := simulator.NewSimulator(data, *resultsFolder)
+	sim.SetLeverage(*leverage)
+	for _, result := range sim.RunStress(*wrapper, library, selected, conditions) {
+		if !result.Passed {
+			os.Exit(1)
+		}
+	}
+}
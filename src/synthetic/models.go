@@ -0,0 +1,147 @@
+package synthetic
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// GBM is a geometric Brownian motion
+type GBM struct {
+	Drift      float64 `json:"drift"`
+	Volatility float64 `json:"volatility"`
+}
+
+func (m GBM) Validate() error {
+	if m.Volatility < 0 {
+		return fmt.Errorf("negative volatility %g", m.Volatility)
+	}
+	return nil
+}
+
+func (m GBM) LogReturns(random *rand.Rand, n int, dt float64) []float64 {
+	returns := make([]float64, n)
+	for i := range returns {
+		returns[i] = diffusion(random, m.Drift, m.Volatility, dt)
+	}
+	return returns
+}
+
+// Merton is a jump-diffusion: a geometric Brownian motion with jumps arriving
+// as a Poisson process, their log size being normal. The drift is compensated
+// for the mean jump, so that the expected return stays the one of Drift.
+type Merton struct {
+	Drift         float64 `json:"drift"`
+	Volatility    float64 `json:"volatility"`
+	JumpIntensity float64 `json:"jumpIntensity"` // jumps per year
+	JumpMean      float64 `json:"jumpMean"`      // of the log jump, negative for crashes
+	JumpStd       float64 `json:"jumpStd"`
+}
+
+func (m Merton) Validate() error {
+	if m.Volatility < 0 || m.JumpIntensity < 0 || m.JumpStd < 0 {
+		return fmt.Errorf("negative volatility, jump intensity or jump std in %+v", m)
+	}
+	return nil
+}
+
+func (m Merton) LogReturns(random *rand.Rand, n int, dt float64) []float64 {
+	kappa := math.Exp(m.JumpMean+m.JumpStd*m.JumpStd/2) - 1
+	drift := m.Drift - m.JumpIntensity*kappa
+	returns := make([]float64, n)
+	for i := range returns {
+		returns[i] = diffusion(random, drift, m.Volatility, dt)
+		for k := poisson(random, m.JumpIntensity*dt); k > 0; k-- {
+			returns[i] += m.JumpMean + m.JumpStd*random.NormFloat64()
+		}
+	}
+	return returns
+}
+
+// GARCH is a GARCH(1,1) volatility clustering: the variance of a return grows
+// with the square of the previous one and reverts to the long run variance.
+type GARCH struct {
+	Drift      float64 `json:"drift"`
+	Volatility float64 `json:"volatility"` // long run
+	Alpha      float64 `json:"alpha"`      // weight of the previous squared return
+	Beta       float64 `json:"beta"`       // weight of the previous variance
+}
+
+func (m GARCH) Validate() error {
+	if m.Volatility < 0 || m.Alpha < 0 || m.Beta < 0 || m.Alpha+m.Beta >= 1 {
+		return fmt.Errorf("GARCH needs a positive volatility and alpha + beta in [0, 1), got %+v", m)
+	}
+	return nil
+}
+
+func (m GARCH) LogReturns(random *rand.Rand, n int, dt float64) []float64 {
+	longRun := m.Volatility * m.Volatility * dt
+	omega := longRun * (1 - m.Alpha - m.Beta)
+	variance, shock := longRun, 0.0
+	returns := make([]float64, n)
+	for i := range returns {
+		variance = omega + m.Alpha*shock*shock + m.Beta*variance
+		shock = math.Sqrt(variance) * random.NormFloat64()
+		returns[i] = (m.Drift-m.Volatility*m.Volatility/2)*dt + shock
+	}
+	return returns
+}
+
+// Regime is a state of a RegimeSwitching market. A trend has a drift, a range
+// reverts to the price at which it started.
+type Regime struct {
+	Name          string        `json:"name"`
+	Drift         float64       `json:"drift"`
+	Volatility    float64       `json:"volatility"`
+	MeanReversion float64       `json:"meanReversion"` // speed per year of the reversion, 0 for none
+	MeanDuration  time.Duration `json:"meanDuration"`  // the duration being exponential
+}
+
+// RegimeSwitching moves between the regimes as a Markov chain, leaving a
+// regime for any other one with the same probability. It starts in the first
+// regime.
+type RegimeSwitching struct {
+	Regimes []Regime `json:"regimes"`
+}
+
+// TrendRange alternates up trends, ranges and down trends of the given mean
+// duration
+func TrendRange(drift float64, volatility float64, meanDuration time.Duration) RegimeSwitching {
+	return RegimeSwitching{Regimes: []Regime{
+		{Name: "up", Drift: drift, Volatility: volatility, MeanDuration: meanDuration},
+		{Name: "range", Volatility: volatility, MeanReversion: 500, MeanDuration: meanDuration},
+		{Name: "down", Drift: -drift, Volatility: volatility, MeanDuration: meanDuration},
+	}}
+}
+
+func (m RegimeSwitching) Validate() error {
+	if len(m.Regimes) == 0 {
+		return fmt.Errorf("no regime")
+	}
+	for _, r := range m.Regimes {
+		if r.Volatility < 0 || r.MeanReversion < 0 || r.MeanDuration <= 0 {
+			return fmt.Errorf("invalid regime %+v", r)
+		}
+	}
+	return nil
+}
+
+func (m RegimeSwitching) LogReturns(random *rand.Rand, n int, dt float64) []float64 {
+	current := 0
+	logPrice, anchor := 0.0, 0.0 // relative to the start price
+	returns := make([]float64, n)
+	for i := range returns {
+		if len(m.Regimes) > 1 && random.Float64() < dt/years(m.Regimes[current].MeanDuration) {
+			next := random.Intn(len(m.Regimes) - 1)
+			if next >= current {
+				next++
+			}
+			current, anchor = next, logPrice
+		}
+		r := m.Regimes[current]
+		returns[i] = diffusion(random, r.Drift, r.Volatility, dt) + r.MeanReversion*(anchor-logPrice)*dt
+		logPrice += returns[i]
+	}
+	return returns
+}
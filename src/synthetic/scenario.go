@@ -0,0 +1,70 @@
+package synthetic
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Shock is a scripted move of the price: MovePerc over Duration from At, then
+// RecoveryPerc of the move retraced over RecoveryDuration. The log price moves
+// linearly during both phases, a zero duration being one step.
+type Shock struct {
+	At               time.Duration `json:"at"`       // from the start of the path
+	MovePerc         float64       `json:"movePerc"` // -30 for a crash of 30%
+	Duration         time.Duration `json:"duration"`
+	RecoveryPerc     float64       `json:"recoveryPerc"` // of the move, in [0, 100]
+	RecoveryDuration time.Duration `json:"recoveryDuration"`
+}
+
+// FlashCrash drops the price by dropPerc over a duration, then recovers
+// recoveryPerc of the drop
+func FlashCrash(at time.Duration, dropPerc float64, duration time.Duration, recoveryPerc float64, recoveryDuration time.Duration) Shock {
+	return Shock{At: at, MovePerc: -dropPerc, Duration: duration, RecoveryPerc: recoveryPerc, RecoveryDuration: recoveryDuration}
+}
+
+// Scenario adds scripted shocks to the returns of a base model
+type Scenario struct {
+	Base   Model   `json:"-"`
+	Shocks []Shock `json:"shocks"`
+}
+
+func (s Scenario) Validate() error {
+	if s.Base == nil {
+		return fmt.Errorf("scenario without base model")
+	}
+	for _, shock := range s.Shocks {
+		if shock.At < 0 || shock.MovePerc <= -100 || shock.Duration < 0 || shock.RecoveryPerc < 0 || shock.RecoveryPerc > 100 || shock.RecoveryDuration < 0 {
+			return fmt.Errorf("invalid shock %+v", shock)
+		}
+	}
+	return s.Base.Validate()
+}
+
+func (s Scenario) LogReturns(random *rand.Rand, n int, dt float64) []float64 {
+	returns := s.Base.LogReturns(random, n, dt)
+	step := time.Duration(math.Round(dt * yearSeconds * float64(time.Second)))
+	for _, shock := range s.Shocks {
+		move := 1 + shock.MovePerc/100
+		recovered := 1 + shock.MovePerc/100*(1-shock.RecoveryPerc/100)
+		start := int(shock.At / step)
+		end := spread(returns, start, steps(shock.Duration, step), math.Log(move))
+		spread(returns, end, steps(shock.RecoveryDuration, step), math.Log(recovered/move))
+	}
+	return returns
+}
+
+// PRIVATE FUNCTIONS
+// spread adds the log move evenly to n returns from start, returning the
+// index after the last one
+func spread(returns []float64, start int, n int, logMove float64) int {
+	for i := start; i < start+n && i < len(returns); i++ {
+		returns[i] += logMove / float64(n)
+	}
+	return start + n
+}
+
+func steps(d time.Duration, step time.Duration) int {
+	return int(math.Max(1, math.Round(float64(d)/float64(step))))
+}
@@ -0,0 +1,91 @@
+package synthetic
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"example.com/gobot-simulator/src/common"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const yearSeconds = 365 * 24 * 3600 // drifts and volatilities are annualized
+
+// Path sets the time line of a generated series
+type Path struct {
+	Symbol   string        `json:"symbol"`
+	Start    time.Time     `json:"start"`
+	Step     time.Duration `json:"step"` // between two prices, 1s as the tick data
+	Duration time.Duration `json:"duration"`
+	Price    float64       `json:"price"` // at start
+	Seed     int64         `json:"seed"`
+}
+
+// Model generates the log returns of a price series. Models keep no state
+// between calls, the same random source always gives the same returns.
+type Model interface {
+	Validate() error
+	// LogReturns returns n log returns over steps of dt years
+	LogReturns(random *rand.Rand, n int, dt float64) []float64
+}
+
+// Generate returns the prices of the path following the model
+func Generate(path Path, model Model) (*common.SymbolData, error) {
+	if path.Step <= 0 || path.Duration < path.Step {
+		return nil, fmt.Errorf("invalid path step %s and duration %s", path.Step, path.Duration)
+	}
+	if path.Price <= 0 {
+		return nil, fmt.Errorf("invalid path start price %g", path.Price)
+	}
+	if err := model.Validate(); err != nil {
+		return nil, err
+	}
+
+	n := int(path.Duration / path.Step)
+	random := rand.New(rand.NewSource(path.Seed))
+	returns := model.LogReturns(random, n, years(path.Step))
+	data := &common.SymbolData{Symbol: path.Symbol, Data: make([]common.SymbolDataItem, n+1)}
+	data.Data[0] = common.SymbolDataItem{Time: path.Start, Price: path.Price}
+	logPrice := math.Log(path.Price)
+	for i, r := range returns {
+		logPrice += r
+		data.Data[i+1] = common.SymbolDataItem{Time: path.Start.Add(time.Duration(i+1) * path.Step), Price: math.Exp(logPrice)}
+	}
+	data.StartDate = data.Data[0].Time.UTC().String()
+	data.EndDate = data.Data[n].Time.UTC().String()
+	return data, nil
+}
+
+// MustGenerate is Generate panicking on invalid paths or models, for the
+// scenarios defined in code
+func MustGenerate(path Path, model Model) *common.SymbolData {
+	data, err := Generate(path, model)
+	if err != nil {
+		log.Panic(err)
+	}
+	return data
+}
+
+// PRIVATE FUNCTIONS
+// diffusion returns the log return of a geometric Brownian motion
+func diffusion(random *rand.Rand, drift float64, volatility float64, dt float64) float64 {
+	return (drift-volatility*volatility/2)*dt + volatility*math.Sqrt(dt)*random.NormFloat64()
+}
+
+func years(d time.Duration) float64 {
+	return d.Seconds() / yearSeconds
+}
+
+// poisson draws the number of events of intensity lambda, small enough for
+// the inversion of the distribution
+func poisson(random *rand.Rand, lambda float64) int {
+	limit, p := math.Exp(-lambda), random.Float64()
+	k := 0
+	for p > limit {
+		p *= random.Float64()
+		k++
+	}
+	return k
+}
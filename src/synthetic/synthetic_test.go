@@ -0,0 +1,106 @@
+package synthetic
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func testPath(seed int64) Path {
+	return Path{Symbol: "TEST", Start: start, Step: time.Minute, Duration: 7 * 24 * time.Hour, Price: 100, Seed: seed}
+}
+
+func TestGenerateReproducible(t *testing.T) {
+	tests := []struct {
+		name  string
+		model Model
+	}{
+		{"GBM", GBM{Drift: 0.1, Volatility: 0.8}},
+		{"Merton", Merton{Drift: 0.1, Volatility: 0.8, JumpIntensity: 50, JumpMean: -0.05, JumpStd: 0.02}},
+		{"GARCH", GARCH{Volatility: 0.8, Alpha: 0.1, Beta: 0.85}},
+		{"regime switching", TrendRange(2, 0.8, 24*time.Hour)},
+		{"scenario", Scenario{Base: GBM{Volatility: 0.8}, Shocks: []Shock{FlashCrash(time.Hour, 20, time.Minute, 50, time.Hour)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := MustGenerate(testPath(1), tt.model)
+			again := MustGenerate(testPath(1), tt.model)
+			other := MustGenerate(testPath(2), tt.model)
+			if !reflect.DeepEqual(first, again) {
+				t.Errorf("same seed generated different prices")
+			}
+			if reflect.DeepEqual(first.Data, other.Data) {
+				t.Errorf("different seeds generated the same prices")
+			}
+			if n := int(testPath(1).Duration/time.Minute) + 1; len(first.Data) != n {
+				t.Fatalf("got %d prices, want %d", len(first.Data), n)
+			}
+			for i, item := range first.Data {
+				if !item.Time.Equal(start.Add(time.Duration(i)*time.Minute)) || item.Price <= 0 || math.IsNaN(item.Price) {
+					t.Fatalf("invalid price %g at %s, index %d", item.Price, item.Time, i)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateInvalid(t *testing.T) {
+	valid := testPath(1)
+	tests := []struct {
+		name  string
+		path  func(p *Path)
+		model Model
+	}{
+		{"no step", func(p *Path) { p.Step = 0 }, GBM{}},
+		{"duration shorter than a step", func(p *Path) { p.Duration = time.Second }, GBM{}},
+		{"no start price", func(p *Path) { p.Price = 0 }, GBM{}},
+		{"negative volatility", func(p *Path) {}, GBM{Volatility: -1}},
+		{"GARCH not stationary", func(p *Path) {}, GARCH{Volatility: 0.5, Alpha: 0.5, Beta: 0.5}},
+		{"no regime", func(p *Path) {}, RegimeSwitching{}},
+		{"scenario without base", func(p *Path) {}, Scenario{}},
+		{"crash of 100%", func(p *Path) {}, Scenario{Base: GBM{}, Shocks: []Shock{FlashCrash(0, 100, time.Minute, 0, 0)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := valid
+			tt.path(&path)
+			if _, err := Generate(path, tt.model); err == nil {
+				t.Errorf("got no error")
+			}
+		})
+	}
+}
+
+func TestGBMDrift(t *testing.T) {
+	path := Path{Start: start, Step: 24 * time.Hour, Duration: 365 * 24 * time.Hour, Price: 100}
+	data := MustGenerate(path, GBM{Drift: 0.5})
+	if got, want := data.Data[len(data.Data)-1].Price, 100*math.Exp(0.5); math.Abs(got-want) > 1e-6 {
+		t.Errorf("got final price %g without volatility, want %g", got, want)
+	}
+}
+
+func TestFlashCrash(t *testing.T) {
+	path := Path{Start: start, Step: time.Minute, Duration: 4 * time.Hour, Price: 100}
+	crash := FlashCrash(time.Hour, 30, 10*time.Minute, 50, time.Hour)
+	data := MustGenerate(path, Scenario{Base: GBM{}, Shocks: []Shock{crash}})
+	tests := []struct {
+		name  string
+		at    time.Duration
+		price float64
+	}{
+		{"before the crash", time.Hour, 100},
+		{"bottom of the crash", 70 * time.Minute, 70},
+		{"half recovered", 130 * time.Minute, 85},
+		{"after the recovery", 4 * time.Hour, 85},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := data.Data[int(tt.at/time.Minute)].Price; math.Abs(got-tt.price) > 1e-9 {
+				t.Errorf("got %g, want %g", got, tt.price)
+			}
+		})
+	}
+}
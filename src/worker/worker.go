@@ -1,24 +1,39 @@
 package worker
 
 import (
-	"math"
+	"fmt"
+	"sort"
 	"time"
 
 	"example.com/gobot-simulator/src/common"
 	"example.com/gobot-simulator/src/engine"
+	"example.com/gobot-simulator/src/filter"
+	"example.com/gobot-simulator/src/indicator"
 	"example.com/gobot-simulator/src/strategy"
 
 	log "github.com/sirupsen/logrus"
 )
 
+// Worker drives a strategy: it forwards the exchange events to the strategy and
+// acts as its context for order management.
 type Worker struct {
-	strategy            strategy.StrategyWrapper
-	exchangeAPI         *engine.ExchangeAPI
-	lastTimeCreatedGrid time.Time // this is used in AntiMartingala to recreate the grid if after some time has still no position
+	strategy    strategy.StrategyWrapper
+	exchangeAPI *engine.ExchangeAPI
+	timers      map[string]time.Time
+	indicators  *indicator.Manager
+	filters     []filter.Filter
+	sizing      strategy.Sizing
+	idleSince   time.Time // zero if not waiting for the entry conditions
+
+	EventCallback func(common.SimulatorEvent)
+	IdleCallback  func(positionSide engine.PositionSideType, idle time.Duration) // at the end of every idle period
 }
 
 func NewWorker() *Worker {
-	return &Worker{}
+	return &Worker{
+		timers:     make(map[string]time.Time),
+		indicators: indicator.NewManager(),
+	}
 }
 
 // PUBLIC METHODS
@@ -26,106 +41,202 @@ func (w *Worker) SetStrategy(strategy strategy.StrategyWrapper) {
 	w.strategy = strategy
 }
 
+func (w *Worker) GetStrategy() strategy.StrategyWrapper {
+	return w.strategy
+}
+
 func (w *Worker) SetExchangeAPI(api *engine.ExchangeAPI) {
 	w.exchangeAPI = api
 }
 
-func (w *Worker) HandlePositionUpdate(position engine.Position) {
-	if position.Size == 0 {
-		w.StartStrategy()
-	} else {
-		w.setTakeProfit(position)
-	}
+// SetEntryFilters sets the conditions checked before every new cycle
+func (w *Worker) SetEntryFilters(filters ...filter.Filter) {
+	w.filters = filters
+}
+
+// SetSizing sets how the capital given to the strategy is computed
+func (w *Worker) SetSizing(sizing strategy.Sizing) {
+	w.sizing = sizing
 }
 
 func (w *Worker) StartStrategy() {
 	log.Debug("Worker: start strategy")
-	// w.lastTimeCreatedGrid = w.exchangeAPI.CurrentTime()
-	symbol := w.strategy.GetSymbol()
-	balance := w.exchangeAPI.Balance()
-	markPrice := w.exchangeAPI.MarkPrice()
-	s0 := (balance / markPrice) * (w.strategy.GetParameters().OS / 100)
-	if math.IsNaN(s0) {
-		log.Panic("Order size is NaN")
+	w.timers = make(map[string]time.Time)
+	w.indicators = indicator.NewManager()
+	w.idleSince = time.Time{}
+	for _, f := range w.filters {
+		f.Start(w)
 	}
+	w.strategy.OnStart(w)
+}
 
-	// TODO change this: worker should not know the strategy
-	positionSide := w.strategy.GetPositionSide()
-	w.createGrid(positionSide, balance, markPrice)
-
-	// TODO this is valid only for Martingala
-	var order engine.Order
-	if positionSide == engine.PositionSideLong {
-		order = *engine.NewOrderMarket(symbol, engine.SideBuy, engine.PositionSideLong, s0)
-	} else {
-		order = *engine.NewOrderMarket(symbol, engine.SideSell, engine.PositionSideShort, s0)
+// StopStrategy closes the current idle period at the end of the run
+func (w *Worker) StopStrategy() {
+	if w.strategy == nil {
+		return
 	}
-	w.placeOrder(order)
+	w.endIdle()
 }
 
-func (w *Worker) HandleGridRecreation(time time.Time) {
-	position := w.exchangeAPI.Position(w.strategy.GetPositionSide())
-	if position.Size > 0 {
-		w.lastTimeCreatedGrid = time
+func (w *Worker) HandleTick(tick common.SymbolDataItem) {
+	if w.strategy == nil {
 		return
 	}
+	w.indicators.Update(tick)
+	w.strategy.OnTick(w, tick)
+	w.fireTimers(tick.Time)
+}
 
-	diffMinutes := time.Sub(w.lastTimeCreatedGrid).Minutes()
-	if diffMinutes > 3 {
-		log.Debug("Worker recreates grid because more than 3 mins have passed without having a position")
-		w.StartStrategy()
+func (w *Worker) HandleFill(fill engine.Fill) {
+	// in hedge mode each side is driven by its own worker, unless the strategy
+	// trades both sides
+	if w.strategy == nil || !w.drives(fill.Position.PositionSide) {
+		return
+	}
+	for _, f := range w.filters {
+		f.OnFill(w, fill)
 	}
+	w.strategy.OnFill(w, fill)
 }
 
-// PRIVATE METHODS
-func (w *Worker) createGrid(positionSide engine.PositionSideType, balance float64, startPrice float64) {
-	w.cancelGrid(positionSide)
-
-	// get orders and execute
-	var orders []*engine.Order
-	if positionSide == engine.PositionSideLong {
-		orders = w.strategy.BuyGridOrders(balance, startPrice)
-	} else {
-		orders = w.strategy.SellGridOrders(balance, startPrice)
+// Flatten cancels the open orders of the strategy, notifying it, and closes
+// its positions at market
+func (w *Worker) Flatten() {
+	if w.strategy == nil {
+		return
 	}
-
-	for _, order := range orders {
-		w.placeOrder(*order)
+	for _, positionSide := range []engine.PositionSideType{engine.PositionSideLong, engine.PositionSideShort} {
+		if !w.drives(positionSide) {
+			continue
+		}
+		for _, order := range w.OpenOrders(positionSide) {
+			w.CancelOrder(order)
+		}
+		position := w.Position(positionSide)
+		if position.Size == 0 {
+			continue
+		}
+		side := engine.SideSell
+		if positionSide == engine.PositionSideShort {
+			side = engine.SideBuy
+		}
+		w.PlaceOrder(*engine.NewOrderMarket(position.Symbol, side, positionSide, position.Size))
 	}
 }
 
-func (w *Worker) cancelGrid(positionSide engine.PositionSideType) {
-	openOrders := w.exchangeAPI.OpenOrders(positionSide)
-	for _, o := range openOrders {
-		w.cancelOrder(o)
-	}
+// CONTEXT
+func (w *Worker) Symbol() string { return w.strategy.GetSymbol() }
+
+func (w *Worker) PositionSide() engine.PositionSideType { return w.strategy.GetPositionSide() }
+
+func (w *Worker) Time() time.Time { return w.exchangeAPI.CurrentTime() }
+
+func (w *Worker) MarkPrice() float64 { return w.exchangeAPI.MarkPrice() }
+
+func (w *Worker) Balance() float64 { return w.exchangeAPI.Balance() }
+
+func (w *Worker) Capital() float64 {
+	return w.sizing.Capital(w.exchangeAPI.Balance(), w.exchangeAPI.Equity(), w.exchangeAPI.MarkPrice(), w.strategy.GetParameters().OS)
 }
 
-func (w *Worker) setTakeProfit(position engine.Position) {
-	w.cancelLastTakeProfit(position.PositionSide)
-	gridReached := w.exchangeAPI.GridReached(position.PositionSide)
-	order := w.strategy.TakeProfitOrder(position, gridReached)
-	log.Debugf("Worker: set take profit order %s", order.String())
-	w.placeOrder(*order)
+func (w *Worker) Position(positionSide engine.PositionSideType) engine.Position {
+	return w.exchangeAPI.Position(positionSide)
 }
 
-func (w *Worker) cancelLastTakeProfit(positionSide engine.PositionSideType) {
-	openOrders := w.exchangeAPI.OpenOrders(positionSide)
-	for _, order := range openOrders {
-		if order.IsTP {
-			w.cancelOrder(order)
-		}
-	}
+func (w *Worker) GridReached(positionSide engine.PositionSideType) int64 {
+	return w.exchangeAPI.GridReached(positionSide)
 }
 
-func (w *Worker) cancelOrder(order engine.Order) bool {
-	return w.exchangeAPI.CancelOrder(order)
+func (w *Worker) OpenOrders(positionSide engine.PositionSideType) []engine.Order {
+	return w.exchangeAPI.OpenOrders(positionSide)
 }
 
-func (w *Worker) placeOrder(order engine.Order) {
+func (w *Worker) PlaceOrder(order engine.Order) {
 	// round order price and amount to 6 digits
 	order.Amount = common.RoundFloatWithPrecision(order.Amount, 6)
 	order.Price = common.RoundFloatWithPrecision(order.Price, 6)
 
 	w.exchangeAPI.PlaceOrder(order)
 }
+
+func (w *Worker) CancelOrder(order engine.Order) bool {
+	if !w.exchangeAPI.CancelOrder(order) {
+		return false
+	}
+	w.strategy.OnCancel(w, order)
+	return true
+}
+
+func (w *Worker) SetTimer(name string, at time.Time) {
+	w.timers[name] = at
+}
+
+func (w *Worker) CancelTimer(name string) {
+	delete(w.timers, name)
+}
+
+func (w *Worker) LogEvent(eventType string, message string) {
+	now := w.exchangeAPI.CurrentTime()
+	event := common.SimulatorEvent{Date: now.String(), Timestamp: now.Unix(), Symbol: w.strategy.GetSymbol(), Type: eventType, Message: message}
+	log.Debugf("Worker: event %s", event.String())
+	if w.EventCallback != nil {
+		w.EventCallback(event)
+	}
+}
+
+func (w *Worker) Indicators() *indicator.Manager { return w.indicators }
+
+func (w *Worker) StartCycle() bool {
+	allowed, reason := filter.AllowAll(w, w.filters)
+	if w.Capital() <= 0 {
+		allowed, reason = false, "no capital above the reserve"
+	}
+	if !allowed {
+		if w.idleSince.IsZero() {
+			w.idleSince = w.Time()
+			w.LogEvent(common.EventEntryBlocked, fmt.Sprintf("%s entry blocked: %s", w.PositionSide(), reason))
+		}
+		return false
+	}
+	if idle := w.endIdle(); idle > 0 {
+		w.LogEvent(common.EventEntryAllowed, fmt.Sprintf("%s entry allowed after %s", w.PositionSide(), idle))
+	}
+	for _, f := range w.filters {
+		f.CycleStarted(w)
+	}
+	return true
+}
+
+// PRIVATE METHODS
+// drives returns true if the strategy trades the position side: in hedge mode
+// each side is driven by its own worker, unless the strategy trades both sides
+func (w *Worker) drives(positionSide engine.PositionSideType) bool {
+	return positionSide == w.strategy.GetPositionSide() || strategy.TradesBothSides(w.strategy)
+}
+
+// endIdle closes the current idle period and returns its duration
+func (w *Worker) endIdle() time.Duration {
+	if w.idleSince.IsZero() {
+		return 0
+	}
+	idle := w.Time().Sub(w.idleSince)
+	w.idleSince = time.Time{}
+	if w.IdleCallback != nil {
+		w.IdleCallback(w.PositionSide(), idle)
+	}
+	return idle
+}
+
+func (w *Worker) fireTimers(now time.Time) {
+	names := make([]string, 0)
+	for name, at := range w.timers {
+		if !now.Before(at) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names) // deterministic order
+	for _, name := range names {
+		delete(w.timers, name)
+		w.strategy.OnTimer(w, name)
+	}
+}
@@ -0,0 +1,246 @@
+package worker
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"example.com/gobot-simulator/src/common"
+	"example.com/gobot-simulator/src/engine"
+	"example.com/gobot-simulator/src/filter"
+	"example.com/gobot-simulator/src/strategy"
+)
+
+var t0 = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// testStrategy records the events forwarded by the worker
+type testStrategy struct {
+	strategy.StrategyWrapper
+	positionSide engine.PositionSideType
+	fills        []engine.PositionSideType
+	cancels      []engine.Order
+	timers       []string
+	ticks        int
+}
+
+func (s *testStrategy) GetSymbol() string                        { return "TEST" }
+func (s *testStrategy) GetPositionSide() engine.PositionSideType { return s.positionSide }
+func (s *testStrategy) GetParameters() strategy.StrategyParameters {
+	return strategy.StrategyParameters{OS: 1}
+}
+func (s *testStrategy) OnStart(ctx strategy.Context) {}
+func (s *testStrategy) OnTick(ctx strategy.Context, tick common.SymbolDataItem) {
+	s.ticks++
+}
+func (s *testStrategy) OnFill(ctx strategy.Context, fill engine.Fill) {
+	s.fills = append(s.fills, fill.Position.PositionSide)
+}
+func (s *testStrategy) OnCancel(ctx strategy.Context, order engine.Order) {
+	s.cancels = append(s.cancels, order)
+}
+func (s *testStrategy) OnTimer(ctx strategy.Context, name string) {
+	s.timers = append(s.timers, name)
+}
+
+// twoSidedStrategy receives the fills of both position sides
+type twoSidedStrategy struct {
+	*testStrategy
+}
+
+func (s twoSidedStrategy) TradesBothSides() bool { return true }
+
+// testAPI keeps the orders placed and the positions of the account
+type testAPI struct {
+	now       time.Time
+	orders    []engine.Order
+	placed    []engine.Order
+	positions map[engine.PositionSideType]engine.Position
+}
+
+func newTestAPI() *testAPI {
+	return &testAPI{now: t0, positions: make(map[engine.PositionSideType]engine.Position)}
+}
+
+func (a *testAPI) api() *engine.ExchangeAPI {
+	return &engine.ExchangeAPI{
+		PlaceOrder: func(order engine.Order) { a.placed = append(a.placed, order) },
+		CancelOrder: func(order engine.Order) bool {
+			for i, o := range a.orders {
+				if o.ID == order.ID {
+					a.orders = append(a.orders[:i], a.orders[i+1:]...)
+					return true
+				}
+			}
+			return false
+		},
+		OpenOrders: func(positionSide engine.PositionSideType) []engine.Order {
+			orders := make([]engine.Order, 0)
+			for _, o := range a.orders {
+				if o.PositionSide == positionSide {
+					orders = append(orders, o)
+				}
+			}
+			return orders
+		},
+		MarkPrice:   func() float64 { return 100 },
+		Balance:     func() float64 { return 1000 },
+		GridReached: func(engine.PositionSideType) int64 { return 0 },
+		Position: func(positionSide engine.PositionSideType) engine.Position {
+			position := a.positions[positionSide]
+			position.PositionSide = positionSide
+			return position
+		},
+		CurrentTime: func() time.Time { return a.now },
+	}
+}
+
+func newTestWorker(s strategy.StrategyWrapper, api *testAPI) *Worker {
+	w := NewWorker()
+	w.SetExchangeAPI(api.api())
+	w.SetStrategy(s)
+	w.StartStrategy()
+	return w
+}
+
+func fill(positionSide engine.PositionSideType) engine.Fill {
+	return engine.Fill{Position: engine.Position{PositionSide: positionSide}, Time: t0}
+}
+
+func TestHandleFill(t *testing.T) {
+	long, short := engine.PositionSideLong, engine.PositionSideShort
+	tests := []struct {
+		name     string
+		strategy func(s *testStrategy) strategy.StrategyWrapper
+		side     engine.PositionSideType
+		want     []engine.PositionSideType
+	}{
+		{"long strategy", func(s *testStrategy) strategy.StrategyWrapper { return s }, long, []engine.PositionSideType{long}},
+		{"short strategy", func(s *testStrategy) strategy.StrategyWrapper { return s }, short, []engine.PositionSideType{short}},
+		{"two sided strategy", func(s *testStrategy) strategy.StrategyWrapper { return twoSidedStrategy{s} }, long, []engine.PositionSideType{long, short}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &testStrategy{positionSide: tt.side}
+			w := newTestWorker(tt.strategy(s), newTestAPI())
+			w.HandleFill(fill(long))
+			w.HandleFill(fill(short))
+			if !reflect.DeepEqual(s.fills, tt.want) {
+				t.Errorf("got fills of %v, want %v", s.fills, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleFillFilters(t *testing.T) {
+	s := &testStrategy{positionSide: engine.PositionSideLong}
+	api := newTestAPI()
+	w := newTestWorker(s, api)
+	w.SetEntryFilters(filter.NewCooldown(time.Hour))
+
+	// a loss of the other side does not reach the filters of the worker
+	w.HandleFill(engine.Fill{Position: engine.Position{PositionSide: engine.PositionSideShort}, RealizedProfit: -1, Time: t0})
+	if !w.StartCycle() {
+		t.Fatalf("cycle blocked by a fill of the other side")
+	}
+	w.HandleFill(engine.Fill{Position: engine.Position{PositionSide: engine.PositionSideLong}, RealizedProfit: -1, Time: t0})
+	if w.StartCycle() {
+		t.Errorf("cycle allowed during the cooldown")
+	}
+}
+
+func TestTimers(t *testing.T) {
+	s := &testStrategy{positionSide: engine.PositionSideLong}
+	api := newTestAPI()
+	w := newTestWorker(s, api)
+	w.SetTimer("b", t0.Add(2*time.Minute))
+	w.SetTimer("a", t0.Add(2*time.Minute))
+	w.SetTimer("cancelled", t0.Add(time.Minute))
+	w.CancelTimer("cancelled")
+
+	tests := []struct {
+		name string
+		at   time.Duration
+		want []string
+	}{
+		{"before the timers", time.Minute, nil},
+		{"at the timers, by name", 2 * time.Minute, []string{"a", "b"}},
+		{"fired once", 3 * time.Minute, []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api.now = t0.Add(tt.at)
+			w.HandleTick(common.SymbolDataItem{Time: api.now, Price: 100})
+			if !reflect.DeepEqual(s.timers, tt.want) {
+				t.Errorf("got timers %v, want %v", s.timers, tt.want)
+			}
+		})
+	}
+	if s.ticks != len(tests) {
+		t.Errorf("got %d ticks, want %d", s.ticks, len(tests))
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	long, short := engine.PositionSideLong, engine.PositionSideShort
+	tests := []struct {
+		name     string
+		strategy func(s *testStrategy) strategy.StrategyWrapper
+		cancels  int
+		closed   []engine.SideType
+	}{
+		{"long strategy", func(s *testStrategy) strategy.StrategyWrapper { return s }, 1, []engine.SideType{engine.SideSell}},
+		{"two sided strategy", func(s *testStrategy) strategy.StrategyWrapper { return twoSidedStrategy{s} }, 2, []engine.SideType{engine.SideSell, engine.SideBuy}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newTestAPI()
+			api.orders = []engine.Order{{ID: "1", PositionSide: long}, {ID: "2", PositionSide: short}}
+			api.positions[long] = engine.Position{Symbol: "TEST", Size: 2}
+			api.positions[short] = engine.Position{Symbol: "TEST", Size: 3}
+			s := &testStrategy{positionSide: long}
+			w := newTestWorker(tt.strategy(s), api)
+
+			w.Flatten()
+			if len(s.cancels) != tt.cancels || len(api.orders) != 2-tt.cancels {
+				t.Errorf("got %d cancels notified, %d orders left, want %d cancels", len(s.cancels), len(api.orders), tt.cancels)
+			}
+			if len(api.placed) != len(tt.closed) {
+				t.Fatalf("got %d orders placed, want %d", len(api.placed), len(tt.closed))
+			}
+			for i, order := range api.placed {
+				size := api.positions[order.PositionSide].Size
+				if order.Type != engine.OrderTypeMarket || order.Side != tt.closed[i] || order.Amount != size {
+					t.Errorf("got %s %s %s %g, want a market %s of %g", order.Type, order.PositionSide, order.Side, order.Amount, tt.closed[i], size)
+				}
+			}
+		})
+	}
+}
+
+func TestStartCycleIdle(t *testing.T) {
+	s := &testStrategy{positionSide: engine.PositionSideLong}
+	api := newTestAPI()
+	w := newTestWorker(s, api)
+	w.SetEntryFilters(filter.NewTimeWindow(nil, 1, 2))
+	events := make([]string, 0)
+	w.EventCallback = func(event common.SimulatorEvent) { events = append(events, event.Type) }
+	var idle time.Duration
+	w.IdleCallback = func(positionSide engine.PositionSideType, d time.Duration) { idle += d }
+
+	for _, at := range []time.Duration{0, 30 * time.Minute} {
+		api.now = t0.Add(at)
+		if w.StartCycle() {
+			t.Fatalf("cycle allowed out of the time window")
+		}
+	}
+	api.now = t0.Add(time.Hour)
+	if !w.StartCycle() {
+		t.Fatalf("cycle blocked in the time window")
+	}
+	if want := []string{common.EventEntryBlocked, common.EventEntryAllowed}; !reflect.DeepEqual(events, want) {
+		t.Errorf("got events %v, want %v", events, want)
+	}
+	if idle != time.Hour {
+		t.Errorf("got idle time %s, want 1h", idle)
+	}
+}